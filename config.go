@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Environment variables of the chaincode server, see chaincode.env
+const (
+	envID           = "CHAINCODE_ID"
+	envAddress      = "CHAINCODE_SERVER_ADDRESS"
+	envTLSDisabled  = "CHAINCODE_TLS_DISABLED"
+	envTLSKey       = "CHAINCODE_TLS_KEY"
+	envTLSCert      = "CHAINCODE_TLS_CERT"
+	envClientCACert = "CHAINCODE_CLIENT_CA_CERT"
+)
+
+// setting of the chaincode server, set by its environment variable or its flag
+type setting struct {
+	env          string
+	flag         string
+	defaultValue string
+	usage        string
+	secret       bool // the value is redacted in the report when it holds the PEM itself
+}
+
+var settings = []setting{
+	{env: envID, flag: "id", usage: "package id assigned to the chaincode on install"},
+	{env: envAddress, flag: "address", usage: "host:port where the peer connects to the chaincode server"},
+	{env: envTLSDisabled, flag: "tls-disabled", defaultValue: "true", usage: "disables TLS between the peer and the chaincode server"},
+	{env: envTLSKey, flag: "tls-key", usage: "PEM private key of the server, a file or the PEM itself", secret: true},
+	{env: envTLSCert, flag: "tls-cert", usage: "PEM certificate of the server, a file or the PEM itself"},
+	{env: envClientCACert, flag: "client-ca-cert", usage: "PEM root certificates that verify the peer, a file or the PEM itself"},
+}
+
+// configValue value of a setting and where it was taken from
+type configValue struct {
+	value  string
+	source string
+}
+
+// serverConfig effective configuration of the chaincode server
+type serverConfig struct {
+	CCID    string
+	Address string
+	TLS     shim.TLSProperties
+
+	CheckOnly bool // validate the configuration and exit, without starting the server
+
+	values  map[string]configValue
+	details map[string]string // description of the crypto material, by environment variable
+}
+
+// loadConfig returns the configuration of the chaincode server. Each setting takes the first value found in
+// the command line flags, the environment, the file given by the -env-file flag and its default value.
+// The whole configuration is validated at now, and every problem found is reported in the error.
+func loadConfig(args []string, lookupEnv func(string) (string, bool), now time.Time) (*serverConfig, error) {
+	fs := flag.NewFlagSet("chaincode", flag.ContinueOnError)
+	envFile := fs.String("env-file", "", "chaincode.env style file with the settings, overridden by the environment")
+	checkOnly := fs.Bool("check", false, "validates the configuration, prints it and exits")
+	flags := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		usage := s.usage + " (" + s.env
+		if s.defaultValue != "" {
+			usage += ", " + s.defaultValue + " by default"
+		}
+		flags[s.flag] = fs.String(s.flag, "", usage+")")
+	}
+	err := fs.Parse(args)
+	if err != nil {
+		return nil, err
+	} else if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments %q", fs.Args())
+	}
+
+	config := serverConfig{
+		CheckOnly: *checkOnly,
+		values:    make(map[string]configValue, len(settings)),
+		details:   make(map[string]string),
+	}
+	for _, s := range settings {
+		if s.defaultValue != "" {
+			config.values[s.env] = configValue{s.defaultValue, "default"}
+		}
+	}
+	if *envFile != "" {
+		fileValues, err := readEnvFile(*envFile)
+		if err != nil {
+			return nil, err
+		}
+		for env, value := range fileValues {
+			config.values[env] = configValue{value, "file " + *envFile}
+		}
+	}
+	for _, s := range settings {
+		if value, ok := lookupEnv(s.env); ok {
+			config.values[s.env] = configValue{value, "environment"}
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name {
+				config.values[s.env] = configValue{*flags[s.flag], "flag -" + s.flag}
+			}
+		}
+	})
+
+	err = config.validate(now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// readEnvFile returns the settings of a chaincode.env style file: KEY=VALUE lines, with # comments
+func readEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the settings file: %s", err)
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(line, "export "), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, number)
+		}
+		env, value := strings.TrimSpace(parts[0]), parts[1]
+		if findSetting(env) == nil {
+			return nil, fmt.Errorf("%s:%d: unknown setting %s", path, number, env)
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		values[env] = value
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading the settings file: %s", err)
+	}
+
+	return values, nil
+}
+
+func findSetting(env string) *setting {
+	for i := range settings {
+		if settings[i].env == env {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+// validate checks every setting and loads the crypto material, the error lists all the problems found
+func (c *serverConfig) validate(now time.Time) error {
+	var problems []string
+	fail := func(env, format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", env, fmt.Sprintf(format, args...)))
+	}
+
+	c.CCID = strings.TrimSpace(c.values[envID].value)
+	if c.CCID == "" {
+		fail(envID, "required")
+	}
+
+	c.Address = strings.TrimSpace(c.values[envAddress].value)
+	if c.Address == "" {
+		fail(envAddress, "required")
+	} else if _, port, err := net.SplitHostPort(c.Address); err != nil {
+		fail(envAddress, "expected host:port, got %q", c.Address)
+	} else if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+		fail(envAddress, "invalid port %q", port)
+	}
+
+	// a typo must not enable or disable TLS silently
+	disabled, err := strconv.ParseBool(strings.TrimSpace(c.values[envTLSDisabled].value))
+	if err != nil {
+		fail(envTLSDisabled, "expected true or false, got %q", c.values[envTLSDisabled].value)
+	}
+	c.TLS.Disabled = disabled
+	if err == nil && !disabled {
+		c.TLS.Cert, c.TLS.Key = c.validateKeyPair(now, fail)
+		if c.values[envClientCACert].value != "" {
+			c.TLS.ClientCACerts = c.validateCertificates(envClientCACert, now, fail)
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid chaincode server configuration:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// validateKeyPair returns the PEM certificate and key of the server, which must match
+func (c *serverConfig) validateKeyPair(now time.Time, fail func(env, format string, args ...interface{})) ([]byte, []byte) {
+	certPEM := c.validateCertificates(envTLSCert, now, fail)
+	keyPEM, err := c.readPEM(envTLSKey)
+	if err != nil {
+		fail(envTLSKey, "%s", err)
+		return certPEM, nil
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil || !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		fail(envTLSKey, "no PEM private key found")
+		return certPEM, nil
+	}
+	c.details[envTLSKey] = block.Type
+	if certPEM != nil {
+		if _, err = tls.X509KeyPair(certPEM, keyPEM); err != nil {
+			fail(envTLSKey, "does not match %s: %s", envTLSCert, err)
+		}
+	}
+
+	return certPEM, keyPEM
+}
+
+// validateCertificates returns the PEM certificates of the setting env, all of them in force at now
+func (c *serverConfig) validateCertificates(env string, now time.Time, fail func(env, format string, args ...interface{})) []byte {
+	certPEM, err := c.readPEM(env)
+	if err != nil {
+		fail(env, "%s", err)
+		return nil
+	}
+
+	var subjects []string
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		} else if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			fail(env, "invalid certificate: %s", err)
+			return nil
+		}
+		if now.Before(cert.NotBefore) {
+			fail(env, "certificate %s is not valid until %s", cert.Subject, cert.NotBefore.Format(time.RFC3339))
+		} else if now.After(cert.NotAfter) {
+			fail(env, "certificate %s expired on %s", cert.Subject, cert.NotAfter.Format(time.RFC3339))
+		}
+		subjects = append(subjects, fmt.Sprintf("%s, expires %s", cert.Subject, cert.NotAfter.Format(lus.DateLayout)))
+	}
+	if len(subjects) == 0 {
+		fail(env, "no PEM certificate found")
+		return nil
+	}
+	c.details[env] = strings.Join(subjects, "; ")
+
+	return certPEM
+}
+
+// readPEM returns the PEM of the setting env, given inline or as the path of a file
+func (c *serverConfig) readPEM(env string) ([]byte, error) {
+	value := strings.TrimSpace(c.values[env].value)
+	if value == "" {
+		return nil, fmt.Errorf("required when TLS is enabled")
+	} else if isInlinePEM(value) {
+		return []byte(c.values[env].value), nil
+	}
+	content, err := ioutil.ReadFile(value)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the crypto file: %s", err)
+	}
+	return content, nil
+}
+
+func isInlinePEM(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), "-----BEGIN")
+}
+
+// report writes the effective configuration, with the source of every setting. Inline private keys
+// are redacted, and the crypto material is described instead of printed.
+func (c *serverConfig) report(w io.Writer) {
+	fmt.Fprintln(w, "chaincode server configuration:")
+	for _, s := range settings {
+		v, ok := c.values[s.env]
+		value := v.value
+		switch {
+		case !ok:
+			value, v.source = "(not set)", "default"
+		case isInlinePEM(value) && s.secret:
+			value = "[redacted]"
+		case isInlinePEM(value):
+			value = "(inline PEM)"
+		}
+		if c.TLS.Disabled && (s.env == envTLSKey || s.env == envTLSCert || s.env == envClientCACert) && ok {
+			value += ", ignored while TLS is disabled"
+		} else if detail, found := c.details[s.env]; found {
+			value += ", " + detail
+		}
+		fmt.Fprintf(w, "  %-26s %s [%s]\n", s.env, value, v.source)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2022, 11, 22, 10, 30, 0, 0, time.UTC)
+
+// testEnv returns a lookup function over the given environment variables
+func testEnv(values map[string]string) func(string) (string, bool) {
+	return func(env string) (string, bool) {
+		value, ok := values[env]
+		return value, ok
+	}
+}
+
+// testKeyPair returns a PEM self-signed certificate and its PEM key, valid from notBefore to notAfter
+func testKeyPair(t *testing.T, name string, notBefore, notAfter time.Time) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func expectConfigError(t *testing.T, err error, want string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("expected error %q, got none", want)
+	} else if !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error %q, got %q", want, err)
+	}
+}
+
+func TestLoadConfigSources(t *testing.T) {
+	envFile := writeTestFile(t, "chaincode.env", `# settings of the test
+CHAINCODE_ID=cc_1.0:abc
+export CHAINCODE_SERVER_ADDRESS="127.0.0.1:9999"
+CHAINCODE_TLS_DISABLED=false
+`)
+	env := testEnv(map[string]string{envTLSDisabled: "true", envAddress: "0.0.0.0:7052"})
+
+	config, err := loadConfig([]string{"-env-file", envFile, "-address", "0.0.0.0:9999"}, env, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.CCID != "cc_1.0:abc" || config.Address != "0.0.0.0:9999" || !config.TLS.Disabled {
+		t.Errorf("config = %+v", config)
+	}
+
+	var report bytes.Buffer
+	config.report(&report)
+	for _, want := range []string{
+		"CHAINCODE_ID               cc_1.0:abc [file " + envFile + "]",
+		"CHAINCODE_SERVER_ADDRESS   0.0.0.0:9999 [flag -address]",
+		"CHAINCODE_TLS_DISABLED     true [environment]",
+		"CHAINCODE_TLS_KEY          (not set) [default]",
+	} {
+		if !strings.Contains(report.String(), want) {
+			t.Errorf("report without %q:\n%s", want, report.String())
+		}
+	}
+
+	// TLS is disabled by default
+	config, err = loadConfig(nil, testEnv(map[string]string{envID: "cc_1.0:abc", envAddress: ":9999"}), testNow)
+	if err != nil {
+		t.Fatal(err)
+	} else if !config.TLS.Disabled {
+		t.Error("TLS enabled by default")
+	}
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	valid := map[string]string{envID: "cc_1.0:abc", envAddress: "127.0.0.1:9999"}
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		file string
+		want string
+	}{
+		{"missing id", map[string]string{envAddress: "127.0.0.1:9999"}, "", "CHAINCODE_ID: required"},
+		{"missing address", map[string]string{envID: "cc_1.0:abc"}, "", "CHAINCODE_SERVER_ADDRESS: required"},
+		{"address without port", map[string]string{envID: "cc_1.0:abc", envAddress: "127.0.0.1"}, "", `expected host:port, got "127.0.0.1"`},
+		{"port out of range", map[string]string{envID: "cc_1.0:abc", envAddress: "127.0.0.1:70000"}, "", `invalid port "70000"`},
+		{"boolean typo", map[string]string{envTLSDisabled: "flase"}, "", `CHAINCODE_TLS_DISABLED: expected true or false, got "flase"`},
+		{"setting typo", nil, "CHAINCODE_TLS_DISABLE=true\n", "chaincode.env:1: unknown setting CHAINCODE_TLS_DISABLE"},
+		{"line without value", nil, "# comment\nCHAINCODE_ID\n", "chaincode.env:2: expected KEY=VALUE"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			env := make(map[string]string)
+			if test.env[envID] == "" && test.env[envAddress] == "" {
+				for k, v := range valid {
+					env[k] = v
+				}
+			}
+			for k, v := range test.env {
+				env[k] = v
+			}
+			var args []string
+			if test.file != "" {
+				args = []string{"-env-file", writeTestFile(t, "chaincode.env", test.file)}
+			}
+			_, err := loadConfig(args, testEnv(env), testNow)
+			expectConfigError(t, err, test.want)
+		})
+	}
+
+	// every problem is reported at once
+	_, err := loadConfig(nil, testEnv(map[string]string{envTLSDisabled: "no"}), testNow)
+	expectConfigError(t, err, "CHAINCODE_ID: required\n  CHAINCODE_SERVER_ADDRESS: required\n  CHAINCODE_TLS_DISABLED")
+}
+
+func TestLoadConfigTLS(t *testing.T) {
+	cert, key := testKeyPair(t, "chaincode", testNow.AddDate(-1, 0, 0), testNow.AddDate(1, 0, 0))
+	otherCert, otherKey := testKeyPair(t, "peer root", testNow.AddDate(-1, 0, 0), testNow.AddDate(1, 0, 0))
+	expiredCert, _ := testKeyPair(t, "expired", testNow.AddDate(-2, 0, 0), testNow.AddDate(0, 0, -1))
+	certFile := writeTestFile(t, "cert.pem", cert)
+
+	load := func(values map[string]string) (*serverConfig, error) {
+		env := map[string]string{envID: "cc_1.0:abc", envAddress: "127.0.0.1:9999", envTLSDisabled: "false"}
+		for k, v := range values {
+			env[k] = v
+		}
+		return loadConfig(nil, testEnv(env), testNow)
+	}
+
+	// files or inline PEM, inline keys are redacted in the report
+	config, err := load(map[string]string{envTLSCert: certFile, envTLSKey: key, envClientCACert: otherCert})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.TLS.Disabled || string(config.TLS.Cert) != cert || string(config.TLS.Key) != key || string(config.TLS.ClientCACerts) != otherCert {
+		t.Errorf("TLS properties = %+v", config.TLS)
+	}
+	var report bytes.Buffer
+	config.report(&report)
+	if strings.Contains(report.String(), "PRIVATE KEY-----") || !strings.Contains(report.String(), "CHAINCODE_TLS_KEY          [redacted], EC PRIVATE KEY [environment]") {
+		t.Errorf("key not redacted:\n%s", report.String())
+	}
+	if !strings.Contains(report.String(), certFile+", CN=chaincode, expires 2023-11-22") {
+		t.Errorf("certificate not described:\n%s", report.String())
+	}
+
+	_, err = load(nil)
+	expectConfigError(t, err, "CHAINCODE_TLS_CERT: required when TLS is enabled\n  CHAINCODE_TLS_KEY: required when TLS is enabled")
+	_, err = load(map[string]string{envTLSCert: filepath.Join(t.TempDir(), "missing.pem"), envTLSKey: key})
+	expectConfigError(t, err, "CHAINCODE_TLS_CERT: error while reading the crypto file")
+	_, err = load(map[string]string{envTLSCert: cert, envTLSKey: otherKey})
+	expectConfigError(t, err, "CHAINCODE_TLS_KEY: does not match CHAINCODE_TLS_CERT")
+	_, err = load(map[string]string{envTLSCert: cert, envTLSKey: cert})
+	expectConfigError(t, err, "CHAINCODE_TLS_KEY: no PEM private key found")
+	_, err = load(map[string]string{envTLSCert: writeTestFile(t, "cert.pem", "not a certificate"), envTLSKey: key})
+	expectConfigError(t, err, "CHAINCODE_TLS_CERT: no PEM certificate found")
+	_, err = load(map[string]string{envTLSCert: cert, envTLSKey: key, envClientCACert: expiredCert})
+	expectConfigError(t, err, "CHAINCODE_CLIENT_CA_CERT: certificate CN=expired expired on 2022-11-21")
+}
@@ -0,0 +1,196 @@
+package certificate
+
+import (
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/signatory"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// chainStatus returns the progress of a chain of signatures from the names of its signers
+func chainStatus(secretary, dean, rector string) StateValidation {
+	switch {
+	case rector != "":
+		return Valid
+	case dean != "":
+		return SignedSD
+	case secretary != "":
+		return SignedS
+	}
+	return New
+}
+
+// issuerChain returns the chain of signatures of the issuer with given id, the emitter if id is empty,
+// and its index in CoIssuers, -1 for the emitter
+func (a *Asset) issuerChain(id string) (CoIssuer, int, error) {
+	if id == "" || id == a.EmitterID {
+		return CoIssuer{
+			EmitterID:           a.EmitterID,
+			Emitter:             a.Emitter,
+			FacultyID:           a.FacultyID,
+			SecretaryValidating: a.SecretaryValidating,
+			DeanValidating:      a.DeanValidating,
+			RectorValidating:    a.RectorValidating,
+			SecretaryID:         a.SecretaryID,
+			DeanID:              a.DeanID,
+			RectorID:            a.RectorID,
+			Status:              chainStatus(a.SecretaryValidating, a.DeanValidating, a.RectorValidating),
+		}, -1, nil
+	}
+	for i, coIssuer := range a.CoIssuers {
+		if coIssuer.EmitterID == id {
+			return coIssuer, i, nil
+		}
+	}
+	return CoIssuer{}, 0, lus.Errorf(lus.ErrorNotIssuer, id, a.ID)
+}
+
+// setIssuerChain stores the chain of signatures of the issuer at index, -1 for the emitter
+func (a *Asset) setIssuerChain(index int, chain CoIssuer) {
+	if index >= 0 {
+		a.CoIssuers[index] = chain
+		return
+	}
+	a.SecretaryValidating, a.SecretaryID = chain.SecretaryValidating, chain.SecretaryID
+	a.DeanValidating, a.DeanID = chain.DeanValidating, chain.DeanID
+	a.RectorValidating, a.RectorID = chain.RectorValidating, chain.RectorID
+}
+
+// issuanceStatus returns the status of the least advanced chain of signatures of the issuers
+func (a *Asset) issuanceStatus() StateValidation {
+	status := chainStatus(a.SecretaryValidating, a.DeanValidating, a.RectorValidating)
+	for _, coIssuer := range a.CoIssuers {
+		if coIssuer.Status < status {
+			status = coIssuer.Status
+		}
+	}
+	return status
+}
+
+// signed reports whether any issuer signed the certificate, its status only follows the least advanced chain
+func (a *Asset) signed() bool {
+	if a.SecretaryValidating != "" || a.DeanValidating != "" || a.RectorValidating != "" {
+		return true
+	}
+	for _, coIssuer := range a.CoIssuers {
+		if coIssuer.SecretaryValidating != "" || coIssuer.DeanValidating != "" || coIssuer.RectorValidating != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNextSignature returns an error unless role signs next in the chain: secretary, dean, rector
+func (chain *CoIssuer) checkNextSignature(role ValidatorType) error {
+	if !(role == Secretary && chain.Status == New) &&
+		!(role == Dean && chain.Status == SignedS) &&
+		!(role == Rector && chain.Status == SignedSD) {
+		return lus.Errorf(lus.ErrorInconsistentValidation)
+	}
+	return nil
+}
+
+// addSignature adds the signature of signer, holding role, to the chain
+func (chain *CoIssuer) addSignature(role ValidatorType, signer *signatory.Signatory) {
+	switch role {
+	case Secretary:
+		chain.SecretaryValidating, chain.SecretaryID = signer.Name, signer.ID
+	case Dean:
+		chain.DeanValidating, chain.DeanID = signer.Name, signer.ID
+	case Rector:
+		chain.RectorValidating, chain.RectorID = signer.Name, signer.ID
+	}
+	chain.Status = chainStatus(chain.SecretaryValidating, chain.DeanValidating, chain.RectorValidating)
+}
+
+// signerID returns the id of the signatory that signed the chain as role, empty if it is not signed yet
+func (chain *CoIssuer) signerID(role ValidatorType) string {
+	switch role {
+	case Secretary:
+		return chain.SecretaryID
+	case Dean:
+		return chain.DeanID
+	case Rector:
+		return chain.RectorID
+	}
+	return ""
+}
+
+// checkCoIssuers returns an error if the chains of the co-issuers do not match their signatures,
+// or the status of the certificate is ahead of any of them
+func checkCoIssuers(asset *Asset) error {
+	for _, coIssuer := range asset.CoIssuers {
+		if coIssuer.Status != chainStatus(coIssuer.SecretaryValidating, coIssuer.DeanValidating, coIssuer.RectorValidating) {
+			return lus.Errorf(lus.ErrorInconsistentStatus)
+		}
+		if asset.Status != Invalid && asset.Status > coIssuer.Status {
+			return lus.Errorf(lus.ErrorInconsistentStatus)
+		}
+	}
+	return nil
+}
+
+// newCoIssuers returns the chains of the co-issuers of a new certificate, without signatures, and
+// the issuing institutions, starting with emitter. getFaculty returns an active faculty of an institution.
+func newCoIssuers(asset *Asset, emitter *institution.Institution, getFaculty func(institutionID, facultyID string) (*institution.Institution, error)) ([]CoIssuer, []*institution.Institution, error) {
+	issuers := []*institution.Institution{emitter}
+	coIssuers := make([]CoIssuer, 0, len(asset.CoIssuers))
+	for _, request := range asset.CoIssuers {
+		coIssuer, err := getFaculty(request.EmitterID, request.FacultyID)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, issuer := range issuers {
+			if issuer.ID == coIssuer.ID {
+				return nil, nil, lus.Errorf(lus.ErrorIssuerDuplicated, coIssuer.ID, asset.ID)
+			}
+		}
+		issuers = append(issuers, coIssuer)
+		coIssuers = append(coIssuers, CoIssuer{
+			EmitterID: coIssuer.ID,
+			Emitter:   coIssuer.Name,
+			FacultyID: request.FacultyID,
+			Status:    New,
+		})
+	}
+	if len(coIssuers) == 0 {
+		coIssuers = nil
+	}
+
+	return coIssuers, issuers, nil
+}
+
+// issuerOrgs returns the organizations of the issuing institutions, without repetitions. Institutions
+// registered without an MSP id must get one with UpdateInstitution before they can endorse.
+func issuerOrgs(issuers []*institution.Institution) ([]string, error) {
+	orgs := make([]string, 0, len(issuers))
+	seen := make(map[string]bool)
+	for _, issuer := range issuers {
+		if issuer.MSPID == "" {
+			return nil, lus.Errorf(lus.ErrorMissingMSP, issuer.ID)
+		} else if !seen[issuer.MSPID] {
+			seen[issuer.MSPID] = true
+			orgs = append(orgs, issuer.MSPID)
+		}
+	}
+	return orgs, nil
+}
+
+// getIssuers returns the registered institutions issuing a certificate, starting with the emitter
+func getIssuers(stub shim.ChaincodeStubInterface, asset *Asset) ([]*institution.Institution, error) {
+	ids := []string{asset.EmitterID}
+	for _, coIssuer := range asset.CoIssuers {
+		ids = append(ids, coIssuer.EmitterID)
+	}
+
+	issuers := make([]*institution.Institution, 0, len(ids))
+	for _, id := range ids {
+		issuer, err := institution.GetInstitution(stub, id)
+		if err != nil {
+			return nil, err
+		}
+		issuers = append(issuers, issuer)
+	}
+	return issuers, nil
+}
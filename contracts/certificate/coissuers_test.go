@@ -0,0 +1,138 @@
+package certificate
+
+import (
+	"testing"
+
+	"academic_certificates/contracts/common"
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/signatory"
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	testPartnerID          = "INST20221122103020"
+	testPartnerFacultyID   = "FACU20221122103021"
+	testPartnerSecretaryID = "SIGN20221122103022"
+	testPartnerDeanID      = "SIGN20221122103023"
+	testPartnerRectorID    = "SIGN20221122103024"
+)
+
+// partner signing identities of the institution co-issuing joint degrees, in Org2MSP
+type partner struct {
+	secretary, dean, rector *mockstub.Identity
+}
+
+// addPartner registers a second institution, in another organization, with a faculty and its signatories
+func (n *testNetwork) addPartner(t *testing.T) *partner {
+	t.Helper()
+	p := &partner{
+		secretary: mockstub.MustIdentity("Org2MSP", "Secretary@org2.example.com", nil),
+		dean:      mockstub.MustIdentity("Org2MSP", "Dean@org2.example.com", nil),
+		rector:    mockstub.MustIdentity("Org2MSP", "Rector@org2.example.com", nil),
+	}
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		stub := ctx.GetStub()
+		err := institution.PutInstitution(stub, &institution.Institution{DocType: lus.CodInstitution, ID: testPartnerID, Name: "Universidad de Oriente", Acronym: "UO", MSPID: "Org2MSP", Active: true})
+		if err != nil {
+			return err
+		}
+		err = institution.PutFaculty(stub, &institution.Faculty{DocType: lus.CodFaculty, ID: testPartnerFacultyID, InstitutionID: testPartnerID, Name: "Facultad de Derecho", Active: true})
+		if err != nil {
+			return err
+		}
+		for _, sig := range []*signatory.Signatory{
+			{ID: testPartnerSecretaryID, Name: "Rosa Díaz", Role: common.Secretary, InstitutionID: testPartnerID, FacultyID: testPartnerFacultyID, TermStart: "2020-01-01", Certificate: p.secretary.CertificatePEM},
+			{ID: testPartnerDeanID, Name: "Jorge Ruiz", Role: common.Dean, InstitutionID: testPartnerID, FacultyID: testPartnerFacultyID, TermStart: "2020-01-01", Certificate: p.dean.CertificatePEM},
+			{ID: testPartnerRectorID, Name: "Diana Sánchez", Role: common.Rector, InstitutionID: testPartnerID, TermStart: "2020-01-01", Certificate: p.rector.CertificatePEM},
+		} {
+			sig.DocType = lus.CodSignatory
+			err = signatory.PutSignatory(stub, sig)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func (n *testNetwork) signIssuer(identity *mockstub.Identity, id, emitterID, signatoryID string, role ValidatorType) error {
+	return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.ValidateAsset(ctx, &ValidateAsset{ID: id, SignatoryID: signatoryID, ValidatorT: role, EmitterID: emitterID})
+	})
+}
+
+func TestCoIssuedAsset(t *testing.T) {
+	n := newTestNetwork(t)
+	p := n.addPartner(t)
+	id := "CERT20221122103010"
+
+	request := newTestAsset(id, 1)
+	request.CoIssuers = []CoIssuer{{EmitterID: testInstitutionID, FacultyID: testLawID}}
+	err := n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateAsset(ctx, request)
+	})
+	expectError(t, err, lus.ErrorIssuerDuplicated, testInstitutionID, id)
+	request.CoIssuers = []CoIssuer{{EmitterID: testPartnerID, FacultyID: testLawID}}
+	err = n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateAsset(ctx, request)
+	})
+	expectError(t, err, lus.ErrorFacultyInstitution, testLawID, testPartnerID)
+
+	request.CoIssuers = []CoIssuer{{EmitterID: testPartnerID, FacultyID: testPartnerFacultyID, Status: Valid, RectorValidating: "forged"}}
+	n.createAsset(t, request)
+	asset := n.readAsset(t, id)
+	if len(asset.CoIssuers) != 1 || asset.CoIssuers[0].Emitter != "Universidad de Oriente" || asset.CoIssuers[0].Status != New || asset.CoIssuers[0].RectorValidating != "" {
+		t.Fatalf("co-issuers = %+v", asset.CoIssuers)
+	}
+	// both organizations must endorse the changes of the certificate
+	if got := n.readEndorsers(t, id); got != "[Org1MSP Org2MSP]" {
+		t.Errorf("endorsers = %s, want [Org1MSP Org2MSP]", got)
+	}
+	// nor can the emitter become one of them
+	asset.EmitterID, asset.FacultyID = testPartnerID, testPartnerFacultyID
+	err = n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateAsset(ctx, asset)
+	})
+	expectError(t, err, lus.ErrorIssuerDuplicated, testPartnerID, id)
+
+	// signatories of the partner cannot sign the chain of the emitter, nor sign for unknown issuers
+	expectError(t, n.signIssuer(p.secretary, id, "", testPartnerSecretaryID, Secretary), lus.ErrorSignatoryScope, testPartnerSecretaryID, testInstitutionID)
+	expectError(t, n.signIssuer(p.secretary, id, "INST20000101000000", testPartnerSecretaryID, Secretary), lus.ErrorNotIssuer, "INST20000101000000", id)
+
+	steps := []struct {
+		identity    *mockstub.Identity
+		emitterID   string
+		signatoryID string
+		role        ValidatorType
+		status      StateValidation // of the certificate after the signature
+	}{
+		{n.secretary, "", testSecretaryID, Secretary, New},
+		{n.dean, testInstitutionID, testDeanID, Dean, New},
+		{n.rector, "", testRectorID, Rector, New},
+		{p.secretary, testPartnerID, testPartnerSecretaryID, Secretary, SignedS},
+		{p.dean, testPartnerID, testPartnerDeanID, Dean, SignedSD},
+		{p.rector, testPartnerID, testPartnerRectorID, Rector, Valid},
+	}
+	for _, step := range steps {
+		err := n.signIssuer(step.identity, id, step.emitterID, step.signatoryID, step.role)
+		if err != nil {
+			t.Fatalf("%s: %v", step.signatoryID, err)
+		}
+		if asset := n.readAsset(t, id); asset.Status != step.status {
+			t.Fatalf("after %s status = %v, want %v", step.signatoryID, asset.Status, step.status)
+		}
+	}
+
+	asset = n.readAsset(t, id)
+	if asset.RectorID != testRectorID || asset.CoIssuers[0].RectorID != testPartnerRectorID || asset.CoIssuers[0].Status != Valid {
+		t.Errorf("signatures = %+v", asset)
+	}
+	expectError(t, n.signIssuer(p.rector, id, testPartnerID, testPartnerRectorID, Rector), lus.ErrorInconsistentValidation)
+}
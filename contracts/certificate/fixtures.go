@@ -0,0 +1,316 @@
+package certificate
+
+import (
+	"academic_certificates/contracts/common"
+	"academic_certificates/contracts/graduate"
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/program"
+	"academic_certificates/contracts/signatory"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// fixtureLoader writes the documents of a Fixtures. The world state does not reflect the writes
+// of the transaction, so the documents loaded so far are kept to resolve references between them.
+type fixtureLoader struct {
+	ctx          contractapi.TransactionContextInterface
+	audit        lus.Audit
+	loaded       map[string]bool
+	institutions map[string]*institution.Institution
+	faculties    map[string]*institution.Faculty
+	programs     map[string]*program.Program
+	signatories  []*signatory.Signatory
+	batchKeys    map[string]bool
+}
+
+func newFixtureLoader(ctx contractapi.TransactionContextInterface, audit lus.Audit) *fixtureLoader {
+	return &fixtureLoader{
+		ctx:          ctx,
+		audit:        audit,
+		loaded:       make(map[string]bool),
+		institutions: make(map[string]*institution.Institution),
+		faculties:    make(map[string]*institution.Faculty),
+		programs:     make(map[string]*program.Program),
+		batchKeys:    make(map[string]bool),
+	}
+}
+
+// load validates and writes the fixtures, in dependency order
+func (l *fixtureLoader) load(data *Fixtures) error {
+	stub := l.ctx.GetStub()
+
+	for _, inst := range data.Institutions {
+		err := l.checkNew(lus.CodInstitution, inst.ID)
+		if err != nil {
+			return err
+		} else if inst.MSPID == "" {
+			return lus.Errorf(lus.ErrorFixture, inst.ID, lus.Errorf(lus.ErrorMissingMSP, inst.ID))
+		}
+		inst.DocType = lus.CodInstitution
+		inst.Audit = l.audit
+		l.institutions[inst.ID] = inst
+
+		err = institution.PutInstitution(stub, inst)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, faculty := range data.Faculties {
+		err := l.checkNew(lus.CodFaculty, faculty.ID)
+		if err != nil {
+			return err
+		}
+		_, err = l.getInstitution(faculty.InstitutionID)
+		if err != nil {
+			return lus.Errorf(lus.ErrorFixture, faculty.ID, err)
+		}
+		faculty.DocType = lus.CodFaculty
+		faculty.Audit = l.audit
+		l.faculties[faculty.ID] = faculty
+
+		err = institution.PutFaculty(stub, faculty)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, degree := range data.Programs {
+		err := l.checkNew(lus.CodProgram, degree.ID)
+		if err != nil {
+			return err
+		}
+		if degree.Level > program.Specialty || degree.FirstYear <= 0 || (degree.LastYear != 0 && degree.LastYear < degree.FirstYear) {
+			return lus.Errorf(lus.ErrorFixture, degree.ID, degree)
+		}
+		err = program.CheckHonorsRules(degree.HonorsRules)
+		if err != nil {
+			return lus.Errorf(lus.ErrorFixture, degree.ID, err)
+		}
+		faculty, err := l.getFaculty(degree.FacultyID)
+		if err != nil {
+			return lus.Errorf(lus.ErrorFixture, degree.ID, err)
+		}
+		degree.DocType = lus.CodProgram
+		degree.InstitutionID = faculty.InstitutionID
+		degree.Audit = l.audit
+		l.programs[degree.ID] = degree
+
+		err = program.PutProgram(stub, degree)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, signer := range data.Signatories {
+		err := l.checkNew(lus.CodSignatory, signer.ID)
+		if err != nil {
+			return err
+		}
+		if signer.Role < common.Secretary || signer.Role > common.Rector {
+			return lus.Errorf(lus.ErrorFixture, signer.ID, lus.Errorf(lus.ErrorInvalidRole, signer.Role))
+		}
+		_, err = l.getInstitution(signer.InstitutionID)
+		if err != nil {
+			return lus.Errorf(lus.ErrorFixture, signer.ID, err)
+		}
+		if signer.Role.FacultyScoped() {
+			faculty, err := l.getFaculty(signer.FacultyID)
+			if err != nil {
+				return lus.Errorf(lus.ErrorFixture, signer.ID, err)
+			} else if faculty.InstitutionID != signer.InstitutionID {
+				return lus.Errorf(lus.ErrorFixture, signer.ID, lus.Errorf(lus.ErrorFacultyInstitution, faculty.ID, signer.InstitutionID))
+			}
+		} else {
+			signer.FacultyID = ""
+		}
+		err = signatory.SetTerm(signer, signer.TermStart, signer.TermEnd)
+		if err == nil {
+			err = signatory.CheckOverlap(stub, signer, l.signatories)
+		}
+		if err != nil {
+			return lus.Errorf(lus.ErrorFixture, signer.ID, err)
+		}
+		_, err = lus.PublicKeyFromPEM(signer.Certificate)
+		if err != nil {
+			return lus.Errorf(lus.ErrorFixture, signer.ID, err)
+		}
+		signer.DocType = lus.CodSignatory
+		signer.Audit = l.audit
+		l.signatories = append(l.signatories, signer)
+
+		err = signatory.PutSignatory(stub, signer)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, holder := range data.Graduates {
+		err := l.checkNew(lus.CodGraduate, holder.ID)
+		if err != nil {
+			return err
+		}
+		_, err = l.getInstitution(holder.InstitutionID)
+		if err != nil {
+			return lus.Errorf(lus.ErrorFixture, holder.ID, err)
+		}
+		holder.DocType = lus.CodGraduate
+		holder.Audit = l.audit
+
+		err = graduate.PutGraduate(stub, holder)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, asset := range data.Certificates {
+		err := l.loadCertificate(asset)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadCertificate validates and writes a certificate. Signatures and status are taken as given, so the
+// fixtures can hold certificates in every step of the validation, but they must be consistent as in updateAsset.
+func (l *fixtureLoader) loadCertificate(asset *Asset) error {
+	stub := l.ctx.GetStub()
+
+	err := l.checkNew(lus.CodCert, asset.ID)
+	if err != nil {
+		return err
+	}
+	emitter, err := l.getInstitution(asset.EmitterID)
+	if err != nil {
+		return lus.Errorf(lus.ErrorFixture, asset.ID, err)
+	}
+	faculty, err := l.getFaculty(asset.FacultyID)
+	if err != nil {
+		return lus.Errorf(lus.ErrorFixture, asset.ID, err)
+	} else if faculty.InstitutionID != emitter.ID {
+		return lus.Errorf(lus.ErrorFixture, asset.ID, lus.Errorf(lus.ErrorFacultyInstitution, faculty.ID, emitter.ID))
+	}
+	asset.Date, err = lus.ValidatePastDate(stub, asset.Date)
+	if err != nil {
+		return lus.Errorf(lus.ErrorFixture, asset.ID, err)
+	}
+	degree, err := l.getProgram(asset.ProgramID)
+	if err == nil {
+		err = degree.CheckOffered(faculty.ID, dateYear(asset.Date))
+	}
+	if err != nil {
+		return lus.Errorf(lus.ErrorFixture, asset.ID, err)
+	}
+	err = l.checkExists(lus.CodGraduate, asset.HolderID)
+	if err != nil {
+		return lus.Errorf(lus.ErrorFixture, asset.ID, err)
+	}
+
+	// the chains of the co-issuers are also taken as given
+	_, issuers, err := newCoIssuers(asset, emitter, l.getActiveFaculty)
+	if err != nil {
+		return lus.Errorf(lus.ErrorFixture, asset.ID, err)
+	}
+	for i := range asset.CoIssuers {
+		asset.CoIssuers[i].Emitter = issuers[i+1].Name
+	}
+	err = checkStatus(asset)
+	if err != nil {
+		return lus.Errorf(lus.ErrorFixture, asset.ID, err)
+	}
+
+	// honors are also taken as given, the gold certificates of older fixtures have gold honors
+	if asset.GoldCertificate && asset.Honors == program.NoHonors {
+		asset.Honors = program.Gold
+	}
+	asset.setHonors(asset.Honors)
+
+	asset.DocType = lus.CodCert
+	asset.Accredited = "" // the name of the graduate is only kept in the graduates collection
+	asset.Emitter = emitter.Name
+	asset.Certification = degree.Name
+	asset.Audit = l.audit
+
+	err = checkIndexes(stub, asset)
+	if err == nil {
+		err = checkBatchKeys(stub, asset, l.batchKeys)
+	}
+	if err != nil {
+		return lus.Errorf(lus.ErrorFixture, asset.ID, err)
+	}
+
+	return putNewAsset(stub, asset, issuers)
+}
+
+// checkNew returns an error if id is invalid or already used, by a fixture or in the world state
+func (l *fixtureLoader) checkNew(code, id string) error {
+	_, _, docJSON, err := lus.ExistsAssetFromId(l.ctx.GetStub(), code, id)
+	if err != nil {
+		return lus.Errorf(lus.ErrorFixture, id, err)
+	} else if docJSON != nil || l.loaded[id] {
+		return lus.Errorf(lus.ErrorFixture, id, lus.Errorf(lus.ErrorAlreadyExistInState, id))
+	}
+
+	l.loaded[id] = true
+	return nil
+}
+
+// checkExists returns an error unless id was loaded by a fixture or exists in the world state
+func (l *fixtureLoader) checkExists(code, id string) error {
+	if l.loaded[id] {
+		return nil
+	}
+	_, _, docJSON, err := lus.ExistsAssetFromId(l.ctx.GetStub(), code, id)
+	if err != nil {
+		return err
+	} else if docJSON == nil {
+		return lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+	return nil
+}
+
+func (l *fixtureLoader) getInstitution(id string) (*institution.Institution, error) {
+	if inst, ok := l.institutions[id]; ok {
+		return inst, nil
+	}
+	return institution.GetInstitution(l.ctx.GetStub(), id)
+}
+
+func (l *fixtureLoader) getFaculty(id string) (*institution.Faculty, error) {
+	if faculty, ok := l.faculties[id]; ok {
+		return faculty, nil
+	}
+	return institution.GetFaculty(l.ctx.GetStub(), id)
+}
+
+// getActiveFaculty returns the institution of a faculty, both fixtures or registered, if both are active
+func (l *fixtureLoader) getActiveFaculty(institutionID, facultyID string) (*institution.Institution, error) {
+	inst, err := l.getInstitution(institutionID)
+	if err != nil {
+		return nil, err
+	}
+	faculty, err := l.getFaculty(facultyID)
+	if err != nil {
+		return nil, err
+	}
+	if faculty.InstitutionID != inst.ID {
+		return nil, lus.Errorf(lus.ErrorFacultyInstitution, faculty.ID, inst.ID)
+	}
+	if !inst.Active {
+		return nil, lus.Errorf(lus.ErrorInactive, inst.ID)
+	}
+	if !faculty.Active {
+		return nil, lus.Errorf(lus.ErrorInactive, faculty.ID)
+	}
+	return inst, nil
+}
+
+func (l *fixtureLoader) getProgram(id string) (*program.Program, error) {
+	if degree, ok := l.programs[id]; ok {
+		return degree, nil
+	}
+	return program.GetProgram(l.ctx.GetStub(), id)
+}
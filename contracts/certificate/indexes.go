@@ -0,0 +1,221 @@
+package certificate
+
+import (
+	"strconv"
+
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// Secondary indexes of the certificates. Only the key name is needed, the
+// value of every index entry is the null character.
+
+func holderIndexKey(stub shim.ChaincodeStubInterface, asset *Asset) (string, error) {
+	return stub.CreateCompositeKey(lus.CodHolder, []string{asset.HolderID, asset.ID})
+}
+
+// holderProgramIndexKey key of the certificate, not invalid, of a graduate for a program. The graduate
+// registry holds one graduate per national id, so the graduate id identifies the person. The entries are
+// unique, so the value of the index is the certificate id.
+func holderProgramIndexKey(stub shim.ChaincodeStubInterface, asset *Asset) (string, error) {
+	return stub.CreateCompositeKey(lus.CodHolderProgram, []string{asset.HolderID, asset.ProgramID})
+}
+
+// flaggedIndexKey key of a certificate waiting for the review of its flags, by emitter
+func flaggedIndexKey(stub shim.ChaincodeStubInterface, asset *Asset) (string, error) {
+	return stub.CreateCompositeKey(lus.CodFlagged, []string{asset.EmitterID, asset.ID})
+}
+
+// registryIndexKey key of an entry of the registry book of a faculty or university. The
+// entries are unique, so the value of the index is the certificate id.
+func registryIndexKey(stub shim.ChaincodeStubInterface, book RegistryBook, ownerID string, entry VolumeFolio) (string, error) {
+	var index string
+	switch book {
+	case FacultyBook:
+		index = lus.CodFacultyBook
+	case UniversityBook:
+		index = lus.CodUnivBook
+	default:
+		return "", lus.Errorf(lus.ErrorInvalidRegistryBook, book)
+	}
+	return stub.CreateCompositeKey(index, []string{ownerID, strconv.Itoa(entry.Volume), strconv.Itoa(entry.Folio)})
+}
+
+// registryEntry entry of a certificate in the registry book of a faculty or institution
+type registryEntry struct {
+	book    RegistryBook
+	ownerID string
+	entry   VolumeFolio
+}
+
+// registryEntries returns the registry book entries of asset
+func registryEntries(asset *Asset) []registryEntry {
+	entries := make([]registryEntry, 0, 2)
+	if asset.FacultyVolumeFolio.Volume != 0 {
+		entries = append(entries, registryEntry{FacultyBook, asset.FacultyID, asset.FacultyVolumeFolio})
+	}
+	if asset.UniversityVolumeFolio.Volume != 0 {
+		entries = append(entries, registryEntry{UniversityBook, asset.EmitterID, asset.UniversityVolumeFolio})
+	}
+	return entries
+}
+
+// checkIndexes returns an error if a unique index entry of asset is already assigned to another certificate
+func checkIndexes(stub shim.ChaincodeStubInterface, asset *Asset) error {
+	for _, entry := range []VolumeFolio{asset.FacultyVolumeFolio, asset.UniversityVolumeFolio} {
+		if entry != (VolumeFolio{}) && (entry.Volume <= 0 || entry.Folio <= 0) {
+			return lus.Errorf(lus.ErrorInvalidVolumeFolio, entry)
+		}
+	}
+	for _, registry := range registryEntries(asset) {
+		key, err := registryIndexKey(stub, registry.book, registry.ownerID, registry.entry)
+		if err != nil {
+			return err
+		}
+		id, err := stub.GetState(key)
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		} else if id != nil && string(id) != asset.ID {
+			return lus.Errorf(lus.ErrorFolioTaken, registry.entry, registry.book, registry.ownerID, id)
+		}
+	}
+	// only a reissue can take the place of the certificate of the holder for the program
+	if asset.HolderID != "" && asset.Status != Invalid {
+		key, err := holderProgramIndexKey(stub, asset)
+		if err != nil {
+			return err
+		}
+		id, err := stub.GetState(key)
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		} else if id != nil && string(id) != asset.ID && string(id) != asset.ReissueOf {
+			return lus.Errorf(lus.ErrorDuplicateCertificate, asset.HolderID, string(id), asset.ProgramID)
+		}
+	}
+
+	return nil
+}
+
+// putIndexes writes the index entries of asset to the world state
+func putIndexes(stub shim.ChaincodeStubInterface, asset *Asset) error {
+	if asset.HolderID != "" {
+		key, err := holderIndexKey(stub, asset)
+		if err != nil {
+			return err
+		}
+		err = stub.PutState(key, []byte{0x00})
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+	if asset.HolderID != "" && asset.Status != Invalid {
+		key, err := holderProgramIndexKey(stub, asset)
+		if err != nil {
+			return err
+		}
+		err = stub.PutState(key, []byte(asset.ID))
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+	for _, registry := range registryEntries(asset) {
+		key, err := registryIndexKey(stub, registry.book, registry.ownerID, registry.entry)
+		if err != nil {
+			return err
+		}
+		err = stub.PutState(key, []byte(asset.ID))
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+	if asset.waitsForReview() {
+		key, err := flaggedIndexKey(stub, asset)
+		if err != nil {
+			return err
+		}
+		err = stub.PutState(key, []byte{0x00})
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+
+	return nil
+}
+
+// delIndexes removes the index entries of asset from the world state
+func delIndexes(stub shim.ChaincodeStubInterface, asset *Asset) error {
+	if asset.HolderID != "" {
+		key, err := holderIndexKey(stub, asset)
+		if err != nil {
+			return err
+		}
+		err = stub.DelState(key)
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+	if asset.HolderID != "" && asset.Status != Invalid {
+		key, err := holderProgramIndexKey(stub, asset)
+		if err != nil {
+			return err
+		}
+		// duplicates issued before the index share its entry, keep the one of the other certificate
+		id, err := stub.GetState(key)
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		} else if string(id) == asset.ID {
+			err = stub.DelState(key)
+			if err != nil {
+				return lus.Errorf(lus.ErrorWorldState, err)
+			}
+		}
+	}
+	for _, registry := range registryEntries(asset) {
+		key, err := registryIndexKey(stub, registry.book, registry.ownerID, registry.entry)
+		if err != nil {
+			return err
+		}
+		err = stub.DelState(key)
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+	if asset.waitsForReview() {
+		key, err := flaggedIndexKey(stub, asset)
+		if err != nil {
+			return err
+		}
+		err = stub.DelState(key)
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+
+	return nil
+}
+
+// idsFromIndex returns the certificate ids, last attribute of the keys, of the index
+// entries matching the partial key
+func idsFromIndex(stub shim.ChaincodeStubInterface, index string, attributes []string) ([]string, error) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(index, attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	ids := make([]string, 0)
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, keyParts, err := stub.SplitCompositeKey(result.Key)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, keyParts[len(keyParts)-1])
+	}
+
+	return ids, nil
+}
@@ -1,5 +1,19 @@
 package certificate
 
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"academic_certificates/contracts/common"
+	"academic_certificates/contracts/graduate"
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/program"
+	"academic_certificates/contracts/signatory"
+	lus "academic_certificates/libutils"
+)
+
 type StateValidation uint
 
 const (
@@ -10,41 +24,232 @@ const (
 	Valid                           // signed by Secretary, Dean and Rector
 )
 
-type ValidatorType uint
+// HonorsLevel is declared in program, the honors rules are part of the program
+type HonorsLevel = program.HonorsLevel
+
+// ValidatorType is declared in common so the signatory registry can share it
+type ValidatorType = common.ValidatorType
 
 const (
-	NoValidator ValidatorType = iota // invalidated for some reason
-	Secretary                        // certificate without signatures
-	Dean                             // signed by Secretary
-	Rector                           // signed by Secretary and Dean
+	NoValidator = common.NoValidator
+	Secretary   = common.Secretary
+	Dean        = common.Dean
+	Rector      = common.Rector
 )
 
 //Auxiliary Functions
 func (state StateValidation) String() string {
-	names := []string{"Invalid", "Miss Dean and Rector signatures", "Miss Rector signature", "Va"}
-	if state < Invalid || state > Valid {
+	names := []string{"Invalid", "Missing Secretary, Dean and Rector signatures", "Missing Dean and Rector signatures", "Missing Rector signature", "Valid"}
+	if state > Valid {
 		return "unknown"
 	}
 	return names[state]
 }
 
+// Localize returns the label of the status in lang
+func (state StateValidation) Localize(lang lus.Language) string {
+	return lus.Translate(lang, state.String())
+}
+
 // Asset describes basic details of what makes up a simple asset
 type Asset struct {
 	DocType               string          `json:"docType"`
+	SchemaVersion         int             `json:"schema_version" metadata:",optional"` // set on write to SchemaVersion, the documents of every package carry one
 	ID                    string          `json:"ID"`
-	Certification         string          `json:"certification"`
-	GoldCertificate       bool            `json:"gold_certificate"`
-	Emitter               string          `json:"emitter"`
-	Accredited            string          `json:"accredited"`
-	Date                  string          `json:"date"`
-	CreatedBy             string          `json:"created_by"`
+	ProgramID             string          `json:"program_id"` // degree program in the catalog
+	Certification         string          `json:"certification" metadata:",optional"`
+	GoldCertificate       bool            `json:"gold_certificate" metadata:",optional"` // set by the chaincode, gold honors
+	EmitterID             string          `json:"emitter_id"`                            // registered institution
+	Emitter               string          `json:"emitter" metadata:",optional"`
+	FacultyID             string          `json:"faculty_id"`                      // registered faculty of the emitter
+	HolderID              string          `json:"holder_id"`                       // graduate registry id
+	Accredited            string          `json:"accredited" metadata:",optional"` // name of the holder of old records, blank for a registered graduate
+	Date                  string          `json:"date"`                            // issuance date, YYYY-MM-DD
 	SecretaryValidating   string          `json:"secretary_validating"`
 	DeanValidating        string          `json:"dean_validating"`
 	RectorValidating      string          `json:"rector_validating"`
-	FacultyVolumeFolio    string          `json:"volume_folio_faculty"`
-	UniversityVolumeFolio string          `json:"volume_folio_university"`
+	SecretaryID           string          `json:"secretary_id" metadata:",optional"` // signatory registry ids
+	DeanID                string          `json:"dean_id" metadata:",optional"`
+	RectorID              string          `json:"rector_id" metadata:",optional"`
+	FacultyVolumeFolio    VolumeFolio     `json:"volume_folio_faculty"`    // entry in the faculty registry book
+	UniversityVolumeFolio VolumeFolio     `json:"volume_folio_university"` // entry in the university registry book
 	InvalidReason         string          `json:"invalid_reason"`
-	Status                StateValidation `json:"certificate_status"`
+	Status                StateValidation `json:"certificate_status"`                        // the least advanced chain of signatures of the issuers
+	CoIssuers             []CoIssuer      `json:"co_issuers,omitempty" metadata:",optional"` // other institutions of a joint degree
+	GPA                   float64         `json:"gpa" metadata:",optional"`                  // grade point average, from the transcript once it is issued
+	FailedCourses         int             `json:"failed_courses" metadata:",optional"`       // courses failed by the graduate
+	Honors                HonorsLevel     `json:"honors" metadata:",optional"`               // set by the chaincode from the honors rules of the program
+	HonorsOverride        *HonorsOverride `json:"honors_override,omitempty" metadata:",optional"`
+	ReissueOf             string          `json:"reissue_of,omitempty" metadata:",optional"` // certificate replaced by this one, invalidated on issuance
+	Flags                 []FraudFlag     `json:"flags,omitempty" metadata:",optional"`      // set by the chaincode on issuance, signing waits for their review
+	FlagReview            *FlagReview     `json:"flag_review,omitempty" metadata:",optional"`
+	HonorsLabel           string          `json:"honors_label,omitempty" metadata:",optional"` // label of the honors in the language of the request, never stored
+	StatusLabel           string          `json:"status_label,omitempty" metadata:",optional"` // label of the status in the language of the request, never stored
+	lus.Audit
+}
+
+// CoIssuer institution issuing a joint certificate with the emitter. Each co-issuer signs its own
+// chain of secretary, dean and rector signatures, the certificate is valid once every chain is complete.
+type CoIssuer struct {
+	EmitterID           string          `json:"emitter_id"` // registered institution
+	Emitter             string          `json:"emitter" metadata:",optional"`
+	FacultyID           string          `json:"faculty_id"` // registered faculty of the co-issuer
+	SecretaryValidating string          `json:"secretary_validating" metadata:",optional"`
+	DeanValidating      string          `json:"dean_validating" metadata:",optional"`
+	RectorValidating    string          `json:"rector_validating" metadata:",optional"`
+	SecretaryID         string          `json:"secretary_id" metadata:",optional"` // signatory registry ids
+	DeanID              string          `json:"dean_id" metadata:",optional"`
+	RectorID            string          `json:"rector_id" metadata:",optional"`
+	Status              StateValidation `json:"certificate_status" metadata:",optional"` // progress of the chain of this institution
+}
+
+// Verification publicly disclosable data of a certificate, returned to third parties checking a certificate
+// presented by its holder. The holder, the signatories and the registry book entries are not disclosed.
+type Verification struct {
+	ID              string          `json:"ID"`
+	Certification   string          `json:"certification"`
+	GoldCertificate bool            `json:"gold_certificate"`
+	EmitterID       string          `json:"emitter_id"`
+	Emitter         string          `json:"emitter"`
+	CoIssuers       []string        `json:"co_issuers,omitempty" metadata:",optional"` // names of the other institutions of a joint degree
+	Date            string          `json:"date"`
+	Status          StateValidation `json:"certificate_status"`
+	StatusLabel     string          `json:"status_label"` // label of the status in the language of the request
+	Valid           bool            `json:"valid"`        // signed by every issuer and not invalidated
+}
+
+// VerificationLogEntry verification of a certificate recorded by RecordVerification, the universities and the
+// holder can tell how often and by whom it was checked. The time and client id of the verifier are the
+// created_at and created_by audit fields.
+type VerificationLogEntry struct {
+	DocType       string          `json:"docType"`
+	SchemaVersion int             `json:"schema_version" metadata:",optional"`
+	CertificateID string          `json:"certificate_id"`
+	VerifierMSP   string          `json:"verifier_msp"` // organization of the verifier
+	Purpose       string          `json:"purpose"`
+	Status        StateValidation `json:"certificate_status"` // status of the certificate when it was verified
+	Valid         bool            `json:"valid"`
+	StatusLabel   string          `json:"status_label,omitempty" metadata:",optional"` // label of the status in the language of the request, never stored
+	lus.Audit
+}
+
+// VerificationLogRequest verification of the certificate ID recorded with its purpose, ex: "hiring"
+type VerificationLogRequest struct {
+	ID      string `json:"id"`
+	Purpose string `json:"purpose"`
+}
+
+// FraudFlag suspicious circumstance of the issuance of a certificate
+type FraudFlag string
+
+const (
+	FlagDuplicate  FraudFlag = "duplicate_holder_program" // the holder has another certificate of the program, issued before its index
+	FlagOutOfHours FraudFlag = "out_of_hours"             // issued out of the office hours of the settings
+)
+
+// FlagReview review of the flags of a certificate by an administrator other than its issuer
+type FlagReview struct {
+	Approved   bool   `json:"approved"`
+	Reason     string `json:"reason" metadata:",optional"`
+	ReviewedBy string `json:"reviewed_by"` // client id of the administrator
+	Date       string `json:"date"`        // YYYY-MM-DD
+}
+
+// FlagReviewRequest clears the flags of a certificate so it can be signed, or invalidates it with the reason
+type FlagReviewRequest struct {
+	ID       string `json:"ID"`
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason" metadata:",optional"`
+}
+
+// IssuanceCounter certificates issued by a client identity in a day
+type IssuanceCounter struct {
+	DocType       string `json:"docType"`
+	SchemaVersion int    `json:"schema_version" metadata:",optional"`
+	ClientID      string `json:"client_id"`
+	Date          string `json:"date"` // YYYY-MM-DD, UTC
+	Count         int    `json:"count"`
+}
+
+// HonorsOverride honors set by the dean of the faculty instead of the ones of the honors rules of the program
+type HonorsOverride struct {
+	Computed HonorsLevel `json:"computed"` // honors of the rules
+	Reason   string      `json:"reason"`
+	DeanID   string      `json:"dean_id"` // signatory registry id
+	Dean     string      `json:"dean"`
+	Date     string      `json:"date"` // YYYY-MM-DD
+}
+
+// HonorsOverrideRequest honors approved by the dean signatory SignatoryID, with its reason
+type HonorsOverrideRequest struct {
+	ID          string      `json:"ID"`
+	Honors      HonorsLevel `json:"honors"`
+	Reason      string      `json:"reason"`
+	SignatoryID string      `json:"signatory_id"`
+}
+
+// VolumeFolio entry of a certificate in an official registry book
+type VolumeFolio struct {
+	Volume int `json:"volume"`
+	Folio  int `json:"folio"`
+}
+
+func (vf VolumeFolio) String() string {
+	return fmt.Sprintf("%d,%d", vf.Volume, vf.Folio)
+}
+
+// UnmarshalJSON also accepts the "volume,folio" strings of the first records of the ledger
+func (vf *VolumeFolio) UnmarshalJSON(data []byte) error {
+	var value string
+	if json.Unmarshal(data, &value) != nil {
+		type plain VolumeFolio
+		return json.Unmarshal(data, (*plain)(vf))
+	}
+
+	parsed, err := ParseVolumeFolio(value)
+	if err != nil {
+		return err
+	}
+	*vf = parsed
+	return nil
+}
+
+// ParseVolumeFolio parses a registry book entry written as "volume,folio", ex: "254, 136"
+func ParseVolumeFolio(value string) (VolumeFolio, error) {
+	if strings.TrimSpace(value) == "" {
+		return VolumeFolio{}, nil
+	}
+	volume, folio, found := strings.Cut(value, ",")
+	if !found {
+		return VolumeFolio{}, lus.Errorf(lus.ErrorInvalidVolumeFolio, value)
+	}
+
+	var vf VolumeFolio
+	var errVolume, errFolio error
+	vf.Volume, errVolume = strconv.Atoi(strings.TrimSpace(volume))
+	vf.Folio, errFolio = strconv.Atoi(strings.TrimSpace(folio))
+	if errVolume != nil || errFolio != nil || vf.Volume <= 0 || vf.Folio <= 0 {
+		return VolumeFolio{}, lus.Errorf(lus.ErrorInvalidVolumeFolio, value)
+	}
+
+	return vf, nil
+}
+
+// RegistryBook registry book where certificates are entered
+type RegistryBook string
+
+const (
+	FacultyBook    RegistryBook = "faculty"
+	UniversityBook RegistryBook = "university"
+)
+
+// RegistryEntryRequest selects a volume and folio of the registry book of a faculty
+// or university. OwnerID is the faculty or institution id.
+type RegistryEntryRequest struct {
+	Book    RegistryBook `json:"book"`
+	OwnerID string       `json:"owner_id"`
+	Volume  int          `json:"volume"`
+	Folio   int          `json:"folio"`
 }
 
 type GetRequest struct {
@@ -52,12 +257,304 @@ type GetRequest struct {
 }
 
 type ValidateAsset struct {
-	ID         string        `json:"ID"`
-	Validator  string        `json:"validator"`
-	ValidatorT ValidatorType `json:"validator_type"`
+	ID          string        `json:"ID"`
+	SignatoryID string        `json:"signatory_id"` // registered signatory signing the certificate
+	ValidatorT  ValidatorType `json:"validator_type"`
+	EmitterID   string        `json:"emitter_id" metadata:",optional"` // issuer whose chain is signed, the emitter if empty
 }
 
 type InvalidateAsset struct {
 	ID          string `json:"ID"`
 	Description string `json:"description"`
 }
+
+// DateRangeRequest filters certificates by issuance date (YYYY-MM-DD). Both limits
+// are inclusive, an empty limit leaves that side of the range open.
+type DateRangeRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Settings configuration of the certificate contract stored in the world state
+type Settings struct {
+	DocType        string `json:"docType" metadata:",optional"`
+	SchemaVersion  int    `json:"schema_version" metadata:",optional"`
+	MaxBatchSize   int    `json:"max_batch_size"`                        // items accepted by batch transactions, keeps them inside the block limits
+	MinistryMSPID  string `json:"ministry_msp_id" metadata:",optional"`  // organization of the Ministry of Education, legalizes certificates
+	ApostilleMSPID string `json:"apostille_msp_id" metadata:",optional"` // organization of the authority issuing apostilles
+	// certificates a client identity can issue per day, DefaultMaxDailyIssuance if 0
+	MaxDailyIssuance int `json:"max_daily_issuance" metadata:",optional"`
+	// office hours, from OfficeHoursStart to OfficeHoursEnd on weekdays at UTCOffset hours from UTC. Certificates
+	// issued out of them are flagged, no certificate is flagged if both are 0.
+	OfficeHoursStart int `json:"office_hours_start" metadata:",optional"`
+	OfficeHoursEnd   int `json:"office_hours_end" metadata:",optional"`
+	UTCOffset        int `json:"utc_offset" metadata:",optional"`
+	lus.Audit
+}
+
+// SettingsUpdate request of UpdateSettings, the settings named in Fields by their json name take the value
+// of the request, ex: {"fields":["max_batch_size"],"max_batch_size":50}
+type SettingsUpdate struct {
+	Fields           []string `json:"fields"`
+	MaxBatchSize     int      `json:"max_batch_size" metadata:",optional"`
+	MinistryMSPID    string   `json:"ministry_msp_id" metadata:",optional"`
+	ApostilleMSPID   string   `json:"apostille_msp_id" metadata:",optional"`
+	MaxDailyIssuance int      `json:"max_daily_issuance" metadata:",optional"`
+	OfficeHoursStart int      `json:"office_hours_start" metadata:",optional"`
+	OfficeHoursEnd   int      `json:"office_hours_end" metadata:",optional"`
+	UTCOffset        int      `json:"utc_offset" metadata:",optional"`
+}
+
+// LegalizationStatus progress of the legalization of a valid certificate, independent of its StateValidation
+type LegalizationStatus uint
+
+const (
+	LegalizationRequested LegalizationStatus = iota // requested by the emitter, waiting for the ministry
+	Legalized                                       // approved by the Ministry of Education
+	Apostilled                                      // apostille issued
+	LegalizationRejected                            // rejected by the Ministry of Education
+)
+
+func (status LegalizationStatus) String() string {
+	names := []string{"Legalization requested", "Legalized", "Apostilled", "Legalization rejected"}
+	if status > LegalizationRejected {
+		return "unknown"
+	}
+	return names[status]
+}
+
+// Localize returns the label of the status in lang
+func (status LegalizationStatus) Localize(lang lus.Language) string {
+	return lus.Translate(lang, status.String())
+}
+
+// Legalization legalization of a valid certificate by the Ministry of Education, followed by its apostille,
+// so graduates can use it abroad. Its ID is the one of the certificate with the lus.CodLegalization prefix,
+// the steps are recorded in its history. The *By fields are the client ids of the identities of each step.
+type Legalization struct {
+	DocType         string             `json:"docType"`
+	SchemaVersion   int                `json:"schema_version" metadata:",optional"`
+	ID              string             `json:"ID"`
+	CertificateID   string             `json:"certificate_id"`
+	Country         string             `json:"country" metadata:",optional"` // destination country
+	Status          LegalizationStatus `json:"legalization_status"`
+	RequestedBy     string             `json:"requested_by"`
+	ReviewedBy      string             `json:"reviewed_by" metadata:",optional"` // ministry identity approving or rejecting it
+	RejectReason    string             `json:"reject_reason" metadata:",optional"`
+	ApostilleNumber string             `json:"apostille_number" metadata:",optional"`
+	ApostilleDate   string             `json:"apostille_date" metadata:",optional"` // YYYY-MM-DD
+	ApostilledBy    string             `json:"apostilled_by" metadata:",optional"`
+	StatusLabel     string             `json:"status_label,omitempty" metadata:",optional"` // label of the status in the language of the request, never stored
+	lus.Audit
+}
+
+// LegalizationRequest requests the legalization of a valid certificate
+type LegalizationRequest struct {
+	ID      string `json:"ID"` // certificate id
+	Country string `json:"country" metadata:",optional"`
+}
+
+// LegalizationReview decision of the Ministry of Education, a rejection requires its reason
+type LegalizationReview struct {
+	ID       string `json:"ID"` // certificate id
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason" metadata:",optional"`
+}
+
+// ApostilleRequest apostille issued for a legalized certificate
+type ApostilleRequest struct {
+	ID     string `json:"ID"` // certificate id
+	Number string `json:"number"`
+	Date   string `json:"date"` // YYYY-MM-DD
+}
+
+// grades of the five-point scale of the courses, a course is passed with PassingGrade or more
+const (
+	MinGrade     = 2
+	PassingGrade = 3
+	MaxGrade     = 5
+)
+
+// CourseRecord final grade of a course in a transcript
+type CourseRecord struct {
+	Code    string `json:"code"` // code of the course in the program
+	Name    string `json:"name"`
+	Year    int    `json:"year" metadata:",optional"` // academic year of the course
+	Credits int    `json:"credits"`
+	Grade   int    `json:"grade"` // MinGrade to MaxGrade
+}
+
+// Transcript academic transcript of a certificate, signed by the secretary, dean and rector of the emitter
+// like the certificate. GPA, Credits and FailedCourses are computed by the chaincode from the courses.
+type Transcript struct {
+	DocType             string          `json:"docType"`
+	SchemaVersion       int             `json:"schema_version" metadata:",optional"`
+	ID                  string          `json:"ID"`
+	CertificateID       string          `json:"certificate_id"`
+	Courses             []CourseRecord  `json:"courses"`
+	GPA                 float64         `json:"gpa" metadata:",optional"`            // credit weighted average of the grades, two decimals
+	Credits             int             `json:"credits" metadata:",optional"`        // credits of the passed courses
+	FailedCourses       int             `json:"failed_courses" metadata:",optional"` // courses graded under PassingGrade
+	SecretaryValidating string          `json:"secretary_validating" metadata:",optional"`
+	DeanValidating      string          `json:"dean_validating" metadata:",optional"`
+	RectorValidating    string          `json:"rector_validating" metadata:",optional"`
+	SecretaryID         string          `json:"secretary_id" metadata:",optional"` // signatory registry ids
+	DeanID              string          `json:"dean_id" metadata:",optional"`
+	RectorID            string          `json:"rector_id" metadata:",optional"`
+	Status              StateValidation `json:"transcript_status" metadata:",optional"`
+	StatusLabel         string          `json:"status_label,omitempty" metadata:",optional"` // label of the status in the language of the request, never stored
+	lus.Audit
+}
+
+type ValidateTranscript struct {
+	ID          string        `json:"ID"`
+	SignatoryID string        `json:"signatory_id"` // registered signatory signing the transcript
+	ValidatorT  ValidatorType `json:"validator_type"`
+}
+
+// GrantScope private data of a certificate disclosed by a grant
+type GrantScope string
+
+const (
+	ScopeHolder      GrantScope = "holder"      // identity of the graduate, from the graduates collection
+	ScopeSignatories GrantScope = "signatories" // names of the officers signing the certificate
+	ScopeRegistry    GrantScope = "registry"    // entries in the registry books
+	ScopeTranscript  GrantScope = "transcript"  // academic transcript, if issued
+)
+
+// MaxGrantDays longest time a grant can be valid for
+const MaxGrantDays = 365
+
+// MinGrantTokenLength shortest token accepted for a grant
+const MinGrantTokenLength = 16
+
+// Grant access to the private data of a valid certificate, granted by its holder (or a secretary on their
+// behalf) to the organization Audience until ExpiresAt. Verifiers present the secret token of the grant,
+// the ledger only keeps its SHA-256 hash.
+type Grant struct {
+	DocType       string       `json:"docType"`
+	SchemaVersion int          `json:"schema_version" metadata:",optional"`
+	ID            string       `json:"ID"`
+	CertificateID string       `json:"certificate_id"`
+	HolderID      string       `json:"holder_id"`
+	Audience      string       `json:"audience"` // MSP id of the verifier organization
+	Scope         []GrantScope `json:"scope"`
+	ExpiresAt     string       `json:"expires_at"`                        // RFC 3339 time, UTC
+	TokenHash     string       `json:"token_hash"`                        // hex encoded
+	SignatoryID   string       `json:"signatory_id" metadata:",optional"` // secretary granting it on behalf of the holder
+	Revoked       bool         `json:"revoked" metadata:",optional"`
+	lus.Audit
+}
+
+// GrantRequest creates a grant, the token is passed in the lus.TransientGrantToken transient field.
+// SignatoryID is the secretary granting it on behalf of the holder, empty when the holder grants it.
+type GrantRequest struct {
+	ID            string       `json:"ID"`
+	CertificateID string       `json:"certificate_id"`
+	Audience      string       `json:"audience"`
+	Scope         []GrantScope `json:"scope"`
+	ExpiresAt     string       `json:"expires_at"`
+	SignatoryID   string       `json:"signatory_id" metadata:",optional"`
+}
+
+// RevokeGrantRequest revokes a grant, SignatoryID as in GrantRequest
+type RevokeGrantRequest struct {
+	ID          string `json:"ID"`
+	SignatoryID string `json:"signatory_id" metadata:",optional"`
+}
+
+// SharedCertificate public data of a certificate with the private data in the scope of a grant
+type SharedCertificate struct {
+	Verification
+	GrantID               string                     `json:"grant_id"`
+	ExpiresAt             string                     `json:"expires_at"`
+	Holder                *graduate.GraduateIdentity `json:"holder,omitempty" metadata:",optional"`
+	SecretaryValidating   string                     `json:"secretary_validating,omitempty" metadata:",optional"`
+	DeanValidating        string                     `json:"dean_validating,omitempty" metadata:",optional"`
+	RectorValidating      string                     `json:"rector_validating,omitempty" metadata:",optional"`
+	FacultyVolumeFolio    *VolumeFolio               `json:"volume_folio_faculty,omitempty" metadata:",optional"`
+	UniversityVolumeFolio *VolumeFolio               `json:"volume_folio_university,omitempty" metadata:",optional"`
+	Transcript            *Transcript                `json:"transcript,omitempty" metadata:",optional"`
+}
+
+// AssetEndorsement organizations whose peers must endorse the changes of a certificate
+type AssetEndorsement struct {
+	ID   string   `json:"ID"`
+	Orgs []string `json:"orgs"` // MSP ids, empty when the chaincode endorsement policy applies
+}
+
+type BatchCreateRequest struct {
+	Assets []*Asset `json:"assets"`
+}
+
+// BatchItemResult outcome of one item of a batch transaction
+type BatchItemResult struct {
+	ID      string `json:"ID"`
+	Success bool   `json:"success"`
+	Code    string `json:"code,omitempty" metadata:",optional"` // stable code of the error, see lus.Errorf
+	Error   string `json:"error,omitempty" metadata:",optional"`
+}
+
+// BatchValidateRequest signs several certificates with the same signatory. By default the batch is
+// all or nothing, with BestEffort the certificates that can be signed are signed and the rest reported.
+type BatchValidateRequest struct {
+	IDs         []string      `json:"ids"`
+	SignatoryID string        `json:"signatory_id"`
+	ValidatorT  ValidatorType `json:"validator_type"`
+	EmitterID   string        `json:"emitter_id" metadata:",optional"` // issuer whose chains are signed, the emitter if empty
+	BestEffort  bool          `json:"best_effort" metadata:",optional"`
+}
+
+// BatchValidateEvent payload of the event emitted by ValidateAssetsBatch
+type BatchValidateEvent struct {
+	SignatoryID string        `json:"signatory_id"`
+	ValidatorT  ValidatorType `json:"validator_type"`
+	Signed      []string      `json:"signed"`
+	Failed      []string      `json:"failed"`
+}
+
+// Fixtures seed data loaded by InitLedger, see fixtures/demo.json. Every section is optional.
+// The identity of the graduates is never part of the fixtures, the document is stored in the ledger.
+type Fixtures struct {
+	Institutions []*institution.Institution `json:"institutions"`
+	Faculties    []*institution.Faculty     `json:"faculties"`
+	Programs     []*program.Program         `json:"programs"`
+	Signatories  []*signatory.Signatory     `json:"signatories"`
+	Graduates    []*graduate.Graduate       `json:"graduates"`
+	Certificates []*Asset                   `json:"certificates"`
+}
+
+// InitRecord marks the ledger as initialized by InitLedger
+type InitRecord struct {
+	DocType       string `json:"docType"`
+	SchemaVersion int    `json:"schema_version" metadata:",optional"`
+	lus.Audit
+}
+
+// MigrateRequest selects a page of the certificates to migrate. Bookmark is the one returned by
+// the previous page, empty for the first one.
+type MigrateRequest struct {
+	PageSize int    `json:"page_size"` // keys scanned, at most the maximum batch size
+	Bookmark string `json:"bookmark" metadata:",optional"`
+}
+
+// MigrationPage outcome of QueryMigrationPage. Bookmark is empty after the last page.
+type MigrationPage struct {
+	Scanned  int      `json:"scanned"`
+	IDs      []string `json:"ids"` // certificates stored with an older schema version
+	Bookmark string   `json:"bookmark"`
+}
+
+// MigrateAssetsRequest certificates to migrate, at most the maximum batch size
+type MigrateAssetsRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// MigrateResponse outcome of MigrateAssets
+type MigrateResponse struct {
+	Migrated  []string `json:"migrated"`  // ids of the certificates rewritten
+	Conflicts []string `json:"conflicts"` // ids of the certificates rewritten without indexes, see MigrateAssets
+}
+
+// MigratedCreator creator of the certificates stored before the audit fields, their issuer is unknown
+const MigratedCreator = "migrated"
@@ -0,0 +1,50 @@
+package certificate
+
+import (
+	"encoding/json"
+	"testing"
+
+	lus "academic_certificates/libutils"
+)
+
+func TestVolumeFolioUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		want    VolumeFolio
+		wantErr bool
+	}{
+		{`{"volume":254,"folio":136}`, VolumeFolio{254, 136}, false},
+		{`"254,136"`, VolumeFolio{254, 136}, false}, // first records of the ledger
+		{`" 254 , 136 "`, VolumeFolio{254, 136}, false},
+		{`""`, VolumeFolio{}, false},
+		{`"254"`, VolumeFolio{}, true},
+		{`"0,136"`, VolumeFolio{}, true},
+		{`"a,b"`, VolumeFolio{}, true},
+	}
+	for _, test := range tests {
+		var got VolumeFolio
+		err := json.Unmarshal([]byte(test.data), &got)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: err = %v, want error %v", test.data, err, test.wantErr)
+		} else if got != test.want {
+			t.Errorf("%s = %+v, want %+v", test.data, got, test.want)
+		}
+	}
+}
+
+func TestStateValidationLabels(t *testing.T) {
+	for state := Invalid; state <= Valid; state++ {
+		if state.String() == "unknown" || state.Localize(lus.LangEs) == state.String() {
+			t.Errorf("status %d has no label: %s / %s", state, state.String(), state.Localize(lus.LangEs))
+		}
+	}
+	if got := Valid.String(); got != "Valid" {
+		t.Errorf("Valid = %s", got)
+	}
+	if got := Valid.Localize(lus.LangEs); got != "Válido" {
+		t.Errorf("Valid in Spanish = %s", got)
+	}
+	if got := StateValidation(9).String(); got != "unknown" {
+		t.Errorf("unknown status = %s", got)
+	}
+}
@@ -0,0 +1,182 @@
+package certificate
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"academic_certificates/contracts/institution"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateAssetsBatch issues several assets in one transaction, ex: a whole graduating class. Every item
+// is validated like in CreateAsset, and against the other items of the batch. The assets are only
+// written if all of them are valid, otherwise the error lists the failures of every item. The whole batch
+// counts for the daily limit of the client identity.
+func (s *ContractCertificate) CreateAssetsBatch(ctx contractapi.TransactionContextInterface, request BatchCreateRequest) ([]BatchItemResult, error) {
+	err := checkBatchSize(ctx.GetStub(), len(request.Assets))
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]BatchItemResult, len(request.Assets))
+	assets := make([]*Asset, 0, len(request.Assets))
+	issuers := make([][]*institution.Institution, 0, len(request.Assets))
+	// the world state does not reflect the writes of the transaction, the batch is checked in memory
+	batchKeys := make(map[string]bool)
+	for i, item := range request.Assets {
+		results[i].ID = item.ID
+
+		asset, assetIssuers, err := s.newAsset(ctx, item)
+		if err == nil {
+			err = checkBatchKeys(ctx.GetStub(), asset, batchKeys)
+		}
+		if err != nil {
+			results[i].Code, results[i].Error = lus.ErrorCode(err), lus.LocalizedMessage(ctx, err)
+			continue
+		}
+
+		results[i].Success = true
+		assets = append(assets, asset)
+		issuers = append(issuers, assetIssuers)
+	}
+
+	if len(assets) != len(request.Assets) {
+		return nil, batchError(results)
+	}
+	settings, err := getSettings(ctx.GetStub())
+	if err != nil {
+		return nil, err
+	}
+	err = countIssuance(ctx, len(assets), settings)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, asset := range assets {
+		err = s.invalidateReissued(ctx, asset)
+		if err != nil {
+			return nil, err
+		}
+		err = putNewAsset(ctx.GetStub(), asset, issuers[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// checkBatchSize returns an error unless size is between 1 and the configured maximum batch size
+func checkBatchSize(stub shim.ChaincodeStubInterface, size int) error {
+	settings, err := getSettings(stub)
+	if err != nil {
+		return err
+	}
+	if size == 0 || size > settings.MaxBatchSize {
+		return lus.Errorf(lus.ErrorBatchSize, size, settings.MaxBatchSize)
+	}
+	return nil
+}
+
+// checkBatchKeys returns an error if the id, a registry book entry or the holder and program of asset were
+// already taken by a previous item of the batch, and adds them to batchKeys otherwise
+func checkBatchKeys(stub shim.ChaincodeStubInterface, asset *Asset, batchKeys map[string]bool) error {
+	if batchKeys[asset.ID] {
+		return lus.Errorf(lus.ErrorBatchDuplicated, asset.ID)
+	}
+	keys := []string{asset.ID}
+	if asset.HolderID != "" && asset.Status != Invalid {
+		key, err := holderProgramIndexKey(stub, asset)
+		if err != nil {
+			return err
+		}
+		if batchKeys[key] {
+			return lus.Errorf(lus.ErrorBatchDuplicated, fmt.Sprintf("%s %s", asset.HolderID, asset.ProgramID))
+		}
+		keys = append(keys, key)
+	}
+	for _, registry := range registryEntries(asset) {
+		key, err := registryIndexKey(stub, registry.book, registry.ownerID, registry.entry)
+		if err != nil {
+			return err
+		}
+		if batchKeys[key] {
+			return lus.Errorf(lus.ErrorBatchDuplicated, fmt.Sprintf("%s %s %s", registry.book, registry.ownerID, registry.entry))
+		}
+		keys = append(keys, key)
+	}
+
+	for _, key := range keys {
+		batchKeys[key] = true
+	}
+	return nil
+}
+
+// batchError returns the error of a failed batch, listing the failures of every item
+func batchError(results []BatchItemResult) error {
+	failures := make([]string, 0)
+	items := make([]BatchItemResult, 0)
+	for _, result := range results {
+		if !result.Success {
+			failures = append(failures, result.ID+": "+result.Error)
+			items = append(items, result)
+		}
+	}
+	return lus.Errorf(lus.ErrorBatchFailed, len(failures), len(results), strings.Join(failures, "; ")).WithDetail("items", items)
+}
+
+// ValidateAssetsBatch signs several assets with the same signatory, ex: the rector signing a whole
+// graduating class. Every item goes through the same checks as ValidateAsset. Unless request.BestEffort
+// is set, the transaction fails if any item fails. A single CertificatesValidated event reports the batch.
+func (s *ContractCertificate) ValidateAssetsBatch(ctx contractapi.TransactionContextInterface, request BatchValidateRequest) ([]BatchItemResult, error) {
+	err := checkBatchSize(ctx.GetStub(), len(request.IDs))
+	if err != nil {
+		return nil, err
+	}
+
+	event := BatchValidateEvent{
+		SignatoryID: request.SignatoryID,
+		ValidatorT:  request.ValidatorT,
+		Signed:      make([]string, 0, len(request.IDs)),
+		Failed:      make([]string, 0),
+	}
+	results := make([]BatchItemResult, len(request.IDs))
+	batchIDs := make(map[string]bool)
+	for i, id := range request.IDs {
+		results[i].ID = id
+
+		if batchIDs[id] {
+			err = lus.Errorf(lus.ErrorBatchDuplicated, id)
+		} else {
+			batchIDs[id] = true
+			err = s.signAsset(ctx, id, request.EmitterID, request.SignatoryID, request.ValidatorT)
+		}
+		if err != nil {
+			results[i].Code, results[i].Error = lus.ErrorCode(err), lus.LocalizedMessage(ctx, err)
+			event.Failed = append(event.Failed, id)
+			continue
+		}
+
+		results[i].Success = true
+		event.Signed = append(event.Signed, id)
+	}
+
+	if len(event.Failed) > 0 && !request.BestEffort {
+		return nil, batchError(results)
+	}
+
+	eventJSON, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.GetStub().SetEvent(lus.EventCertificatesValidated, eventJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
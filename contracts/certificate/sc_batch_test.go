@@ -0,0 +1,160 @@
+package certificate
+
+import (
+	"encoding/json"
+	"testing"
+
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestCreateAssetsBatch(t *testing.T) {
+	n := newTestNetwork(t)
+
+	create := func(assets ...*Asset) ([]BatchItemResult, error) {
+		var results []BatchItemResult
+		err := n.submit(n.member, func(ctx contractapi.TransactionContextInterface) (err error) {
+			results, err = n.contract.CreateAssetsBatch(ctx, BatchCreateRequest{Assets: assets})
+			return err
+		})
+		return results, err
+	}
+
+	// a folio repeated inside the batch fails the whole batch
+	_, err := create(newTestAsset("CERT20221122103010", 1), newTestAsset("CERT20221122103011", 1))
+	expectError(t, err, lus.ErrorBatchFailed, 1, 2, "CERT20221122103011: ")
+	err = n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := n.contract.ReadAsset(ctx, GetRequest{ID: "CERT20221122103010"})
+		return err
+	})
+	expectError(t, err, lus.ErrorNotExistInState, "CERT20221122103010")
+
+	// so does a holder repeated for the program
+	_, err = create(newTestAsset("CERT20221122103010", 1), newTestAsset("CERT20221122103011", 2))
+	expectError(t, err, lus.ErrorBatchFailed, 1, 2, "CERT20221122103011: ")
+
+	classmate := newTestAsset("CERT20221122103011", 2)
+	classmate.HolderID = testClassmateID
+	results, err := create(newTestAsset("CERT20221122103010", 1), classmate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if !result.Success {
+			t.Errorf("%s failed: %s", result.ID, result.Error)
+		}
+	}
+	if asset := n.readAsset(t, "CERT20221122103011"); asset.Status != New {
+		t.Errorf("status = %v, want %v", asset.Status, New)
+	}
+
+	_, err = create()
+	expectError(t, err, lus.ErrorBatchSize, 0, DefaultMaxBatchSize)
+}
+
+func TestUpdateSettings(t *testing.T) {
+	n := newTestNetwork(t)
+
+	update := func(identity *mockstub.Identity, size int) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.UpdateSettings(ctx, &SettingsUpdate{Fields: []string{"max_batch_size"}, MaxBatchSize: size})
+		})
+	}
+	expectError(t, update(n.admin, 0), lus.ErrorInvalidSettings, "max_batch_size must be positive")
+	expectError(t, update(n.member, 1), lus.ErrorNotAdmin)
+	for _, fields := range [][]string{nil, {"max_batch_size", "docType"}} {
+		err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.UpdateSettings(ctx, &SettingsUpdate{Fields: fields, MaxBatchSize: 1})
+		})
+		if len(fields) == 0 {
+			expectError(t, err, lus.ErrorInvalidSettings, "fields must list the settings to update")
+		} else {
+			expectError(t, err, lus.ErrorInvalidSettings, "unknown setting docType")
+		}
+	}
+
+	// the settings not listed keep their value
+	n.updateSettings(t, &SettingsUpdate{Fields: []string{"ministry_msp_id", "max_daily_issuance", "office_hours_start", "office_hours_end"}, MinistryMSPID: "MinedMSP", MaxDailyIssuance: 20, OfficeHoursStart: 8, OfficeHoursEnd: 17})
+	expectError(t, n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateSettings(ctx, &SettingsUpdate{Fields: []string{"office_hours_start"}, OfficeHoursStart: 18})
+	}), lus.ErrorInvalidSettings, "the office hours must be between 0 and 24, starting before they end")
+	err := update(n.admin, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := n.contract.CreateAssetsBatch(ctx, BatchCreateRequest{Assets: []*Asset{newTestAsset("CERT20221122103010", 1), newTestAsset("CERT20221122103011", 2)}})
+		return err
+	})
+	expectError(t, err, lus.ErrorBatchSize, 2, 1)
+
+	err = n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		settings, err := n.contract.ReadSettings(ctx)
+		if err != nil {
+			return err
+		} else if settings.MaxBatchSize != 1 || settings.MinistryMSPID != "MinedMSP" || settings.MaxDailyIssuance != 20 ||
+			settings.OfficeHoursStart != 8 || settings.OfficeHoursEnd != 17 || settings.CreatedBy == "" {
+			t.Errorf("settings = %+v", settings)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateAssetsBatch(t *testing.T) {
+	n := newTestNetwork(t)
+	ids := []string{"CERT20221122103010", "CERT20221122103011", "CERT20221122103012"}
+	for i, id := range ids {
+		request := newTestAsset(id, i+1)
+		request.HolderID = testClassIDs[i]
+		n.createAsset(t, request)
+	}
+	if err := n.sign(n.secretary, ids[0], testSecretaryID, Secretary); err != nil {
+		t.Fatal(err)
+	}
+
+	validate := func(bestEffort bool) ([]BatchItemResult, error) {
+		var results []BatchItemResult
+		err := n.submit(n.secretary, func(ctx contractapi.TransactionContextInterface) (err error) {
+			request := BatchValidateRequest{IDs: ids, SignatoryID: testSecretaryID, ValidatorT: Secretary, BestEffort: bestEffort}
+			results, err = n.contract.ValidateAssetsBatch(ctx, request)
+			return err
+		})
+		return results, err
+	}
+
+	// ids[0] is already signed by the secretary
+	_, err := validate(false)
+	expectError(t, err, lus.ErrorBatchFailed, 1, 3, ids[0])
+	if asset := n.readAsset(t, ids[1]); asset.Status != New {
+		t.Errorf("a failed batch signed %s", ids[1])
+	}
+
+	results, err := validate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Success || results[0].Code == "" || !results[1].Success || !results[2].Success {
+		t.Errorf("results = %+v", results)
+	}
+	if asset := n.readAsset(t, ids[2]); asset.Status != SignedS {
+		t.Errorf("status = %v, want %v", asset.Status, SignedS)
+	}
+
+	event := n.stub.LastEvent()
+	if event == nil || event.EventName != lus.EventCertificatesValidated {
+		t.Fatalf("event = %v, want %s", event, lus.EventCertificatesValidated)
+	}
+	var payload BatchValidateEvent
+	err = json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Signed) != 2 || len(payload.Failed) != 1 || payload.Failed[0] != ids[0] {
+		t.Errorf("event payload = %+v", payload)
+	}
+}
@@ -2,12 +2,17 @@ package certificate
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
-	"strings"
 
+	"academic_certificates/contracts/graduate"
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/program"
+	"academic_certificates/contracts/signatory"
 	lus "academic_certificates/libutils"
 	"encoding/json"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
@@ -16,92 +21,135 @@ type ContractCertificate struct {
 	contractapi.Contract
 }
 
-// InitLedger adds a base set of cars to the ledger
-func (s *ContractCertificate) InitLedger(ctx contractapi.TransactionContextInterface) error {
-	var assets []Asset
-	for i := 0; i < 10; i++ {
-		assets = append(assets, Asset{
-			DocType:               lus.CodCert,
-			Certification:         "Licenciado en Derecho",
-			GoldCertificate:       false,
-			Emitter:               "Universidad de La Habana",
-			Accredited:            fmt.Sprintf("Joe Doe %d", i),
-			Date:                  "8 de Noviembre del 2010",
-			SecretaryValidating:   "Mirtha Guerra",
-			DeanValidating:        "",
-			RectorValidating:      "",
-			FacultyVolumeFolio:    "254,136",
-			UniversityVolumeFolio: "158,187",
-			InvalidReason:         "",
-			Status:                SignedS,
-		})
-	}
-	for i := 10; i < 20; i++ {
-		assets = append(assets, Asset{
-			DocType:               lus.CodCert,
-			Certification:         "Licenciado en Química",
-			GoldCertificate:       true,
-			Emitter:               "Universidad de La Habana",
-			Accredited:            fmt.Sprintf("Joe Doe %d", i),
-			Date:                  "10 de Julio del 2018",
-			SecretaryValidating:   "Manuela Azurra",
-			DeanValidating:        "Pedro Navaja",
-			RectorValidating:      "",
-			FacultyVolumeFolio:    "254, 333",
-			UniversityVolumeFolio: "158,781",
-			InvalidReason:         "",
-			Status:                SignedSD,
-		})
-	}
-
-	for i, asset := range assets {
-		var idSlice = make([]string, 0)
-		if i < 9 {
-			var time = "10300" + strconv.Itoa(i+1)
-			idSlice = []string{"2022", "11", "22", time}
-		} else {
-			var time = "1030" + strconv.Itoa(i+1)
-			idSlice = []string{"2022", "11", "22", time}
-		}
+// InitLedger seeds the ledger with the fixtures JSON document (see Fixtures), ex: to set up a test network
+// or a demo environment. It can only be invoked once, by an administrator.
+func (s *ContractCertificate) InitLedger(ctx contractapi.TransactionContextInterface, fixtures string) error {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
 
-		key, err := ctx.GetStub().CreateCompositeKey(lus.CodCert, idSlice)
-		if err != nil {
-			return err
-		}
-		asset.ID = lus.CodCert + strings.Join(idSlice, "")
+	initKey, err := ctx.GetStub().CreateCompositeKey(lus.CodSettings, []string{lus.InitLedgerKey})
+	if err != nil {
+		return err
+	}
+	initialized, err := ctx.GetStub().GetState(initKey)
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	} else if initialized != nil {
+		return lus.Errorf(lus.ErrorAlreadyInitialized)
+	}
 
-		assetJSON, err := json.Marshal(asset)
-		if err != nil {
-			return err
-		}
+	var data Fixtures
+	err = json.Unmarshal([]byte(fixtures), &data)
+	if err != nil {
+		return lus.Errorf(lus.ErrorUnmarshal, err)
+	}
 
-		err = ctx.GetStub().PutState(key, assetJSON)
-		if err != nil {
-			return fmt.Errorf("failed to put to world state: %v", err)
-		}
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return err
+	}
+	err = newFixtureLoader(ctx, audit).load(&data)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	record, err := json.Marshal(InitRecord{DocType: lus.CodSettings, SchemaVersion: SchemaVersion, Audit: audit})
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(initKey, record)
 }
 
-// CreateAsset issues a new asset to the world state with given details.
+// CreateAsset issues a new asset to the world state with given details. A client identity can issue at most
+// the daily limit of the settings, suspicious certificates are flagged and can not be signed until reviewed.
+// A graduate has one certificate per program unless the new one is the reissue (ReissueOf) of the existing
+// one, which is invalidated. The GPA and the honors of the request are ignored, they come from its transcript.
 func (s *ContractCertificate) CreateAsset(ctx contractapi.TransactionContextInterface, request *Asset) error {
-	compositeKey, _, cert, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodCert, request.ID)
+	asset, issuers, err := s.newAsset(ctx, request)
+	if err != nil {
+		return err
+	}
+	settings, err := getSettings(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	err = countIssuance(ctx, 1, settings)
 	if err != nil {
 		return err
+	}
+	err = s.invalidateReissued(ctx, asset)
+	if err != nil {
+		return err
+	}
+
+	return putNewAsset(ctx.GetStub(), asset, issuers)
+}
+
+// newAsset validates the request of a new asset against the world state and builds the asset,
+// it also returns the issuing institutions, starting with the emitter
+func (s *ContractCertificate) newAsset(ctx contractapi.TransactionContextInterface, request *Asset) (*Asset, []*institution.Institution, error) {
+	_, _, cert, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodCert, request.ID)
+	if err != nil {
+		return nil, nil, err
 	} else if cert != nil {
-		return fmt.Errorf(lus.ErrorAlreadyExistInState, request.ID)
+		return nil, nil, lus.Errorf(lus.ErrorAlreadyExistInState, request.ID)
+	}
+
+	date, err := lus.ValidatePastDate(ctx.GetStub(), request.Date)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	emitter, _, err := institution.GetActiveFaculty(ctx.GetStub(), request.EmitterID, request.FacultyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	coIssuers, issuers, err := newCoIssuers(request, emitter, func(institutionID, facultyID string) (*institution.Institution, error) {
+		coIssuer, _, err := institution.GetActiveFaculty(ctx.GetStub(), institutionID, facultyID)
+		return coIssuer, err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	degree, err := program.GetOfferedProgram(ctx.GetStub(), request.ProgramID, request.FacultyID, dateYear(date))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_, err = graduate.GetGraduate(ctx.GetStub(), request.HolderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if request.ReissueOf != "" {
+		reissued, err := getAsset(ctx, request.ReissueOf)
+		if err != nil {
+			return nil, nil, err
+		}
+		if reissued.ID == request.ID || reissued.Status == Invalid || reissued.EmitterID != emitter.ID ||
+			reissued.HolderID != request.HolderID || reissued.ProgramID != degree.ID {
+			return nil, nil, lus.Errorf(lus.ErrorInvalidReissue, reissued.ID, request.ID)
+		}
+	}
+
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	asset := Asset{
 		DocType:               lus.CodCert,
 		ID:                    request.ID,
-		Certification:         request.Certification,
-		GoldCertificate:       request.GoldCertificate,
-		Emitter:               request.Emitter,
-		Accredited:            request.Accredited,
-		Date:                  request.Date,
-		CreatedBy:             request.CreatedBy,
+		ProgramID:             degree.ID,
+		Certification:         degree.Name,
+		EmitterID:             emitter.ID,
+		Emitter:               emitter.Name,
+		FacultyID:             request.FacultyID,
+		HolderID:              request.HolderID,
+		Date:                  date,
 		SecretaryValidating:   "",
 		DeanValidating:        "",
 		RectorValidating:      "",
@@ -109,77 +157,247 @@ func (s *ContractCertificate) CreateAsset(ctx contractapi.TransactionContextInte
 		UniversityVolumeFolio: request.UniversityVolumeFolio,
 		InvalidReason:         "",
 		Status:                New,
+		CoIssuers:             coIssuers,
+		ReissueOf:             request.ReissueOf,
+		Audit:                 audit,
+	}
+
+	err = checkIndexes(ctx.GetStub(), &asset)
+	if err != nil {
+		return nil, nil, err
+	}
+	settings, err := getSettings(ctx.GetStub())
+	if err != nil {
+		return nil, nil, err
+	}
+	err = flagAsset(ctx.GetStub(), &asset, settings)
+	if err != nil {
+		return nil, nil, err
 	}
 
+	return &asset, issuers, nil
+}
+
+// putNewAsset writes a new asset, and its index entries, to the world state. Later changes of the
+// asset need the endorsement of the peers of every issuer organization.
+func putNewAsset(stub shim.ChaincodeStubInterface, asset *Asset, issuers []*institution.Institution) error {
+	compositeKey, _, err := lus.CompositeKeyFromID(stub, lus.CodCert, asset.ID)
+	if err != nil {
+		return err
+	}
+	asset.SchemaVersion = SchemaVersion
 	assetJSON, err := json.Marshal(asset)
 	if err != nil {
 		return err
 	}
+	orgs, err := issuerOrgs(issuers)
+	if err != nil {
+		return err
+	}
+	policy, err := endorsementPolicy(asset.ID, orgs)
+	if err != nil {
+		return err
+	}
 
-	return ctx.GetStub().PutState(compositeKey, assetJSON)
+	err = putIndexes(stub, asset)
+	if err != nil {
+		return err
+	}
+	err = stub.SetStateValidationParameter(compositeKey, policy)
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(compositeKey, assetJSON)
 }
 
-// ReadAsset returns the asset stored in the world state with given id.
+// invalidateReissued invalidates the certificate replaced by asset, if it is a reissue
+func (s *ContractCertificate) invalidateReissued(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	if asset.ReissueOf == "" {
+		return nil
+	}
+	reissued, err := getAsset(ctx, asset.ReissueOf)
+	if err != nil {
+		return err
+	}
+	reissued.Status = Invalid
+	reissued.InvalidReason = fmt.Sprintf("reissued as %s", asset.ID)
+
+	return s.updateAsset(ctx, reissued)
+}
+
+// ReadAsset returns the asset stored in the world state with given id. Its signatories and registry book
+// entries are only returned to its holder and issuers, see redactAsset.
 func (s *ContractCertificate) ReadAsset(ctx contractapi.TransactionContextInterface, request GetRequest) (*Asset, error) {
-	_, _, assetJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodCert, request.ID)
+	asset, err := getAsset(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = redactAsset(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+// getAsset returns the asset stored in the world state with given id, with its labels in the language of the request
+func getAsset(ctx contractapi.TransactionContextInterface, id string) (*Asset, error) {
+	_, _, assetJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodCert, id)
 	if err != nil {
 		return nil, err
 	} else if assetJSON == nil {
-		return nil, fmt.Errorf(lus.ErrorNotExistInState, request.ID)
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
 	}
 
-	var asset Asset
-	err = json.Unmarshal(assetJSON, &asset)
+	asset, err := unmarshalAsset(assetJSON)
 	if err != nil {
 		return nil, err
 	}
+	lang := lus.GetLanguage(ctx)
+	asset.StatusLabel = asset.Status.Localize(lang)
+	asset.HonorsLabel = asset.Honors.Localize(lang)
 
-	return &asset, nil
+	return asset, nil
 }
 
 // UpdateAsset updates an existing asset in the world state with provided parameters.
+// Signatures, status, honors, reissue and fraud flags are kept, they can only change through ValidateAsset, InvalidateAsset,
+// OverrideHonors and ReviewFlags. The emitter, faculty, program, date and holder can not change once it is signed.
 func (s *ContractCertificate) UpdateAsset(ctx contractapi.TransactionContextInterface, request *Asset) error {
+	stored, err := getAsset(ctx, request.ID)
+	if err != nil {
+		return err
+	}
+
+	request.SecretaryValidating = stored.SecretaryValidating
+	request.DeanValidating = stored.DeanValidating
+	request.RectorValidating = stored.RectorValidating
+	request.SecretaryID = stored.SecretaryID
+	request.DeanID = stored.DeanID
+	request.RectorID = stored.RectorID
+	request.InvalidReason = stored.InvalidReason
+	request.Status = stored.Status
+	request.CoIssuers = stored.CoIssuers
+	request.GoldCertificate = stored.GoldCertificate
+	request.GPA = stored.GPA
+	request.FailedCourses = stored.FailedCourses
+	request.Honors = stored.Honors
+	request.HonorsOverride = stored.HonorsOverride
+	request.ReissueOf = stored.ReissueOf
+	request.Flags = stored.Flags
+	request.FlagReview = stored.FlagReview
+
+	return s.updateAsset(ctx, request)
+}
+
+// updateAsset overwrites an existing asset in the world state after checking its consistency.
+func (s *ContractCertificate) updateAsset(ctx contractapi.TransactionContextInterface, request *Asset) error {
 	compositeKey, _, assetJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodCert, request.ID)
 	if err != nil {
 		return err
 	} else if assetJSON == nil {
-		return fmt.Errorf(lus.ErrorNotExistInState, request.ID)
+		return lus.Errorf(lus.ErrorNotExistInState, request.ID)
 	}
-	// Check new params of the asset consistency
-
-	// If certificate is valid then it should have the 3 signatures
-	if (request.Status == Valid) && (request.SecretaryValidating == "" || request.DeanValidating == "" || request.RectorValidating == "") {
-		return fmt.Errorf(lus.ErrorInconsistentStatus)
+	err = checkStatus(request)
+	if err != nil {
+		return err
 	}
-	// If certificate is SignedSD then it should have Secretary and Dean signatures
-	if (request.Status == SignedSD) && (request.SecretaryValidating == "" || request.DeanValidating == "") {
-		return fmt.Errorf(lus.ErrorInconsistentStatus)
+	// Audit fields are never taken from the request, keep the stored ones
+	stored, err := unmarshalAsset(assetJSON)
+	if err != nil {
+		return err
 	}
-	// If certificate is SignedSD then it should have Secretary and Dean signatures
-	if (request.Status == SignedS) && (request.SecretaryValidating == "") {
-		return fmt.Errorf(lus.ErrorInconsistentStatus)
+	err = stored.Audit.Stamp(ctx)
+	if err != nil {
+		return err
 	}
-	// If certificate is revoked then it should have a revoked reason
-	if (request.Status == Invalid) && (request.InvalidReason == "") {
-		return fmt.Errorf(lus.ErrorInconsistentInvalidation)
+	// Old records may still hold the date in the Spanish long format, normalize it
+	date, err := lus.ValidatePastDate(ctx.GetStub(), request.Date)
+	if err != nil {
+		return err
+	}
+	storedDate, _ := lus.NormalizeDate(stored.Date)
+	// the signatories signed the issuance data, it can not change afterwards
+	if stored.signed() {
+		err = checkSignedFields(stored, request, date, storedDate)
+		if err != nil {
+			return err
+		}
+	}
+	// the emitter can not be moved to one of the co-issuers, as on creation
+	for _, coIssuer := range request.CoIssuers {
+		if coIssuer.EmitterID == request.EmitterID {
+			return lus.Errorf(lus.ErrorIssuerDuplicated, request.EmitterID, request.ID)
+		}
+	}
+	// A certificate can only be moved to a registered and active institution and faculty
+	emitterName := stored.Emitter
+	if request.EmitterID != stored.EmitterID || request.FacultyID != stored.FacultyID {
+		emitter, _, err := institution.GetActiveFaculty(ctx.GetStub(), request.EmitterID, request.FacultyID)
+		if err != nil {
+			return err
+		}
+		emitterName = emitter.Name
+	}
+	// The program must be offered by the faculty in the year of the certificate
+	certification := stored.Certification
+	var degree *program.Program
+	if request.ProgramID != stored.ProgramID || request.FacultyID != stored.FacultyID || date != storedDate {
+		degree, err = program.GetOfferedProgram(ctx.GetStub(), request.ProgramID, request.FacultyID, dateYear(date))
+		if err != nil {
+			return err
+		}
+		certification = degree.Name
+	}
+	// the name of a registered graduate is only kept in the graduates private data collection
+	accredited := request.Accredited
+	if request.HolderID != "" {
+		accredited = ""
+	}
+	if request.HolderID != stored.HolderID {
+		_, err = graduate.GetGraduate(ctx.GetStub(), request.HolderID)
+		if err != nil {
+			return err
+		}
 	}
 	// overwritting original asset with new asset
 	asset := Asset{
 		DocType:               lus.CodCert,
+		SchemaVersion:         SchemaVersion,
 		ID:                    request.ID,
-		Certification:         request.Certification,
+		ProgramID:             request.ProgramID,
+		Certification:         certification,
 		GoldCertificate:       request.GoldCertificate,
-		Emitter:               request.Emitter,
-		Accredited:            request.Accredited,
-		Date:                  request.Date,
-		CreatedBy:             request.CreatedBy,
+		EmitterID:             request.EmitterID,
+		Emitter:               emitterName,
+		FacultyID:             request.FacultyID,
+		HolderID:              request.HolderID,
+		Accredited:            accredited,
+		Date:                  date,
 		SecretaryValidating:   request.SecretaryValidating,
 		DeanValidating:        request.DeanValidating,
 		RectorValidating:      request.RectorValidating,
+		SecretaryID:           request.SecretaryID,
+		DeanID:                request.DeanID,
+		RectorID:              request.RectorID,
 		FacultyVolumeFolio:    request.FacultyVolumeFolio,
 		UniversityVolumeFolio: request.UniversityVolumeFolio,
 		InvalidReason:         request.InvalidReason,
 		Status:                request.Status,
+		CoIssuers:             request.CoIssuers,
+		GPA:                   request.GPA,
+		FailedCourses:         request.FailedCourses,
+		Honors:                request.Honors,
+		HonorsOverride:        request.HonorsOverride,
+		ReissueOf:             request.ReissueOf,
+		Flags:                 request.Flags,
+		FlagReview:            request.FlagReview,
+		Audit:                 stored.Audit,
+	}
+	// the honors follow the rules of the new program, the certificate is not signed yet
+	if asset.ProgramID != stored.ProgramID {
+		asset.evaluateHonors(degree)
 	}
 
 	assetJSON, err = json.Marshal(asset)
@@ -187,35 +405,154 @@ func (s *ContractCertificate) UpdateAsset(ctx contractapi.TransactionContextInte
 		return err
 	}
 
+	err = checkIndexes(ctx.GetStub(), &asset)
+	if err != nil {
+		return err
+	}
+	// the key-level policy follows the certificate to its new emitter
+	var policy []byte
+	if asset.EmitterID != stored.EmitterID {
+		issuers, err := getIssuers(ctx.GetStub(), &asset)
+		if err != nil {
+			return err
+		}
+		orgs, err := issuerOrgs(issuers)
+		if err != nil {
+			return err
+		}
+		policy, err = endorsementPolicy(asset.ID, orgs)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = delIndexes(ctx.GetStub(), stored)
+	if err != nil {
+		return err
+	}
+	err = putIndexes(ctx.GetStub(), &asset)
+	if err != nil {
+		return err
+	}
+	if policy != nil {
+		err = ctx.GetStub().SetStateValidationParameter(compositeKey, policy)
+		if err != nil {
+			return err
+		}
+		err = setTranscriptPolicy(ctx.GetStub(), asset.ID, policy)
+		if err != nil {
+			return err
+		}
+	}
+
 	return ctx.GetStub().PutState(compositeKey, assetJSON)
 }
 
+// checkSignedFields returns an error if request changes the issuer, program, date or holder of the
+// signed certificate stored. date and storedDate are the normalized dates of both.
+func checkSignedFields(stored, request *Asset, date, storedDate string) error {
+	fields := []struct {
+		name    string
+		changed bool
+	}{
+		{"emitter_id", request.EmitterID != stored.EmitterID},
+		{"faculty_id", request.FacultyID != stored.FacultyID},
+		{"program_id", request.ProgramID != stored.ProgramID},
+		{"date", date != storedDate},
+		{"holder_id", request.HolderID != stored.HolderID},
+	}
+	for _, field := range fields {
+		if field.changed {
+			return lus.Errorf(lus.ErrorSignedLocked, field.name, stored.ID)
+		}
+	}
+	return nil
+}
+
+// checkStatus returns an error unless the status of asset matches its signatures and invalid reason
+func checkStatus(asset *Asset) error {
+	// If certificate is valid then it should have the 3 signatures
+	if (asset.Status == Valid) && (asset.SecretaryValidating == "" || asset.DeanValidating == "" || asset.RectorValidating == "") {
+		return lus.Errorf(lus.ErrorInconsistentStatus)
+	}
+	// If certificate is SignedSD then it should have Secretary and Dean signatures
+	if (asset.Status == SignedSD) && (asset.SecretaryValidating == "" || asset.DeanValidating == "") {
+		return lus.Errorf(lus.ErrorInconsistentStatus)
+	}
+	// If certificate is SignedS then it should have the Secretary signature
+	if (asset.Status == SignedS) && (asset.SecretaryValidating == "") {
+		return lus.Errorf(lus.ErrorInconsistentStatus)
+	}
+	// If certificate is revoked then it should have a revoked reason
+	if (asset.Status == Invalid) && (asset.InvalidReason == "") {
+		return lus.Errorf(lus.ErrorInconsistentInvalidation)
+	}
+	// Every co-issuer chain must match its signatures, and not be behind the certificate
+	return checkCoIssuers(asset)
+}
+
 // ValidateAsset Validate an existing asset in the world state with provided parameters.
+// The signatory must hold the required role in the faculty (or institution, for the rector) of
+// the signed issuer at the transaction timestamp, and the client identity must be the signatory one.
+// A co-issued certificate is valid once the chains of the emitter and every co-issuer are complete.
 func (s *ContractCertificate) ValidateAsset(ctx contractapi.TransactionContextInterface, request *ValidateAsset) error {
-	asset, err := s.ReadAsset(ctx, GetRequest{ID: request.ID})
+	return s.signAsset(ctx, request.ID, request.EmitterID, request.SignatoryID, request.ValidatorT)
+}
+
+// signAsset adds the signature of the signatory, holding role, to the chain of the issuer emitterID
+// (the emitter if empty) of the asset with given id
+func (s *ContractCertificate) signAsset(ctx contractapi.TransactionContextInterface, id, emitterID, signatoryID string, role ValidatorType) error {
+	asset, err := getAsset(ctx, id)
 	if err != nil {
 		return err
 	}
+	if asset.Status == Invalid {
+		return lus.Errorf(lus.ErrorInconsistentValidation)
+	} else if asset.waitsForReview() {
+		return lus.Errorf(lus.ErrorCertificateFlagged, asset.ID)
+	}
 
-	if request.ValidatorT == Secretary && asset.Status == New {
-		asset.SecretaryValidating = request.Validator
-		asset.Status = SignedS
-	} else if request.ValidatorT == Dean && asset.Status == SignedS {
-		asset.DeanValidating = request.Validator
-		asset.Status = SignedSD
-	} else if request.ValidatorT == Rector && asset.Status == SignedSD {
-		asset.RectorValidating = request.Validator
-		asset.Status = Valid
-	} else {
-		return fmt.Errorf(lus.ErrorInconsistentValidation)
+	chain, index, err := asset.issuerChain(emitterID)
+	if err != nil {
+		return err
+	}
+	err = chain.checkNextSignature(role)
+	if err != nil {
+		return err
+	}
+	signer, err := signatory.CheckSigner(ctx, signatoryID, role, chain.EmitterID, chain.FacultyID)
+	if err != nil {
+		return err
+	}
+	chain.addSignature(role, signer)
+	asset.setIssuerChain(index, chain)
+	asset.Status = asset.issuanceStatus()
+
+	// certificates issued before the key-level policies get it once they are valid, the policy is
+	// built before writing the signature so that a batch never keeps a signature whose policy failed
+	var key string
+	var policy []byte
+	if asset.Status == Valid {
+		key, policy, err = issuersEndorsement(ctx.GetStub(), asset)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.updateAsset(ctx, asset)
+	if err != nil {
+		return err
+	}
+	if policy != nil {
+		return ctx.GetStub().SetStateValidationParameter(key, policy)
 	}
 
-	return s.UpdateAsset(ctx, asset)
+	return nil
 }
 
 // InvalidateAsset Invalidate an existing asset in the world state and insert the reason.
 func (s *ContractCertificate) InvalidateAsset(ctx contractapi.TransactionContextInterface, request *InvalidateAsset) error {
-	asset, err := s.ReadAsset(ctx, GetRequest{ID: request.ID})
+	asset, err := getAsset(ctx, request.ID)
 	if err != nil {
 		return err
 	}
@@ -223,7 +560,7 @@ func (s *ContractCertificate) InvalidateAsset(ctx contractapi.TransactionContext
 	asset.Status = Invalid
 	asset.InvalidReason = request.Description
 
-	return s.UpdateAsset(ctx, asset)
+	return s.updateAsset(ctx, asset)
 }
 
 // DeleteAsset deletes an given asset from the world state.
@@ -232,7 +569,7 @@ func (s *ContractCertificate) DeleteAsset(ctx contractapi.TransactionContextInte
 	if err != nil {
 		return err
 	} else if assetJSON == nil {
-		return fmt.Errorf(lus.ErrorNotExistInState, request.ID)
+		return lus.Errorf(lus.ErrorNotExistInState, request.ID)
 	}
 
 	compositeKeyDeleted, err := lus.CreateCompositeKeyToDelete(ctx.GetStub(), lus.CodCert, responseKey)
@@ -246,14 +583,160 @@ func (s *ContractCertificate) DeleteAsset(ctx contractapi.TransactionContextInte
 	//  Note - passing a 'nil' value will effectively delete the key from state, therefore we pass null character as value
 	err = ctx.GetStub().PutState(compositeKeyDeleted, []byte{0x00})
 	if err != nil {
-		return fmt.Errorf("failed to put to world state: %v", err)
+		return lus.Errorf(lus.ErrorWorldState, err)
 	}
 
 	fmt.Println("--> end", compositeKeyDeleted)
 
+	asset, err := unmarshalAsset(assetJSON)
+	if err != nil {
+		return err
+	}
+	err = delIndexes(ctx.GetStub(), asset)
+	if err != nil {
+		return err
+	}
+
 	return ctx.GetStub().DelState(compositeKey)
 }
 
+// QueryAssetsByDateRange returns the certificates issued between request.From and request.To (inclusive).
+// Only available on state databases that support rich query (e.g. CouchDB)
+func (s *ContractCertificate) QueryAssetsByDateRange(ctx contractapi.TransactionContextInterface, request DateRangeRequest) ([]*Asset, error) {
+	dateSelector := make(map[string]interface{})
+	if request.From != "" {
+		from, err := lus.NormalizeDate(request.From)
+		if err != nil {
+			return nil, err
+		}
+		dateSelector["$gte"] = from
+	}
+	if request.To != "" {
+		to, err := lus.NormalizeDate(request.To)
+		if err != nil {
+			return nil, err
+		}
+		dateSelector["$lte"] = to
+	}
+	if from, ok := dateSelector["$gte"]; ok {
+		if to, ok := dateSelector["$lte"]; ok && from.(string) > to.(string) {
+			return nil, lus.Errorf(lus.ErrorInvalidDateRange, from, to)
+		}
+	}
+
+	selector := map[string]interface{}{"docType": lus.CodCert}
+	if len(dateSelector) > 0 {
+		selector["date"] = dateSelector
+	}
+	assets, err := queryAssets(ctx, map[string]interface{}{"selector": selector})
+	if err != nil {
+		return nil, err
+	}
+
+	// sorting in the chaincode avoids requiring a CouchDB index on date
+	sort.SliceStable(assets, func(i, j int) bool {
+		return assets[i].Date < assets[j].Date
+	})
+	for _, asset := range assets {
+		err = redactAsset(ctx, asset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return assets, nil
+}
+
+// QueryAssetsByHolder returns the certificates of the graduate with given id. Only the
+// pseudonymous graduate id is needed, the identity of the holder is never disclosed: it is
+// read from the graduates private data collection, see graduate.ReadGraduateIdentity.
+func (s *ContractCertificate) QueryAssetsByHolder(ctx contractapi.TransactionContextInterface, request GetRequest) ([]*Asset, error) {
+	ids, err := idsFromIndex(ctx.GetStub(), lus.CodHolder, []string{request.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make([]*Asset, 0, len(ids))
+	for _, id := range ids {
+		asset, err := s.ReadAsset(ctx, GetRequest{ID: id})
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// ReadAssetByRegistryEntry returns the certificate registered in the given volume and folio
+// of the registry book of a faculty or university. The entries are private, only the holder and
+// the issuers of the certificate find it.
+func (s *ContractCertificate) ReadAssetByRegistryEntry(ctx contractapi.TransactionContextInterface, request RegistryEntryRequest) (*Asset, error) {
+	entry := VolumeFolio{Volume: request.Volume, Folio: request.Folio}
+	key, err := registryIndexKey(ctx.GetStub(), request.Book, request.OwnerID, entry)
+	if err != nil {
+		return nil, err
+	}
+	id, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	} else if id == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, entry)
+	}
+
+	asset, err := getAsset(ctx, string(id))
+	if err != nil {
+		return nil, err
+	}
+	// other clients can not tell a taken entry from a free one
+	err = checkAssetReader(ctx, asset)
+	if err != nil && lus.AsError(err).Category == lus.CategoryForbidden {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, entry)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
+
+// dateYear returns the year of a date normalized with lus.DateLayout
+func dateYear(date string) int {
+	year, _ := strconv.Atoi(date[:4])
+	return year
+}
+
+// queryAssets executes a rich query and unmarshals the results as certificates
+func queryAssets(ctx contractapi.TransactionContextInterface, query map[string]interface{}) ([]*Asset, error) {
+	queryString, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryString))
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	lang := lus.GetLanguage(ctx)
+	assets := make([]*Asset, 0)
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		asset, err := unmarshalAsset(queryResult.Value)
+		if err != nil {
+			return nil, err
+		}
+		asset.StatusLabel = asset.Status.Localize(lang)
+		asset.HonorsLabel = asset.Honors.Localize(lang)
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
 func (s *ContractCertificate) GetEvaluateTransactions() []string {
-	return []string{"ReadAsset"}
+	return []string{"ReadAsset", "QueryAssetsByDateRange", "QueryAssetsByHolder", "ReadAssetByRegistryEntry", "ReadSettings", "ReadAssetEndorsement", "VerifyCertificate", "ReadLegalization", "ReadTranscript", "ReadTranscriptByCertificate", "ReadSharedCertificate", "QueryVerificationLog", "QueryFlaggedAssets", "QueryMigrationPage"}
 }
@@ -0,0 +1,753 @@
+package certificate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"academic_certificates/contracts/common"
+	"academic_certificates/contracts/graduate"
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/program"
+	"academic_certificates/contracts/signatory"
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	testInstitutionID = "INST20221122103000"
+	testLawID         = "FACU20221122103001"
+	testChemistryID   = "FACU20221122103002"
+	testLawProgramID  = "PROG20221122103003"
+	testChemProgramID = "PROG20221122103004"
+	testHolderID      = "GRAD20221122103005"
+	testOtherHolderID = "GRAD20221122103006"
+	testClassmateID   = "GRAD20221122103090"
+	testSecretaryID   = "SIGN20221122103007"
+	testDeanID        = "SIGN20221122103008"
+	testRectorID      = "SIGN20221122103009"
+)
+
+// testClassIDs graduates of the same class, a graduate has one certificate of a program
+var testClassIDs = []string{testHolderID, testOtherHolderID, testClassmateID, "GRAD20221122103091", "GRAD20221122103092", "GRAD20221122103093"}
+
+// testNetwork world state seeded with an institution, its faculties, programs, signatories and graduates
+type testNetwork struct {
+	stub     *mockstub.Stub
+	contract *ContractCertificate
+
+	admin     *mockstub.Identity
+	member    *mockstub.Identity // member of the institution organization
+	secretary *mockstub.Identity
+	dean      *mockstub.Identity
+	rector    *mockstub.Identity
+}
+
+func newTestNetwork(t *testing.T) *testNetwork {
+	t.Helper()
+
+	n := &testNetwork{
+		stub:      mockstub.NewStub("mychannel"),
+		contract:  new(ContractCertificate),
+		admin:     mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType}),
+		member:    mockstub.MustIdentity("Org1MSP", "User1@org1.example.com", map[string]string{lus.AttrType: "client"}),
+		secretary: mockstub.MustIdentity("Org1MSP", "Secretary@org1.example.com", nil),
+		dean:      mockstub.MustIdentity("Org1MSP", "Dean@org1.example.com", nil),
+		rector:    mockstub.MustIdentity("Org1MSP", "Rector@org1.example.com", nil),
+	}
+	n.stub.SetTime(time.Date(2022, 11, 22, 10, 30, 0, 0, time.UTC))
+
+	fixtures, err := json.Marshal(n.fixtures())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.InitLedger(ctx, string(fixtures))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+// fixtures returns the seed data of the network, without certificates
+func (n *testNetwork) fixtures() *Fixtures {
+	graduates := make([]*graduate.Graduate, 0, len(testClassIDs))
+	for _, id := range testClassIDs {
+		graduates = append(graduates, &graduate.Graduate{ID: id, InstitutionID: testInstitutionID})
+	}
+
+	return &Fixtures{
+		Institutions: []*institution.Institution{
+			{ID: testInstitutionID, Name: "Universidad de La Habana", Acronym: "UH", MSPID: "Org1MSP", Active: true},
+		},
+		Faculties: []*institution.Faculty{
+			{ID: testLawID, InstitutionID: testInstitutionID, Name: "Facultad de Derecho", Active: true},
+			{ID: testChemistryID, InstitutionID: testInstitutionID, Name: "Facultad de Química", Active: true},
+		},
+		Programs: []*program.Program{
+			{ID: testLawProgramID, Code: "DER", Name: "Licenciado en Derecho", Level: program.Bachelor, FacultyID: testLawID, FirstYear: 1990},
+			{ID: testChemProgramID, Code: "QUI", Name: "Licenciado en Química", Level: program.Bachelor, FacultyID: testChemistryID, FirstYear: 2012},
+		},
+		Signatories: []*signatory.Signatory{
+			{ID: testSecretaryID, Name: "Ana Pérez", Role: common.Secretary, InstitutionID: testInstitutionID, FacultyID: testLawID, TermStart: "2020-01-01", Certificate: n.secretary.CertificatePEM},
+			{ID: testDeanID, Name: "Luis Gómez", Role: common.Dean, InstitutionID: testInstitutionID, FacultyID: testLawID, TermStart: "2020-01-01", Certificate: n.dean.CertificatePEM},
+			{ID: testRectorID, Name: "Miriam Nicado", Role: common.Rector, InstitutionID: testInstitutionID, TermStart: "2020-01-01", Certificate: n.rector.CertificatePEM},
+		},
+		Graduates: graduates,
+	}
+}
+
+func (n *testNetwork) submit(identity *mockstub.Identity, fn mockstub.TxFunc) error {
+	return n.stub.Submit(identity, fn)
+}
+
+// newTestAsset returns a request for a law certificate with the given faculty registry book folio
+func newTestAsset(id string, folio int) *Asset {
+	return &Asset{
+		ID:                 id,
+		ProgramID:          testLawProgramID,
+		EmitterID:          testInstitutionID,
+		FacultyID:          testLawID,
+		HolderID:           testHolderID,
+		Date:               "2010-11-08",
+		FacultyVolumeFolio: VolumeFolio{Volume: 1, Folio: folio},
+		Status:             New,
+	}
+}
+
+func (n *testNetwork) createAsset(t *testing.T, request *Asset) {
+	t.Helper()
+	err := n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateAsset(ctx, request)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (n *testNetwork) readAsset(t *testing.T, id string) *Asset {
+	t.Helper()
+	var asset *Asset
+	err := n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) (err error) {
+		asset, err = n.contract.ReadAsset(ctx, GetRequest{ID: id})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return asset
+}
+
+func (n *testNetwork) sign(identity *mockstub.Identity, id, signatoryID string, role ValidatorType) error {
+	return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.ValidateAsset(ctx, &ValidateAsset{ID: id, SignatoryID: signatoryID, ValidatorT: role})
+	})
+}
+
+// expectError fails the test unless err contains the error message built from format and args
+func expectError(t *testing.T, err error, format string, args ...interface{}) {
+	t.Helper()
+	want := fmt.Sprintf(format, args...)
+	if err == nil {
+		t.Fatalf("expected error %q, got nil", want)
+	} else if !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error %q, got %q", want, err)
+	}
+}
+
+func TestInitLedger(t *testing.T) {
+	n := newTestNetwork(t)
+
+	fixtures, _ := json.Marshal(Fixtures{})
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.InitLedger(ctx, string(fixtures))
+	})
+	expectError(t, err, lus.ErrorAlreadyInitialized)
+
+	signer, err := signatory.GetSignatory(n.stub, testRectorID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.DocType != lus.CodSignatory || signer.CreatedBy == "" {
+		t.Errorf("the fixtures were not stamped: %+v", signer)
+	}
+	degree, err := program.GetProgram(n.stub, testChemProgramID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if degree.InstitutionID != testInstitutionID {
+		t.Errorf("program institution = %s, want %s", degree.InstitutionID, testInstitutionID)
+	}
+}
+
+func TestInitLedgerRequiresAdmin(t *testing.T) {
+	stub := mockstub.NewStub("mychannel")
+	member := mockstub.MustIdentity("Org1MSP", "User1@org1.example.com", nil)
+
+	err := stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		return new(ContractCertificate).InitLedger(ctx, "{}")
+	})
+	expectError(t, err, lus.ErrorNotAdmin)
+}
+
+func TestInitLedgerRejectsInvalidFixtures(t *testing.T) {
+	stub := mockstub.NewStub("mychannel")
+	admin := mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+	network := &testNetwork{secretary: admin, dean: admin, rector: admin}
+
+	tests := []struct {
+		name   string
+		change func(f *Fixtures)
+		want   string
+	}{
+		{"unknown faculty", func(f *Fixtures) { f.Programs[0].FacultyID = "FACU20000101000000" }, fmt.Sprintf(lus.ErrorNotExistInState, "FACU20000101000000")},
+		{"repeated id", func(f *Fixtures) { f.Graduates[1].ID = testHolderID }, fmt.Sprintf(lus.ErrorAlreadyExistInState, testHolderID)},
+		{"invalid certificate pem", func(f *Fixtures) { f.Signatories[0].Certificate = "none" }, lus.ErrorParseX509},
+		{"term ending before its start", func(f *Fixtures) { f.Signatories[0].TermEnd = "2019-12-31" },
+			fmt.Sprintf(lus.ErrorInvalidTerm, "2019-12-31", "2020-01-01")},
+		{"overlapping terms", func(f *Fixtures) {
+			secretary := *f.Signatories[0]
+			secretary.ID, secretary.TermStart = "SIGN20221122103099", "2021-06-01"
+			f.Signatories = append(f.Signatories, &secretary)
+		}, fmt.Sprintf(lus.ErrorTermOverlap, testSecretaryID)},
+		{"program not offered in the year", func(f *Fixtures) {
+			asset := newTestAsset("CERT20221122103010", 1)
+			asset.FacultyID, asset.ProgramID = testChemistryID, testChemProgramID
+			f.Certificates = []*Asset{asset}
+		}, fmt.Sprintf(lus.ErrorProgramYear, testChemProgramID, 2010)},
+		{"repeated folio", func(f *Fixtures) {
+			f.Certificates = []*Asset{newTestAsset("CERT20221122103010", 1), newTestAsset("CERT20221122103011", 1)}
+		}, "is repeated in the batch"},
+		{"future certificate", func(f *Fixtures) {
+			asset := newTestAsset("CERT20221122103010", 1)
+			asset.Date = "2030-01-01"
+			f.Certificates = []*Asset{asset}
+		}, fmt.Sprintf(lus.ErrorFutureDate, "2030-01-01")},
+		{"invalid without reason", func(f *Fixtures) {
+			asset := newTestAsset("CERT20221122103010", 1)
+			asset.Status = Invalid
+			f.Certificates = []*Asset{asset}
+		}, lus.ErrorInconsistentInvalidation},
+		{"status without signatures", func(f *Fixtures) {
+			asset := newTestAsset("CERT20221122103010", 1)
+			asset.Status, asset.SecretaryValidating = SignedSD, "Ana Pérez"
+			f.Certificates = []*Asset{asset}
+		}, lus.ErrorInconsistentStatus},
+		{"co-issuer ahead of its signatures", func(f *Fixtures) {
+			f.Institutions = append(f.Institutions, &institution.Institution{ID: testPartnerID, Name: "Universidad de Oriente", MSPID: "Org2MSP", Active: true})
+			f.Faculties = append(f.Faculties, &institution.Faculty{ID: testPartnerFacultyID, InstitutionID: testPartnerID, Name: "Facultad de Derecho", Active: true})
+			asset := newTestAsset("CERT20221122103010", 1)
+			asset.CoIssuers = []CoIssuer{{EmitterID: testPartnerID, FacultyID: testPartnerFacultyID, Status: Valid}}
+			f.Certificates = []*Asset{asset}
+		}, lus.ErrorInconsistentStatus},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fixtures := network.fixtures()
+			test.change(fixtures)
+			fixturesJSON, _ := json.Marshal(fixtures)
+
+			err := stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+				return new(ContractCertificate).InitLedger(ctx, string(fixturesJSON))
+			})
+			expectError(t, err, test.want)
+			if keys := stub.Keys(); len(keys) != 0 {
+				t.Errorf("a failed InitLedger wrote %d keys", len(keys))
+			}
+		})
+	}
+}
+
+func TestInitLedgerDemoFixtures(t *testing.T) {
+	stub := mockstub.NewStub("mychannel")
+	stub.SetTime(time.Date(2022, 11, 22, 10, 30, 0, 0, time.UTC))
+	admin := mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+	fixtures, err := ioutil.ReadFile("../../fixtures/demo.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contract := new(ContractCertificate)
+	err = stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return contract.InitLedger(ctx, string(fixtures))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stub.Evaluate(admin, func(ctx contractapi.TransactionContextInterface) error {
+		for _, id := range []string{"CERT20221122103007", "CERT20221122103008"} {
+			asset, err := contract.ReadAsset(ctx, GetRequest{ID: id})
+			if err != nil {
+				return err
+			} else if asset.Status != New {
+				t.Errorf("%s status = %v, want %v", id, asset.Status, New)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInitLedgerThroughChaincode(t *testing.T) {
+	stub := mockstub.NewStub("mychannel")
+	admin := mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+	contract := new(ContractCertificate)
+	contract.Name = lus.ContractNameCertificate
+	chaincode, err := contractapi.NewChaincode(contract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures := (&testNetwork{secretary: admin, dean: admin, rector: admin}).fixtures()
+	fixtures.Certificates = []*Asset{newTestAsset("CERT20221122103010", 1)}
+	fixturesJSON, _ := json.Marshal(fixtures)
+
+	response := stub.Invoke(admin, chaincode, "certificate:InitLedger", string(fixturesJSON))
+	if response.Status != 200 {
+		t.Fatalf("InitLedger: %s", response.Message)
+	}
+	response = stub.Invoke(admin, chaincode, "certificate:ReadAsset", `{"id":"CERT20221122103010"}`)
+	if response.Status != 200 {
+		t.Fatalf("ReadAsset: %s", response.Message)
+	}
+	var asset Asset
+	err = json.Unmarshal(response.Payload, &asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset.Emitter != "Universidad de La Habana" || asset.Certification != "Licenciado en Derecho" {
+		t.Errorf("fixture certificate names not resolved: %+v", asset)
+	}
+
+	// errors reach the client as JSON with a stable code
+	response = stub.Invoke(admin, chaincode, "certificate:ReadAsset", `{"id":"CERT20000101000000"}`)
+	var e lus.Error
+	if err = json.Unmarshal([]byte(response.Message), &e); err != nil {
+		t.Fatalf("ReadAsset error %q is not JSON: %v", response.Message, err)
+	}
+	if e.Code != lus.CodeNotFound || e.Category != lus.CategoryNotFound || e.Details["id"] == nil {
+		t.Errorf("ReadAsset error = %+v", e)
+	}
+
+	// the localized chaincode renders errors and labels in the language of the request
+	localized := lus.NewLocalizedChaincode(chaincode)
+	response = stub.Invoke(admin, localized, "certificate:ReadAsset", `{"id":"CERT20000101000000"}`)
+	e = lus.Error{}
+	_ = json.Unmarshal([]byte(response.Message), &e)
+	if e.Code != lus.CodeNotFound || e.Message != "no existe CERT20000101000000 en el estado" || e.Messages != nil {
+		t.Errorf("Spanish error = %+v", e)
+	}
+	stub.SetTransient(lus.TransientLanguage, []byte("en"))
+	response = stub.Invoke(admin, localized, "certificate:ReadAsset", `{"id":"CERT20000101000000"}`)
+	e = lus.Error{}
+	_ = json.Unmarshal([]byte(response.Message), &e)
+	if e.Message != "no state found for CERT20000101000000" {
+		t.Errorf("English error = %+v", e)
+	}
+
+	english := mockstub.MustIdentity("Org1MSP", "User1@org1.example.com", map[string]string{lus.AttrLanguage: "en-US"})
+	response = stub.Invoke(english, localized, "certificate:ReadAsset", `{"id":"CERT20221122103010"}`)
+	_ = json.Unmarshal(response.Payload, &asset)
+	if asset.StatusLabel != "Missing Secretary, Dean and Rector signatures" {
+		t.Errorf("English status label = %s", asset.StatusLabel)
+	}
+	response = stub.Invoke(admin, localized, "certificate:ReadAsset", `{"id":"CERT20221122103010"}`)
+	_ = json.Unmarshal(response.Payload, &asset)
+	if asset.StatusLabel != "Faltan las firmas del Secretario, el Decano y el Rector" {
+		t.Errorf("Spanish status label = %s", asset.StatusLabel)
+	}
+}
+
+func TestCreateAsset(t *testing.T) {
+	n := newTestNetwork(t)
+	request := newTestAsset("CERT20221122103010", 1)
+	request.Date = "8 de Noviembre del 2010"
+	request.Status = Valid // ignored, new certificates are never signed
+	request.SecretaryValidating = "forged"
+	request.Accredited = "María Fernández" // the name of the graduate is never stored in the certificate
+	n.createAsset(t, request)
+
+	asset := n.readAsset(t, request.ID)
+	if asset.Status != New || asset.SecretaryValidating != "" {
+		t.Errorf("status = %v, secretary = %q, want a new certificate", asset.Status, asset.SecretaryValidating)
+	}
+	if asset.Accredited != "" {
+		t.Errorf("accredited = %q, want it blank", asset.Accredited)
+	}
+	if asset.Date != "2010-11-08" {
+		t.Errorf("date = %s, want 2010-11-08", asset.Date)
+	}
+	if asset.Emitter != "Universidad de La Habana" || asset.Certification != "Licenciado en Derecho" {
+		t.Errorf("emitter = %q, certification = %q", asset.Emitter, asset.Certification)
+	}
+	if !strings.HasPrefix(asset.CreatedBy, "Org1MSP::x509::CN=User1@org1.example.com") || asset.CreatedAt != asset.UpdatedAt {
+		t.Errorf("audit = %+v", asset.Audit)
+	}
+}
+
+func TestCreateAssetValidation(t *testing.T) {
+	n := newTestNetwork(t)
+	n.createAsset(t, newTestAsset("CERT20221122103010", 1))
+
+	tests := []struct {
+		name   string
+		change func(a *Asset)
+		want   string
+	}{
+		{"existing id", func(a *Asset) { a.ID = "CERT20221122103010" }, fmt.Sprintf(lus.ErrorAlreadyExistInState, "CERT20221122103010")},
+		{"invalid id", func(a *Asset) { a.ID = "CERT1" }, "invalid id"},
+		{"future date", func(a *Asset) { a.Date = "2023-01-01" }, fmt.Sprintf(lus.ErrorFutureDate, "2023-01-01")},
+		{"invalid date", func(a *Asset) { a.Date = "yesterday" }, fmt.Sprintf(lus.ErrorInvalidDate, "yesterday")},
+		{"unknown institution", func(a *Asset) { a.EmitterID = "INST20000101000000" }, fmt.Sprintf(lus.ErrorNotExistInState, "INST20000101000000")},
+		{"program of another faculty", func(a *Asset) { a.ProgramID = testChemProgramID }, fmt.Sprintf(lus.ErrorProgramFaculty, testChemProgramID, testLawID)},
+		{"program not offered yet", func(a *Asset) {
+			a.ProgramID, a.FacultyID = testChemProgramID, testChemistryID
+		}, fmt.Sprintf(lus.ErrorProgramYear, testChemProgramID, 2010)},
+		{"unknown holder", func(a *Asset) { a.HolderID = "GRAD20000101000000" }, fmt.Sprintf(lus.ErrorNotExistInState, "GRAD20000101000000")},
+		{"folio taken", func(a *Asset) {}, fmt.Sprintf(lus.ErrorFolioTaken, "1,1", FacultyBook, testLawID, "CERT20221122103010")},
+		{"invalid folio", func(a *Asset) { a.FacultyVolumeFolio.Folio = -1 }, fmt.Sprintf(lus.ErrorInvalidVolumeFolio, "1,-1")},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			request := newTestAsset("CERT20221122103011", 1)
+			test.change(request)
+			err := n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+				return n.contract.CreateAsset(ctx, request)
+			})
+			expectError(t, err, test.want)
+		})
+	}
+}
+
+func TestValidationChain(t *testing.T) {
+	n := newTestNetwork(t)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+
+	// signatures must follow the order secretary, dean, rector
+	expectError(t, n.sign(n.dean, id, testDeanID, Dean), lus.ErrorInconsistentValidation)
+	// the client identity must be the one of the signatory
+	expectError(t, n.sign(n.dean, id, testSecretaryID, Secretary), lus.ErrorSignerIdentity, testSecretaryID)
+	// the signatory must hold the role
+	expectError(t, n.sign(n.dean, id, testDeanID, Secretary), lus.ErrorSignatoryRole, testDeanID, Secretary)
+
+	steps := []struct {
+		identity    *mockstub.Identity
+		signatoryID string
+		role        ValidatorType
+		status      StateValidation
+	}{
+		{n.secretary, testSecretaryID, Secretary, SignedS},
+		{n.dean, testDeanID, Dean, SignedSD},
+		{n.rector, testRectorID, Rector, Valid},
+	}
+	for _, step := range steps {
+		err := n.sign(step.identity, id, step.signatoryID, step.role)
+		if err != nil {
+			t.Fatalf("%v: %v", step.role, err)
+		}
+		if asset := n.readAsset(t, id); asset.Status != step.status {
+			t.Fatalf("after the %v signature status = %v, want %v", step.role, asset.Status, step.status)
+		}
+	}
+
+	asset := n.readAsset(t, id)
+	if asset.SecretaryValidating != "Ana Pérez" || asset.DeanID != testDeanID || asset.RectorValidating != "Miriam Nicado" {
+		t.Errorf("signatures = %+v", asset)
+	}
+	expectError(t, n.sign(n.rector, id, testRectorID, Rector), lus.ErrorInconsistentValidation)
+}
+
+func TestValidateAssetOutOfTerm(t *testing.T) {
+	n := newTestNetwork(t)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+
+	n.stub.SetTime(time.Date(2019, 12, 31, 12, 0, 0, 0, time.UTC))
+	expectError(t, n.sign(n.secretary, id, testSecretaryID, Secretary), lus.ErrorSignatoryTerm, testSecretaryID, "2019-12-31")
+}
+
+func TestUpdateAsset(t *testing.T) {
+	n := newTestNetwork(t)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+	update := func(request *Asset) error {
+		return n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.UpdateAsset(ctx, request)
+		})
+	}
+
+	request := newTestAsset(id, 2)
+	request.HolderID = testOtherHolderID
+	request.Accredited = "María Fernández"
+	err := update(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset := n.readAsset(t, id)
+	if asset.Accredited != "" {
+		t.Errorf("accredited = %q, want it blank", asset.Accredited)
+	}
+	if asset.UpdatedAt == asset.CreatedAt {
+		t.Errorf("audit not stamped: %+v", asset.Audit)
+	}
+
+	// the indexes follow the update
+	err = n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := n.contract.ReadAssetByRegistryEntry(ctx, RegistryEntryRequest{Book: FacultyBook, OwnerID: testLawID, Volume: 1, Folio: 1})
+		expectError(t, err, lus.ErrorNotExistInState, "1,1")
+
+		found, err := n.contract.ReadAssetByRegistryEntry(ctx, RegistryEntryRequest{Book: FacultyBook, OwnerID: testLawID, Volume: 1, Folio: 2})
+		if err != nil {
+			return err
+		} else if found.ID != id {
+			t.Errorf("registry entry 1,2 = %s, want %s", found.ID, id)
+		}
+
+		assets, err := n.contract.QueryAssetsByHolder(ctx, GetRequest{ID: testHolderID})
+		if err != nil {
+			return err
+		} else if len(assets) != 0 {
+			t.Errorf("the previous holder still has %d certificates", len(assets))
+		}
+		assets, err = n.contract.QueryAssetsByHolder(ctx, GetRequest{ID: testOtherHolderID})
+		if err != nil {
+			return err
+		} else if len(assets) != 1 {
+			t.Errorf("the new holder has %d certificates, want 1", len(assets))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// once signed, the registry entries can still be fixed but not what the signatories signed
+	if err := n.sign(n.secretary, id, testSecretaryID, Secretary); err != nil {
+		t.Fatal(err)
+	}
+	request = newTestAsset(id, 3)
+	request.HolderID = testOtherHolderID
+	request.Status = Valid
+	request.RectorValidating = "forged"
+	if err := update(request); err != nil {
+		t.Fatal(err)
+	}
+	asset = n.readAsset(t, id)
+	if asset.Status != SignedS || asset.RectorValidating != "" || asset.SecretaryID != testSecretaryID {
+		t.Errorf("the update changed the signatures: %+v", asset)
+	}
+	if asset.FacultyVolumeFolio.Folio != 3 {
+		t.Errorf("faculty entry = %v, want folio 3", asset.FacultyVolumeFolio)
+	}
+
+	locked := []struct {
+		field  string
+		change func(*Asset)
+	}{
+		{"emitter_id", func(a *Asset) { a.EmitterID = "INST20221122103099" }},
+		{"faculty_id", func(a *Asset) { a.FacultyID = "FACU20221122103099" }},
+		{"program_id", func(a *Asset) { a.ProgramID = "PROG20221122103099" }},
+		{"date", func(a *Asset) { a.Date = "2011-11-08" }},
+		{"holder_id", func(a *Asset) { a.HolderID = testHolderID }},
+	}
+	for _, tc := range locked {
+		request := newTestAsset(id, 3)
+		request.HolderID = testOtherHolderID
+		tc.change(request)
+		expectError(t, update(request), lus.ErrorSignedLocked, tc.field, id)
+	}
+}
+
+func TestInvalidateAsset(t *testing.T) {
+	n := newTestNetwork(t)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+
+	invalidate := func(description string) error {
+		return n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.InvalidateAsset(ctx, &InvalidateAsset{ID: id, Description: description})
+		})
+	}
+	expectError(t, invalidate(""), lus.ErrorInconsistentInvalidation)
+	if err := invalidate("duplicated record"); err != nil {
+		t.Fatal(err)
+	}
+
+	asset := n.readAsset(t, id)
+	if asset.Status != Invalid || asset.InvalidReason != "duplicated record" {
+		t.Errorf("status = %v, reason = %q", asset.Status, asset.InvalidReason)
+	}
+	expectError(t, n.sign(n.secretary, id, testSecretaryID, Secretary), lus.ErrorInconsistentValidation)
+}
+
+func TestReissueAsset(t *testing.T) {
+	n := newTestNetwork(t)
+	first, duplicate, reissue := "CERT20221122103010", "CERT20221122103011", "CERT20221122103012"
+	n.createAsset(t, newTestAsset(first, 1))
+
+	create := func(request *Asset) error {
+		return n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.CreateAsset(ctx, request)
+		})
+	}
+	expectError(t, create(newTestAsset(duplicate, 2)), lus.ErrorDuplicateCertificate, testHolderID, first, testLawProgramID)
+
+	// a reissue must replace a certificate of the same holder and program
+	request := newTestAsset(reissue, 2)
+	request.HolderID, request.ReissueOf = testOtherHolderID, first
+	expectError(t, create(request), lus.ErrorInvalidReissue, first, reissue)
+	request = newTestAsset(reissue, 2)
+	request.ReissueOf = reissue
+	expectError(t, create(request), lus.ErrorNotExistInState, reissue)
+
+	request.ReissueOf = first
+	if err := create(request); err != nil {
+		t.Fatal(err)
+	}
+	if asset := n.readAsset(t, first); asset.Status != Invalid || asset.InvalidReason != "reissued as "+reissue {
+		t.Errorf("reissued status = %v, reason = %q", asset.Status, asset.InvalidReason)
+	}
+	asset := n.readAsset(t, reissue)
+	if asset.ReissueOf != first || len(asset.Flags) != 0 {
+		t.Errorf("reissue of = %s, flags = %v", asset.ReissueOf, asset.Flags)
+	}
+	expectError(t, create(newTestAsset(duplicate, 3)), lus.ErrorDuplicateCertificate, testHolderID, reissue, testLawProgramID)
+
+	// the updates keep the reissued certificate
+	err := n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateAsset(ctx, newTestAsset(reissue, 4))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset = n.readAsset(t, reissue); asset.ReissueOf != first {
+		t.Errorf("reissue of after the update = %q", asset.ReissueOf)
+	}
+	request = newTestAsset(duplicate, 3)
+	request.ReissueOf = first
+	expectError(t, create(request), lus.ErrorInvalidReissue, first, duplicate)
+
+	// an invalid certificate frees the program for a new one
+	err = n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.InvalidateAsset(ctx, &InvalidateAsset{ID: reissue, Description: "wrong graduate"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = create(newTestAsset(duplicate, 3)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDeleteAsset(t *testing.T) {
+	n := newTestNetwork(t)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+
+	err := n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.DeleteAsset(ctx, GetRequest{ID: id})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := n.contract.ReadAsset(ctx, GetRequest{ID: id})
+		expectError(t, err, lus.ErrorNotExistInState, id)
+
+		assets, err := n.contract.QueryAssetsByHolder(ctx, GetRequest{ID: testHolderID})
+		if err != nil {
+			return err
+		} else if len(assets) != 0 {
+			t.Errorf("the holder index still lists %d certificates", len(assets))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deletedKey, _ := n.stub.CreateCompositeKey(lus.DocTypeDeleted, []string{lus.CodCert, "2022", "11", "22", "103010"})
+	if n.stub.State(deletedKey) == nil {
+		t.Error("the deletion marker was not written")
+	}
+	// the folio can be reused once the certificate is deleted
+	n.createAsset(t, newTestAsset("CERT20221122103011", 1))
+}
+
+func TestQueryAssetsByDateRange(t *testing.T) {
+	n := newTestNetwork(t)
+	for i, date := range []string{"2015-07-20", "2010-11-08", "2019-01-10"} {
+		request := newTestAsset(fmt.Sprintf("CERT2022112210301%d", i), i+1)
+		request.HolderID, request.Date = testClassIDs[i], date
+		n.createAsset(t, request)
+	}
+
+	query := func(from, to string) ([]*Asset, error) {
+		var assets []*Asset
+		err := n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) (err error) {
+			assets, err = n.contract.QueryAssetsByDateRange(ctx, DateRangeRequest{From: from, To: to})
+			return err
+		})
+		return assets, err
+	}
+
+	tests := []struct {
+		from, to string
+		want     string
+	}{
+		{"", "", "2010-11-08,2015-07-20,2019-01-10"},
+		{"2011-01-01", "", "2015-07-20,2019-01-10"},
+		{"", "2015-07-20", "2010-11-08,2015-07-20"},
+		{"1 de Enero del 2015", "2018-12-31", "2015-07-20"},
+	}
+	for _, test := range tests {
+		assets, err := query(test.from, test.to)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dates := make([]string, 0, len(assets))
+		for _, asset := range assets {
+			dates = append(dates, asset.Date)
+		}
+		if got := strings.Join(dates, ","); got != test.want {
+			t.Errorf("%s..%s = %s, want %s", test.from, test.to, got, test.want)
+		}
+	}
+
+	_, err := query("2019-01-01", "2010-01-01")
+	expectError(t, err, lus.ErrorInvalidDateRange, "2019-01-01", "2010-01-01")
+}
+
+func TestReadAssetByRegistryEntry(t *testing.T) {
+	n := newTestNetwork(t)
+	request := newTestAsset("CERT20221122103010", 1)
+	request.UniversityVolumeFolio = VolumeFolio{Volume: 12, Folio: 48}
+	n.createAsset(t, request)
+
+	err := n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		asset, err := n.contract.ReadAssetByRegistryEntry(ctx, RegistryEntryRequest{Book: UniversityBook, OwnerID: testInstitutionID, Volume: 12, Folio: 48})
+		if err != nil {
+			return err
+		} else if asset.ID != request.ID {
+			t.Errorf("university entry 12,48 = %s, want %s", asset.ID, request.ID)
+		}
+
+		_, err = n.contract.ReadAssetByRegistryEntry(ctx, RegistryEntryRequest{Book: "ministry", OwnerID: testInstitutionID, Volume: 12, Folio: 48})
+		expectError(t, err, lus.ErrorInvalidRegistryBook, "ministry")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,147 @@
+package certificate
+
+import (
+	"sort"
+
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/statebased"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ReadAssetEndorsement returns the organizations whose peers must endorse the changes of a certificate.
+// An empty list means the chaincode endorsement policy applies.
+func (s *ContractCertificate) ReadAssetEndorsement(ctx contractapi.TransactionContextInterface, request GetRequest) (*AssetEndorsement, error) {
+	key, _, assetJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodCert, request.ID)
+	if err != nil {
+		return nil, err
+	} else if assetJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, request.ID)
+	}
+
+	orgs, err := getEndorsers(ctx.GetStub(), key, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AssetEndorsement{ID: request.ID, Orgs: orgs}, nil
+}
+
+// UpdateAssetEndorsement replaces the organizations whose peers must endorse the changes of a certificate,
+// an empty list removes the key-level policy. Only administrators can change the policy.
+func (s *ContractCertificate) UpdateAssetEndorsement(ctx contractapi.TransactionContextInterface, request AssetEndorsement) error {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	key, _, assetJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodCert, request.ID)
+	if err != nil {
+		return err
+	} else if assetJSON == nil {
+		return lus.Errorf(lus.ErrorNotExistInState, request.ID)
+	}
+
+	return setEndorsers(ctx.GetStub(), key, request.ID, request.Orgs)
+}
+
+// getEndorsers returns the organizations of the key-level endorsement policy of key, sorted
+func getEndorsers(stub shim.ChaincodeStubInterface, key, id string) ([]string, error) {
+	policy, err := stub.GetStateValidationParameter(key)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	}
+	if len(policy) == 0 {
+		return []string{}, nil
+	}
+	ep, err := statebased.NewStateEP(policy)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorEndorsementPolicy, id, err)
+	}
+	orgs := ep.ListOrgs()
+	sort.Strings(orgs)
+
+	return orgs, nil
+}
+
+// setEndorsers sets a key-level endorsement policy on key requiring a peer of every organization
+// in orgs, or removes it if orgs is empty
+func setEndorsers(stub shim.ChaincodeStubInterface, key, id string, orgs []string) error {
+	policy, err := endorsementPolicy(id, orgs)
+	if err != nil {
+		return err
+	}
+
+	return stub.SetStateValidationParameter(key, policy)
+}
+
+// endorsementPolicy returns the key-level endorsement policy of id requiring a peer of every
+// organization in orgs, nil if orgs is empty
+func endorsementPolicy(id string, orgs []string) ([]byte, error) {
+	if len(orgs) == 0 {
+		return nil, nil
+	}
+	for _, org := range orgs {
+		if org == "" {
+			return nil, lus.Errorf(lus.ErrorEndorsementPolicy, id, "empty MSP id")
+		}
+	}
+
+	ep, err := statebased.NewStateEP(nil)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorEndorsementPolicy, id, err)
+	}
+	err = ep.AddOrgs(statebased.RoleTypePeer, orgs...)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorEndorsementPolicy, id, err)
+	}
+	policy, err := ep.Policy()
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorEndorsementPolicy, id, err)
+	}
+
+	return policy, nil
+}
+
+// issuersEndorsement returns the key of a certificate and its key-level endorsement policy with the
+// organizations of the issuers added to the ones already required, a nil policy if none is missing
+func issuersEndorsement(stub shim.ChaincodeStubInterface, asset *Asset) (string, []byte, error) {
+	issuers, err := getIssuers(stub, asset)
+	if err != nil {
+		return "", nil, err
+	}
+	issuersOrgs, err := issuerOrgs(issuers)
+	if err != nil {
+		return "", nil, err
+	}
+	key, _, err := lus.CompositeKeyFromID(stub, lus.CodCert, asset.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	orgs, err := getEndorsers(stub, key, asset.ID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	required := make(map[string]bool)
+	for _, org := range orgs {
+		required[org] = true
+	}
+	missing := false
+	for _, org := range issuersOrgs {
+		if !required[org] {
+			orgs = append(orgs, org)
+			missing = true
+		}
+	}
+	if !missing {
+		return key, nil, nil
+	}
+
+	policy, err := endorsementPolicy(asset.ID, orgs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, policy, nil
+}
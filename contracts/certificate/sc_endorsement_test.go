@@ -0,0 +1,209 @@
+package certificate
+
+import (
+	"fmt"
+	"testing"
+
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/program"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (n *testNetwork) readEndorsers(t *testing.T, id string) string {
+	t.Helper()
+	var endorsement *AssetEndorsement
+	err := n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) (err error) {
+		endorsement, err = n.contract.ReadAssetEndorsement(ctx, GetRequest{ID: id})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprint(endorsement.Orgs)
+}
+
+func TestAssetEndorsement(t *testing.T) {
+	n := newTestNetwork(t)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+
+	key, _, _ := lus.CompositeKeyFromID(n.stub, lus.CodCert, id)
+	if len(n.stub.ValidationParameter(key)) == 0 {
+		t.Fatal("no key-level policy was set on the new certificate")
+	}
+	if got := n.readEndorsers(t, id); got != "[Org1MSP]" {
+		t.Errorf("endorsers = %s, want [Org1MSP]", got)
+	}
+
+	update := func(orgs ...string) error {
+		return n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.UpdateAssetEndorsement(ctx, AssetEndorsement{ID: id, Orgs: orgs})
+		})
+	}
+	err := n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateAssetEndorsement(ctx, AssetEndorsement{ID: id, Orgs: []string{"Org2MSP"}})
+	})
+	expectError(t, err, lus.ErrorNotAdmin)
+	expectError(t, update("Org1MSP", ""), lus.ErrorEndorsementPolicy, id, "empty MSP id")
+
+	if err = update("Org2MSP", "Org1MSP"); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.readEndorsers(t, id); got != "[Org1MSP Org2MSP]" {
+		t.Errorf("endorsers = %s, want [Org1MSP Org2MSP]", got)
+	}
+
+	// without a key-level policy the chaincode policy applies, until the certificate is valid
+	if err = update(); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.readEndorsers(t, id); got != "[]" {
+		t.Errorf("endorsers = %s, want []", got)
+	}
+	if err = n.sign(n.secretary, id, testSecretaryID, Secretary); err != nil {
+		t.Fatal(err)
+	}
+	if err = n.sign(n.dean, id, testDeanID, Dean); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.readEndorsers(t, id); got != "[]" {
+		t.Errorf("endorsers before the rector signature = %s, want []", got)
+	}
+	if err = n.sign(n.rector, id, testRectorID, Rector); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.readEndorsers(t, id); got != "[Org1MSP]" {
+		t.Errorf("endorsers of the valid certificate = %s, want [Org1MSP]", got)
+	}
+}
+
+func TestAssetEndorsementWithoutMSP(t *testing.T) {
+	n := newTestNetwork(t)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateAssetEndorsement(ctx, AssetEndorsement{ID: id})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = n.sign(n.secretary, id, testSecretaryID, Secretary); err != nil {
+		t.Fatal(err)
+	}
+	if err = n.sign(n.dean, id, testDeanID, Dean); err != nil {
+		t.Fatal(err)
+	}
+
+	// an institution registered before the MSP id was required
+	contract := institution.ContractInstitution{}
+	updateInstitution := func(mspID string) error {
+		return n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+			inst, err := institution.GetInstitution(ctx.GetStub(), testInstitutionID)
+			if err != nil {
+				return err
+			}
+			inst.MSPID = mspID
+			return contract.UpdateInstitution(ctx, inst)
+		})
+	}
+	expectError(t, updateInstitution(""), lus.ErrorMissingMSP, testInstitutionID)
+	err = n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		inst, err := institution.GetInstitution(ctx.GetStub(), testInstitutionID)
+		if err != nil {
+			return err
+		}
+		inst.MSPID = ""
+		return institution.PutInstitution(ctx.GetStub(), inst)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateAsset(ctx, newClassAsset(1))
+	})
+	expectError(t, err, lus.ErrorMissingMSP, testInstitutionID)
+
+	// the signature completing the certificate is not kept when its policy can not be set
+	var results []BatchItemResult
+	err = n.submit(n.rector, func(ctx contractapi.TransactionContextInterface) (err error) {
+		request := BatchValidateRequest{IDs: []string{id}, SignatoryID: testRectorID, ValidatorT: Rector, BestEffort: true}
+		results, err = n.contract.ValidateAssetsBatch(ctx, request)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Success || results[0].Code != lus.CodeMissingMSP {
+		t.Errorf("results = %+v", results)
+	}
+	if asset := n.readAsset(t, id); asset.Status != SignedSD || asset.RectorID != "" {
+		t.Errorf("status = %v, rector = %q, want the certificate unsigned by the rector", asset.Status, asset.RectorID)
+	}
+
+	if err = updateInstitution("Org1MSP"); err != nil {
+		t.Fatal(err)
+	}
+	if err = n.sign(n.rector, id, testRectorID, Rector); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.readEndorsers(t, id); got != "[Org1MSP]" {
+		t.Errorf("endorsers of the valid certificate = %s, want [Org1MSP]", got)
+	}
+}
+
+func TestAssetEndorsementFollowsEmitter(t *testing.T) {
+	n := newTestNetwork(t)
+	n.addPartner(t)
+	id, transcriptID := "CERT20221122103010", "TRSC20221122103011"
+	n.createAsset(t, newTestAsset(id, 1))
+	if err := n.createTranscript(n.member, transcriptID, id, testCourses()); err != nil {
+		t.Fatal(err)
+	}
+
+	asset := n.readAsset(t, id)
+	asset.EmitterID = testPartnerID
+	asset.FacultyID = testPartnerFacultyID
+	asset.ProgramID = "PROG20221122103025"
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return program.PutProgram(ctx.GetStub(), &program.Program{DocType: lus.CodProgram, ID: asset.ProgramID, Code: "DER", Name: "Licenciado en Derecho", Level: program.Bachelor, FacultyID: testPartnerFacultyID, FirstYear: 1990})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateAsset(ctx, asset)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := n.readEndorsers(t, id); got != "[Org2MSP]" {
+		t.Errorf("endorsers of the moved certificate = %s, want [Org2MSP]", got)
+	}
+
+	// the transcript and its index entry follow the certificate
+	err = n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		key, _, err := lus.CompositeKeyFromID(ctx.GetStub(), lus.CodTranscript, transcriptID)
+		if err != nil {
+			return err
+		}
+		indexKey, err := transcriptIndexKey(ctx.GetStub(), id)
+		if err != nil {
+			return err
+		}
+		for _, key := range []string{key, indexKey} {
+			orgs, err := getEndorsers(ctx.GetStub(), key, transcriptID)
+			if err != nil {
+				return err
+			} else if fmt.Sprint(orgs) != "[Org2MSP]" {
+				t.Errorf("endorsers of %q = %v, want [Org2MSP]", key, orgs)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,187 @@
+package certificate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ReviewFlags reviews a certificate flagged on issuance. An approved certificate can be signed, a rejected
+// one is invalidated with the reason of the rejection. Only administrators other than the issuer can review it.
+func (s *ContractCertificate) ReviewFlags(ctx contractapi.TransactionContextInterface, request FlagReviewRequest) error {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	asset, err := getAsset(ctx, request.ID)
+	if err != nil {
+		return err
+	} else if !asset.waitsForReview() {
+		return lus.Errorf(lus.ErrorNotFlagged, asset.ID)
+	}
+	clientID, err := lus.GetClientID(ctx)
+	if err != nil {
+		return err
+	} else if clientID == asset.CreatedBy {
+		return lus.Errorf(lus.ErrorSelfReview, asset.ID)
+	}
+	reason := strings.TrimSpace(request.Reason)
+	if !request.Approved && reason == "" {
+		return lus.Errorf(lus.ErrorReviewReason)
+	}
+	txTime, err := lus.GetTxTime(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+
+	asset.FlagReview = &FlagReview{
+		Approved:   request.Approved,
+		Reason:     reason,
+		ReviewedBy: clientID,
+		Date:       txTime.Format(lus.DateLayout),
+	}
+	if !request.Approved {
+		asset.Status = Invalid
+		asset.InvalidReason = reason
+	}
+
+	return s.updateAsset(ctx, asset)
+}
+
+// QueryFlaggedAssets returns the certificates of the institution with given id waiting for the review of their flags.
+func (s *ContractCertificate) QueryFlaggedAssets(ctx contractapi.TransactionContextInterface, request GetRequest) ([]*Asset, error) {
+	ids, err := idsFromIndex(ctx.GetStub(), lus.CodFlagged, []string{request.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	assets := make([]*Asset, 0, len(ids))
+	for _, id := range ids {
+		asset, err := s.ReadAsset(ctx, GetRequest{ID: id})
+		if err != nil {
+			return nil, err
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// waitsForReview reports if the asset has flags not reviewed yet, which block its signatures
+func (a *Asset) waitsForReview() bool {
+	return len(a.Flags) > 0 && a.FlagReview == nil && a.Status != Invalid
+}
+
+// addFlag adds flag to the asset, once
+func (a *Asset) addFlag(flag FraudFlag) {
+	for _, added := range a.Flags {
+		if added == flag {
+			return
+		}
+	}
+	a.Flags = append(a.Flags, flag)
+}
+
+// flagAsset sets the fraud flags of a new asset: another certificate of the program, not invalid nor reissued
+// by asset, for the same holder, or an issuance out of the office hours of the settings. The certificates
+// issued since the holder and program index are rejected instead, see checkIndexes.
+func flagAsset(stub shim.ChaincodeStubInterface, asset *Asset, settings *Settings) error {
+	ids, err := idsFromIndex(stub, lus.CodHolder, []string{asset.HolderID})
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		_, _, otherJSON, err := lus.ExistsAssetFromId(stub, lus.CodCert, id)
+		if err != nil {
+			return err
+		} else if otherJSON == nil || id == asset.ID {
+			continue
+		}
+		other, err := unmarshalAsset(otherJSON)
+		if err != nil {
+			return err
+		}
+		if other.ProgramID == asset.ProgramID && other.Status != Invalid && other.ID != asset.ReissueOf {
+			asset.addFlag(FlagDuplicate)
+			break
+		}
+	}
+
+	txTime, err := lus.GetTxTime(stub)
+	if err != nil {
+		return err
+	}
+	if outOfHours(txTime, settings) {
+		asset.addFlag(FlagOutOfHours)
+	}
+
+	return nil
+}
+
+// outOfHours reports if t is out of the office hours of the settings, always false if they are not set
+func outOfHours(t time.Time, settings *Settings) bool {
+	if settings.OfficeHoursStart == 0 && settings.OfficeHoursEnd == 0 {
+		return false
+	}
+	local := t.Add(time.Duration(settings.UTCOffset) * time.Hour)
+	if local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+		return true
+	}
+	return local.Hour() < settings.OfficeHoursStart || local.Hour() >= settings.OfficeHoursEnd
+}
+
+// countIssuance adds count certificates to the ones issued by the client identity on the day of the
+// transaction, or returns an error if they exceed the daily limit of the settings. Concurrent issuances
+// of the same identity conflict on the counter, so they are serialized.
+func countIssuance(ctx contractapi.TransactionContextInterface, count int, settings *Settings) error {
+	stub := ctx.GetStub()
+	clientID, err := lus.GetClientID(ctx)
+	if err != nil {
+		return err
+	}
+	txTime, err := lus.GetTxTime(stub)
+	if err != nil {
+		return err
+	}
+	date := txTime.Format(lus.DateLayout)
+	// the client id is hashed to keep the key short
+	hash := sha256.Sum256([]byte(clientID))
+	key, err := stub.CreateCompositeKey(lus.CodIssuance, []string{hex.EncodeToString(hash[:]), date})
+	if err != nil {
+		return lus.Errorf(lus.ErrorCompositeKey, err)
+	}
+
+	counter := IssuanceCounter{DocType: lus.CodIssuance, ClientID: clientID, Date: date}
+	counterJSON, err := stub.GetState(key)
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	} else if counterJSON != nil {
+		err = json.Unmarshal(counterJSON, &counter)
+		if err != nil {
+			return lus.Errorf(lus.ErrorUnmarshal, err)
+		}
+	}
+
+	limit := settings.MaxDailyIssuance
+	if limit == 0 {
+		limit = DefaultMaxDailyIssuance
+	}
+	if counter.Count+count > limit {
+		return lus.Errorf(lus.ErrorIssuanceLimit, limit, date)
+	}
+	counter.Count += count
+	counter.SchemaVersion = SchemaVersion
+	counterJSON, err = json.Marshal(counter)
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(key, counterJSON)
+}
@@ -0,0 +1,192 @@
+package certificate
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (n *testNetwork) updateSettings(t *testing.T, settings *SettingsUpdate) {
+	t.Helper()
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateSettings(ctx, settings)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (n *testNetwork) reviewFlags(identity *mockstub.Identity, id string, approved bool, reason string) error {
+	return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.ReviewFlags(ctx, FlagReviewRequest{ID: id, Approved: approved, Reason: reason})
+	})
+}
+
+func (n *testNetwork) queryFlagged(t *testing.T) []string {
+	t.Helper()
+	var ids []string
+	err := n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		assets, err := n.contract.QueryFlaggedAssets(ctx, GetRequest{ID: testInstitutionID})
+		for _, asset := range assets {
+			ids = append(ids, asset.ID)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// newClassAsset returns a certificate of the law program for the graduate i of the class
+func newClassAsset(i int) *Asset {
+	request := newTestAsset(fmt.Sprintf("CERT2022112210301%d", i), i+1)
+	request.HolderID = testClassIDs[i]
+	return request
+}
+
+func TestIssuanceLimit(t *testing.T) {
+	n := newTestNetwork(t)
+	n.updateSettings(t, &SettingsUpdate{Fields: []string{"max_daily_issuance"}, MaxDailyIssuance: 2})
+	create := func(identity *mockstub.Identity, assets ...*Asset) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := n.contract.CreateAssetsBatch(ctx, BatchCreateRequest{Assets: assets})
+			return err
+		})
+	}
+
+	n.createAsset(t, newClassAsset(0))
+	expectError(t, create(n.member, newClassAsset(1), newClassAsset(2)), lus.ErrorIssuanceLimit, 2, "2022-11-22")
+	if err := create(n.member, newClassAsset(1)); err != nil {
+		t.Fatal(err)
+	}
+	err := n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateAsset(ctx, newClassAsset(2))
+	})
+	expectError(t, err, lus.ErrorIssuanceLimit, 2, "2022-11-22")
+
+	// the limit is per identity and per day
+	if err = create(n.admin, newClassAsset(2)); err != nil {
+		t.Fatal(err)
+	}
+	n.stub.SetTime(time.Date(2022, 11, 23, 10, 30, 0, 0, time.UTC))
+	n.createAsset(t, newClassAsset(3))
+
+	err = n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateSettings(ctx, &SettingsUpdate{Fields: []string{"max_daily_issuance"}, MaxDailyIssuance: -1})
+	})
+	expectError(t, err, lus.ErrorInvalidSettings, "max_daily_issuance can not be negative")
+}
+
+func TestFlaggedCertificates(t *testing.T) {
+	n := newTestNetwork(t)
+	first, duplicate, other := "CERT20221122103010", "CERT20221122103011", "CERT20221122103012"
+	n.createAsset(t, newTestAsset(first, 1))
+	// the duplicates of certificates issued before the holder and program index are only flagged
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		key, err := holderProgramIndexKey(ctx.GetStub(), newTestAsset(first, 1))
+		if err != nil {
+			return err
+		}
+		return ctx.GetStub().DelState(key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.createAsset(t, newTestAsset(duplicate, 2))
+	request := newTestAsset(other, 3)
+	request.HolderID = testOtherHolderID
+	n.createAsset(t, request)
+
+	asset := n.readAsset(t, duplicate)
+	if len(asset.Flags) != 1 || asset.Flags[0] != FlagDuplicate || len(n.readAsset(t, first).Flags) != 0 {
+		t.Fatalf("flags = %v", asset.Flags)
+	}
+	if ids := n.queryFlagged(t); len(ids) != 1 || ids[0] != duplicate {
+		t.Errorf("flagged = %v", ids)
+	}
+
+	// the flags block the signatures and survive the updates
+	expectError(t, n.sign(n.secretary, duplicate, testSecretaryID, Secretary), lus.ErrorCertificateFlagged, duplicate)
+	err = n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateAsset(ctx, newTestAsset(duplicate, 2))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, n.sign(n.secretary, duplicate, testSecretaryID, Secretary), lus.ErrorCertificateFlagged, duplicate)
+
+	expectError(t, n.reviewFlags(n.member, duplicate, true, ""), lus.ErrorNotAdmin)
+	expectError(t, n.reviewFlags(n.admin, other, true, ""), lus.ErrorNotFlagged, other)
+	expectError(t, n.reviewFlags(n.admin, duplicate, false, " "), lus.ErrorReviewReason)
+	if err = n.reviewFlags(n.admin, duplicate, true, "reissued after a typo"); err != nil {
+		t.Fatal(err)
+	}
+	if ids := n.queryFlagged(t); len(ids) != 0 {
+		t.Errorf("flagged after the review = %v", ids)
+	}
+	asset = n.readAsset(t, duplicate)
+	if asset.FlagReview == nil || !asset.FlagReview.Approved || asset.FlagReview.Date != "2022-11-22" {
+		t.Errorf("review = %+v", asset.FlagReview)
+	}
+	if err = n.sign(n.secretary, duplicate, testSecretaryID, Secretary); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, n.reviewFlags(n.admin, duplicate, true, ""), lus.ErrorNotFlagged, duplicate)
+
+	// administrators can not review their own certificates, a rejection invalidates the certificate
+	rejected := "CERT20221122103013"
+	n.updateSettings(t, &SettingsUpdate{Fields: []string{"office_hours_start", "office_hours_end", "utc_offset"}, OfficeHoursStart: 8, OfficeHoursEnd: 17, UTCOffset: -5})
+	err = n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		request := newTestAsset(rejected, 4)
+		request.HolderID = testClassmateID
+		return n.contract.CreateAsset(ctx, request)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, n.reviewFlags(n.admin, rejected, false, "unknown graduate"), lus.ErrorSelfReview, rejected)
+	otherAdmin := mockstub.MustIdentity("Org1MSP", "Admin2@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+	if err = n.reviewFlags(otherAdmin, rejected, false, "unknown graduate"); err != nil {
+		t.Fatal(err)
+	}
+	if asset = n.readAsset(t, rejected); asset.Status != Invalid || asset.InvalidReason != "unknown graduate" {
+		t.Errorf("status = %v, reason = %s", asset.Status, asset.InvalidReason)
+	}
+
+}
+
+func TestOutOfHours(t *testing.T) {
+	n := newTestNetwork(t)
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateSettings(ctx, &SettingsUpdate{Fields: []string{"office_hours_start", "office_hours_end"}, OfficeHoursStart: 17, OfficeHoursEnd: 8})
+	})
+	expectError(t, err, lus.ErrorInvalidSettings, "the office hours must be between 0 and 24, starting before they end")
+	n.updateSettings(t, &SettingsUpdate{Fields: []string{"office_hours_start", "office_hours_end", "utc_offset"}, OfficeHoursStart: 8, OfficeHoursEnd: 17, UTCOffset: -5})
+
+	tests := []struct {
+		time    time.Time
+		flagged bool
+	}{
+		{time.Date(2022, 11, 22, 10, 30, 0, 0, time.UTC), true}, // Tuesday 5:30
+		{time.Date(2022, 11, 22, 15, 0, 0, 0, time.UTC), false}, // Tuesday 10:00
+		{time.Date(2022, 11, 22, 22, 0, 0, 0, time.UTC), true},  // Tuesday 17:00
+		{time.Date(2022, 11, 26, 15, 0, 0, 0, time.UTC), true},  // Saturday 10:00
+		{time.Date(2022, 11, 28, 4, 30, 0, 0, time.UTC), true},  // Sunday 23:30
+		{time.Date(2022, 11, 28, 13, 0, 0, 0, time.UTC), false}, // Monday 8:00
+	}
+	for i, test := range tests {
+		n.stub.SetTime(test.time)
+		request := newClassAsset(i)
+		n.createAsset(t, request)
+		asset := n.readAsset(t, request.ID)
+		if flagged := len(asset.Flags) > 0; flagged != test.flagged || flagged && asset.Flags[0] != FlagOutOfHours {
+			t.Errorf("%v: out of hours = %v, want %v", test.time, flagged, test.flagged)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package certificate
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"academic_certificates/contracts/graduate"
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/signatory"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateGrant lets the organization Audience read the private data in Scope of a valid certificate until
+// ExpiresAt, at most MaxGrantDays later. The holder grants it, identified by the lus.AttrGraduate attribute
+// of an identity of the institution that registered them, or a secretary of the faculty on their behalf.
+// The token given to the verifier is passed in the lus.TransientGrantToken transient field.
+func (s *ContractCertificate) CreateGrant(ctx contractapi.TransactionContextInterface, request GrantRequest) error {
+	stub := ctx.GetStub()
+	asset, err := getValidAsset(stub, request.CertificateID)
+	if err != nil {
+		return err
+	}
+	err = checkGrantor(ctx, asset, request.SignatoryID)
+	if err != nil {
+		return err
+	}
+
+	_, _, grantJSON, err := lus.ExistsAssetFromId(stub, lus.CodGrant, request.ID)
+	if err != nil {
+		return err
+	} else if grantJSON != nil {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, request.ID)
+	}
+
+	scope, err := checkGrantScope(request.Scope)
+	if err != nil {
+		return err
+	}
+	audience := strings.TrimSpace(request.Audience)
+	if audience == "" {
+		return lus.Errorf(lus.ErrorGrantAudience)
+	}
+	expiresAt, err := checkGrantExpiry(stub, request.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	token, err := getGrantToken(stub)
+	if err != nil {
+		return err
+	} else if len(token) < MinGrantTokenLength {
+		return lus.Errorf(lus.ErrorGrantToken, MinGrantTokenLength)
+	}
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return err
+	}
+
+	return putGrant(stub, &Grant{
+		DocType:       lus.CodGrant,
+		ID:            request.ID,
+		CertificateID: asset.ID,
+		HolderID:      asset.HolderID,
+		Audience:      audience,
+		Scope:         scope,
+		ExpiresAt:     expiresAt,
+		TokenHash:     hashGrantToken(token),
+		SignatoryID:   request.SignatoryID,
+		Audit:         audit,
+	})
+}
+
+// RevokeGrant revokes a grant before it expires. The holder or a secretary of the faculty can revoke it,
+// as in CreateGrant, even if the certificate is no longer valid.
+func (s *ContractCertificate) RevokeGrant(ctx contractapi.TransactionContextInterface, request RevokeGrantRequest) error {
+	stub := ctx.GetStub()
+	grant, err := getGrant(stub, request.ID)
+	if err != nil {
+		return err
+	}
+	asset, err := getAsset(ctx, grant.CertificateID)
+	if err != nil {
+		return err
+	}
+	err = checkGrantor(ctx, asset, request.SignatoryID)
+	if err != nil {
+		return err
+	}
+
+	grant.Revoked = true
+	err = grant.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	return putGrant(stub, grant)
+}
+
+// ReadSharedCertificate returns the certificate of a grant with the private data in its scope. The client
+// identity must belong to the audience of the grant and pass its token in the lus.TransientGrantToken
+// transient field, the grant must not be revoked or expired at the transaction timestamp. The holder scope
+// is only available on peers of the organizations in the graduates collection.
+func (s *ContractCertificate) ReadSharedCertificate(ctx contractapi.TransactionContextInterface, request GetRequest) (*SharedCertificate, error) {
+	stub := ctx.GetStub()
+	grant, err := getGrant(stub, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = checkGrantAccess(ctx, grant)
+	if err != nil {
+		return nil, err
+	}
+
+	asset, err := getValidAsset(stub, grant.CertificateID)
+	if err != nil {
+		return nil, err
+	}
+	shared := SharedCertificate{
+		Verification: *newVerification(asset, lus.GetLanguage(ctx)),
+		GrantID:      grant.ID,
+		ExpiresAt:    grant.ExpiresAt,
+	}
+	for _, scope := range grant.Scope {
+		switch scope {
+		case ScopeHolder:
+			shared.Holder, err = graduate.GetIdentity(stub, asset.HolderID)
+			if err != nil {
+				return nil, err
+			}
+		case ScopeSignatories:
+			shared.SecretaryValidating = asset.SecretaryValidating
+			shared.DeanValidating = asset.DeanValidating
+			shared.RectorValidating = asset.RectorValidating
+		case ScopeRegistry:
+			shared.FacultyVolumeFolio = &asset.FacultyVolumeFolio
+			shared.UniversityVolumeFolio = &asset.UniversityVolumeFolio
+		case ScopeTranscript:
+			shared.Transcript, err = getTranscriptByCertificate(stub, asset.ID)
+			// a certificate without transcript is shared without it
+			if err != nil && lus.ErrorCode(err) != lus.CodeNotFound {
+				return nil, err
+			} else if shared.Transcript != nil {
+				shared.Transcript.StatusLabel = shared.Transcript.Status.Localize(lus.GetLanguage(ctx))
+			}
+		}
+	}
+
+	return &shared, nil
+}
+
+// checkGrantor returns an error unless the client identity is the holder of asset or, if signatoryID
+// is given, the secretary signatoryID of the faculty of asset
+func checkGrantor(ctx contractapi.TransactionContextInterface, asset *Asset, signatoryID string) error {
+	if signatoryID != "" {
+		_, err := signatory.CheckSigner(ctx, signatoryID, Secretary, asset.EmitterID, asset.FacultyID)
+		return err
+	}
+	return checkHolder(ctx, asset)
+}
+
+// checkHolder returns an error unless the client identity is the holder of asset, with the lus.AttrGraduate
+// attribute of an identity of the institution that registered the graduate
+func checkHolder(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	holderID, found, err := ctx.GetClientIdentity().GetAttributeValue(lus.AttrGraduate)
+	if err != nil {
+		return lus.Errorf(lus.ErrorClientIdentity, err)
+	} else if !found || holderID != asset.HolderID {
+		return lus.Errorf(lus.ErrorNotHolder, asset.HolderID)
+	}
+	// only the institution that registered the graduate vouches for the attribute
+	holder, err := graduate.GetGraduate(ctx.GetStub(), asset.HolderID)
+	if err != nil {
+		return err
+	}
+	registrar, err := institution.GetInstitution(ctx.GetStub(), holder.InstitutionID)
+	if err != nil {
+		return err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return lus.Errorf(lus.ErrorClientIdentity, err)
+	} else if mspID != registrar.MSPID {
+		return lus.Errorf(lus.ErrorNotHolder, asset.HolderID)
+	}
+
+	return nil
+}
+
+// checkGrantAccess returns an error unless the client identity can read the certificate of grant
+func checkGrantAccess(ctx contractapi.TransactionContextInterface, grant *Grant) error {
+	token, err := getGrantToken(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashGrantToken(token)), []byte(grant.TokenHash)) != 1 {
+		return lus.Errorf(lus.ErrorGrantDenied, grant.ID, "invalid token")
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return lus.Errorf(lus.ErrorClientIdentity, err)
+	} else if mspID != grant.Audience {
+		return lus.Errorf(lus.ErrorGrantDenied, grant.ID, "granted to another organization")
+	}
+	if grant.Revoked {
+		return lus.Errorf(lus.ErrorGrantDenied, grant.ID, "revoked")
+	}
+	txTime, err := lus.GetTxTime(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, grant.ExpiresAt)
+	if err != nil {
+		return lus.Errorf(lus.ErrorInvalidDate, grant.ExpiresAt)
+	} else if !txTime.Before(expiresAt) {
+		return lus.Errorf(lus.ErrorGrantDenied, grant.ID, "expired")
+	}
+
+	return nil
+}
+
+// checkGrantScope returns the scopes without repetitions, or an error if there is none or any is unknown
+func checkGrantScope(scopes []GrantScope) ([]GrantScope, error) {
+	if len(scopes) == 0 {
+		return nil, lus.Errorf(lus.ErrorInvalidGrantScope, "")
+	}
+
+	checked := make([]GrantScope, 0, len(scopes))
+	seen := make(map[GrantScope]bool)
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeHolder, ScopeSignatories, ScopeRegistry, ScopeTranscript:
+		default:
+			return nil, lus.Errorf(lus.ErrorInvalidGrantScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			checked = append(checked, scope)
+		}
+	}
+
+	return checked, nil
+}
+
+// checkGrantExpiry returns the expiry in value, a RFC 3339 time, in UTC. It must be after the transaction
+// timestamp and at most MaxGrantDays later.
+func checkGrantExpiry(stub shim.ChaincodeStubInterface, value string) (string, error) {
+	expiresAt, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return "", lus.Errorf(lus.ErrorGrantExpiry, value, MaxGrantDays)
+	}
+	txTime, err := lus.GetTxTime(stub)
+	if err != nil {
+		return "", err
+	}
+	if !expiresAt.After(txTime) || expiresAt.After(txTime.AddDate(0, 0, MaxGrantDays)) {
+		return "", lus.Errorf(lus.ErrorGrantExpiry, value, MaxGrantDays)
+	}
+
+	return expiresAt.UTC().Format(time.RFC3339), nil
+}
+
+// getGrantToken returns the token passed in the transient map
+func getGrantToken(stub shim.ChaincodeStubInterface) (string, error) {
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return "", err
+	}
+	token, ok := transientMap[lus.TransientGrantToken]
+	if !ok {
+		return "", lus.Errorf(lus.ErrorTransientMissing, lus.TransientGrantToken)
+	}
+	return string(token), nil
+}
+
+// hashGrantToken returns the hex encoded SHA-256 hash of token
+func hashGrantToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// getGrant returns the grant stored in the world state with given id
+func getGrant(stub shim.ChaincodeStubInterface, id string) (*Grant, error) {
+	_, _, grantJSON, err := lus.ExistsAssetFromId(stub, lus.CodGrant, id)
+	if err != nil {
+		return nil, err
+	} else if grantJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+
+	var grant Grant
+	err = json.Unmarshal(grantJSON, &grant)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &grant, nil
+}
+
+// putGrant writes a grant to the world state
+func putGrant(stub shim.ChaincodeStubInterface, grant *Grant) error {
+	key, _, err := lus.CompositeKeyFromID(stub, lus.CodGrant, grant.ID)
+	if err != nil {
+		return err
+	}
+	grant.SchemaVersion = SchemaVersion
+	grantJSON, err := json.Marshal(grant)
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(key, grantJSON)
+}
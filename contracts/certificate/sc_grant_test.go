@@ -0,0 +1,243 @@
+package certificate
+
+import (
+	"testing"
+	"time"
+
+	"academic_certificates/contracts/graduate"
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const testGrantToken = "0123456789abcdef-employer"
+
+func (n *testNetwork) createGrant(identity *mockstub.Identity, token string, request GrantRequest) error {
+	n.stub.SetTransient(lus.TransientGrantToken, []byte(token))
+	return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateGrant(ctx, request)
+	})
+}
+
+func (n *testNetwork) readShared(identity *mockstub.Identity, id, token string) (*SharedCertificate, error) {
+	var shared *SharedCertificate
+	n.stub.SetTransient(lus.TransientGrantToken, []byte(token))
+	err := n.stub.Evaluate(identity, func(ctx contractapi.TransactionContextInterface) (err error) {
+		shared, err = n.contract.ReadSharedCertificate(ctx, GetRequest{ID: id})
+		return err
+	})
+	return shared, err
+}
+
+func TestGrants(t *testing.T) {
+	n := newTestNetwork(t)
+	holder := mockstub.MustIdentity("Org1MSP", "Graduate1@org1.example.com", map[string]string{lus.AttrGraduate: testHolderID})
+	impostor := mockstub.MustIdentity("Org2MSP", "Graduate1@org2.example.com", map[string]string{lus.AttrGraduate: testHolderID})
+	employer := mockstub.MustIdentity("EmployerMSP", "User1@employer.example.com", nil)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+
+	n.stub.SetTransient(graduate.TransientIdentityKey, []byte("0123456789abcdef0123456789abcdef"))
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return new(graduate.ContractGraduate).SetIdentityKey(ctx)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.stub.SetTransient(graduate.TransientIdentity, []byte(`{"full_name":"Juan Pérez","national_id":"85010112345"}`))
+	err = n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return new(graduate.ContractGraduate).UpdateGraduateIdentity(ctx, graduate.GetRequest{ID: testHolderID})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := GrantRequest{
+		ID:            "GRNT20221122103011",
+		CertificateID: id,
+		Audience:      "EmployerMSP",
+		Scope:         []GrantScope{ScopeHolder, ScopeRegistry, ScopeHolder},
+		ExpiresAt:     "2022-12-22T10:30:00Z",
+	}
+
+	// only valid certificates can be shared
+	expectError(t, n.createGrant(holder, testGrantToken, request), lus.ErrorCertificateNotValid, id)
+	for _, err := range []error{n.sign(n.secretary, id, testSecretaryID, Secretary), n.sign(n.dean, id, testDeanID, Dean), n.sign(n.rector, id, testRectorID, Rector)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectError(t, n.createGrant(n.member, testGrantToken, request), lus.ErrorNotHolder, testHolderID)
+	expectError(t, n.createGrant(impostor, testGrantToken, request), lus.ErrorNotHolder, testHolderID)
+	expectError(t, n.createGrant(holder, "short", request), lus.ErrorGrantToken, MinGrantTokenLength)
+	invalid := request
+	invalid.Scope = []GrantScope{"grades"}
+	expectError(t, n.createGrant(holder, testGrantToken, invalid), lus.ErrorInvalidGrantScope, "grades")
+	invalid = request
+	invalid.ExpiresAt = "2024-01-01T00:00:00Z"
+	expectError(t, n.createGrant(holder, testGrantToken, invalid), lus.ErrorGrantExpiry, invalid.ExpiresAt, MaxGrantDays)
+	invalid.ExpiresAt = "2022-11-22T10:00:00Z"
+	expectError(t, n.createGrant(holder, testGrantToken, invalid), lus.ErrorGrantExpiry, invalid.ExpiresAt, MaxGrantDays)
+
+	if err = n.createGrant(holder, testGrantToken, request); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, n.createGrant(holder, testGrantToken, request), lus.ErrorAlreadyExistInState, request.ID)
+
+	// the verifier needs the token and to belong to the audience
+	_, err = n.readShared(employer, request.ID, "0123456789abcdef-guessed")
+	expectError(t, err, lus.ErrorGrantDenied, request.ID, "invalid token")
+	_, err = n.readShared(n.member, request.ID, testGrantToken)
+	expectError(t, err, lus.ErrorGrantDenied, request.ID, "granted to another organization")
+	shared, err := n.readShared(employer, request.ID, testGrantToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shared.Holder == nil || shared.Holder.FullName != "Juan Pérez" || shared.FacultyVolumeFolio == nil || shared.FacultyVolumeFolio.Folio != 1 {
+		t.Errorf("shared = %+v", shared)
+	}
+	if !shared.Valid || shared.RectorValidating != "" || shared.Transcript != nil {
+		t.Errorf("shared outside the scope = %+v", shared)
+	}
+
+	// a secretary grants the signatories and the transcript on behalf of the holder
+	other := GrantRequest{ID: "GRNT20221122103012", CertificateID: id, Audience: "EmployerMSP", Scope: []GrantScope{ScopeSignatories, ScopeTranscript}, ExpiresAt: "2022-11-23T10:30:00Z", SignatoryID: testDeanID}
+	expectError(t, n.createGrant(n.dean, testGrantToken, other), lus.ErrorSignatoryRole, testDeanID, Secretary)
+	other.SignatoryID = testSecretaryID
+	if err = n.createGrant(n.secretary, testGrantToken, other); err != nil {
+		t.Fatal(err)
+	}
+	if shared, err = n.readShared(employer, other.ID, testGrantToken); err != nil {
+		t.Fatal(err)
+	}
+	if shared.Holder != nil || shared.RectorValidating == "" || shared.Transcript != nil {
+		t.Errorf("shared by the secretary = %+v", shared)
+	}
+
+	// expired at the transaction timestamp
+	n.stub.SetTime(time.Date(2022, 11, 23, 10, 30, 0, 0, time.UTC))
+	_, err = n.readShared(employer, other.ID, testGrantToken)
+	expectError(t, err, lus.ErrorGrantDenied, other.ID, "expired")
+
+	revoke := func(identity *mockstub.Identity, signatoryID string) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.RevokeGrant(ctx, RevokeGrantRequest{ID: request.ID, SignatoryID: signatoryID})
+		})
+	}
+	expectError(t, revoke(employer, ""), lus.ErrorNotHolder, testHolderID)
+	if err = revoke(holder, ""); err != nil {
+		t.Fatal(err)
+	}
+	_, err = n.readShared(employer, request.ID, testGrantToken)
+	expectError(t, err, lus.ErrorGrantDenied, request.ID, "revoked")
+}
+
+func TestPrivateDataReaders(t *testing.T) {
+	n := newTestNetwork(t)
+	holder := mockstub.MustIdentity("Org1MSP", "Graduate1@org1.example.com", map[string]string{lus.AttrGraduate: testHolderID})
+	classmate := mockstub.MustIdentity("Org1MSP", "Graduate2@org1.example.com", map[string]string{lus.AttrGraduate: testClassmateID})
+	employer := mockstub.MustIdentity("EmployerMSP", "User1@employer.example.com", nil)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+	for _, err := range []error{
+		n.sign(n.secretary, id, testSecretaryID, Secretary),
+		n.sign(n.dean, id, testDeanID, Dean),
+		n.sign(n.rector, id, testRectorID, Rector),
+		n.createTranscript(n.member, "TRSC20221122103011", id, testCourses()),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	read := func(identity *mockstub.Identity) []*Asset {
+		t.Helper()
+		var assets []*Asset
+		err := n.stub.Evaluate(identity, func(ctx contractapi.TransactionContextInterface) error {
+			asset, err := n.contract.ReadAsset(ctx, GetRequest{ID: id})
+			if err != nil {
+				return err
+			}
+			byHolder, err := n.contract.QueryAssetsByHolder(ctx, GetRequest{ID: testHolderID})
+			if err != nil {
+				return err
+			}
+			byDate, err := n.contract.QueryAssetsByDateRange(ctx, DateRangeRequest{})
+			if err != nil {
+				return err
+			}
+			assets = append(assets, asset)
+			assets = append(assets, byHolder...)
+			assets = append(assets, byDate...)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return assets
+	}
+	readEntry := func(identity *mockstub.Identity, folio int) error {
+		return n.stub.Evaluate(identity, func(ctx contractapi.TransactionContextInterface) error {
+			asset, err := n.contract.ReadAssetByRegistryEntry(ctx, RegistryEntryRequest{Book: FacultyBook, OwnerID: testLawID, Volume: 1, Folio: folio})
+			if err == nil && (asset.ID != id || asset.RectorID != testRectorID) {
+				t.Errorf("certificate of the registry entry = %+v", asset)
+			}
+			return err
+		})
+	}
+	readTranscript := func(identity *mockstub.Identity) error {
+		return n.stub.Evaluate(identity, func(ctx contractapi.TransactionContextInterface) error {
+			_, err := n.contract.ReadTranscript(ctx, GetRequest{ID: "TRSC20221122103011"})
+			if err != nil {
+				return err
+			}
+			_, err = n.contract.ReadTranscriptByCertificate(ctx, GetRequest{ID: id})
+			return err
+		})
+	}
+
+	// the holder and the issuers read the signatories, the registry book entries and the transcript
+	for _, identity := range []*mockstub.Identity{holder, n.member, n.admin} {
+		for _, asset := range read(identity) {
+			if asset.RectorValidating != "Miriam Nicado" || asset.RectorID != testRectorID || asset.FacultyVolumeFolio.Folio != 1 {
+				t.Errorf("certificate read by its holder or issuer = %+v", asset)
+			}
+		}
+		if err := readTranscript(identity); err != nil {
+			t.Error(err)
+		}
+		if err := readEntry(identity, 1); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// other graduates and organizations only read the public data
+	for _, identity := range []*mockstub.Identity{classmate, employer} {
+		for _, asset := range read(identity) {
+			if asset.SecretaryValidating != "" || asset.DeanID != "" || asset.RectorValidating != "" || asset.FacultyVolumeFolio != (VolumeFolio{}) ||
+				asset.GPA != 0 || asset.FailedCourses != 0 || asset.Status != Valid {
+				t.Errorf("certificate read by another organization = %+v", asset)
+			}
+		}
+		// a taken registry entry can not be told from a free one
+		expectError(t, readEntry(identity, 1), lus.ErrorNotExistInState, VolumeFolio{1, 1})
+		expectError(t, readEntry(identity, 2), lus.ErrorNotExistInState, VolumeFolio{1, 2})
+	}
+	expectError(t, readTranscript(classmate), lus.ErrorNotHolder, testHolderID)
+	expectError(t, readTranscript(employer), lus.ErrorForbiddenMSP, "EmployerMSP", testInstitutionID)
+
+	// a grant shares them
+	grant := GrantRequest{ID: "GRNT20221122103012", CertificateID: id, Audience: "EmployerMSP", Scope: []GrantScope{ScopeSignatories, ScopeTranscript}, ExpiresAt: "2022-12-22T10:30:00Z"}
+	if err := n.createGrant(holder, testGrantToken, grant); err != nil {
+		t.Fatal(err)
+	}
+	shared, err := n.readShared(employer, grant.ID, testGrantToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shared.RectorValidating != "Miriam Nicado" || shared.Transcript == nil || len(shared.Transcript.Courses) != 4 || shared.Transcript.StatusLabel == "" {
+		t.Errorf("shared = %+v", shared)
+	}
+}
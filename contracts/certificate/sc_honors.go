@@ -0,0 +1,68 @@
+package certificate
+
+import (
+	"strings"
+
+	"academic_certificates/contracts/program"
+	"academic_certificates/contracts/signatory"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// OverrideHonors replaces the honors of the rules of the program of a certificate, ex: for merits the rules
+// do not cover. The dean of the faculty approves it with its signatory identity, and the reason is recorded.
+// The honors can only change until the certificate is valid.
+func (s *ContractCertificate) OverrideHonors(ctx contractapi.TransactionContextInterface, request HonorsOverrideRequest) error {
+	asset, err := getAsset(ctx, request.ID)
+	if err != nil {
+		return err
+	} else if asset.Status == Invalid || asset.Status == Valid {
+		return lus.Errorf(lus.ErrorHonorsLocked, asset.ID)
+	}
+	if request.Honors > program.Gold {
+		return lus.Errorf(lus.ErrorInvalidHonorsLevel, uint(request.Honors))
+	}
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return lus.Errorf(lus.ErrorHonorsReason)
+	}
+	dean, err := signatory.CheckSigner(ctx, request.SignatoryID, Dean, asset.EmitterID, asset.FacultyID)
+	if err != nil {
+		return err
+	}
+	txTime, err := lus.GetTxTime(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+
+	computed := asset.Honors
+	if asset.HonorsOverride != nil {
+		computed = asset.HonorsOverride.Computed
+	}
+	asset.HonorsOverride = &HonorsOverride{
+		Computed: computed,
+		Reason:   reason,
+		DeanID:   dean.ID,
+		Dean:     dean.Name,
+		Date:     txTime.Format(lus.DateLayout),
+	}
+	asset.setHonors(request.Honors)
+
+	return s.updateAsset(ctx, asset)
+}
+
+// setHonors sets the honors of the asset, a gold certificate has gold honors
+func (a *Asset) setHonors(honors HonorsLevel) {
+	a.Honors = honors
+	a.GoldCertificate = honors == program.Gold
+}
+
+// evaluateHonors sets the honors of the rules of degree for the GPA and failed courses of the
+// asset, unless the dean overrode them
+func (a *Asset) evaluateHonors(degree *program.Program) {
+	if a.HonorsOverride != nil {
+		return
+	}
+	a.setHonors(degree.Honors(a.GPA, a.FailedCourses))
+}
@@ -0,0 +1,203 @@
+package certificate
+
+import (
+	"fmt"
+	"testing"
+
+	"academic_certificates/contracts/program"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (n *testNetwork) setHonorsRules(rules ...program.HonorsRule) error {
+	return n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return new(program.ContractProgram).UpdateProgram(ctx, &program.Program{
+			ID: testLawProgramID, Name: "Licenciado en Derecho", Level: program.Bachelor, FirstYear: 1990, HonorsRules: rules,
+		})
+	})
+}
+
+func (n *testNetwork) overrideHonors(id string, honors HonorsLevel, reason, signatoryID string) error {
+	return n.submit(n.dean, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.OverrideHonors(ctx, HonorsOverrideRequest{ID: id, Honors: honors, Reason: reason, SignatoryID: signatoryID})
+	})
+}
+
+func TestHonorsRules(t *testing.T) {
+	n := newTestNetwork(t)
+
+	expectError(t, n.setHonorsRules(program.HonorsRule{Level: program.NoHonors, MinGPA: 4}), lus.ErrorInvalidHonorsLevel, 0)
+	expectError(t, n.setHonorsRules(program.HonorsRule{Level: program.Gold, MinGPA: 6}), lus.ErrorInvalidHonorsRule, program.Gold)
+	expectError(t, n.setHonorsRules(program.HonorsRule{Level: program.Gold, MinGPA: 4.75}, program.HonorsRule{Level: program.Gold, MinGPA: 4.5}),
+		lus.ErrorHonorsRuleDuplicated, program.Gold)
+	err := n.setHonorsRules(
+		program.HonorsRule{Level: program.Gold, MinGPA: 4.75},
+		program.HonorsRule{Level: program.Distinction, MinGPA: 4.5, MaxFailedCourses: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passed := CourseRecord{Code: "DER-101", Name: "Derecho Romano", Grade: 5}
+	failed := func(code string) CourseRecord {
+		return CourseRecord{Code: code, Name: "Derecho Penal", Credits: 1, Grade: 2}
+	}
+	tests := []struct {
+		id      string
+		credits int // of the passed course
+		failed  []CourseRecord
+		gpa     float64
+		honors  HonorsLevel
+	}{
+		{"CERT20221122103010", 24, nil, 5, program.Gold},
+		{"CERT20221122103011", 22, []CourseRecord{failed("DER-201")}, 4.87, program.Distinction},
+		{"CERT20221122103012", 40, []CourseRecord{failed("DER-201"), failed("DER-202")}, 4.86, program.NoHonors},
+	}
+	for i, test := range tests {
+		// the client can not set the GPA nor the honors, they are evaluated with the transcript
+		request := newTestAsset(test.id, 10+i)
+		request.HolderID = testClassIDs[i]
+		request.GPA, request.FailedCourses, request.Honors, request.GoldCertificate = 5, 0, program.Gold, true
+		n.createAsset(t, request)
+		if asset := n.readAsset(t, test.id); asset.Honors != program.NoHonors || asset.GoldCertificate || asset.GPA != 0 {
+			t.Fatalf("%s: honors without transcript = %v, gold = %v, GPA = %v", test.id, asset.Honors, asset.GoldCertificate, asset.GPA)
+		}
+
+		course := passed
+		course.Credits = test.credits
+		err := n.createTranscript(n.member, fmt.Sprintf("TRSC202211221030%d", 20+i), test.id, append([]CourseRecord{course}, test.failed...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		asset := n.readAsset(t, test.id)
+		if asset.GPA != test.gpa || asset.Honors != test.honors || asset.GoldCertificate != (test.honors == program.Gold) || asset.HonorsLabel != test.honors.Localize(lus.LangEs) {
+			t.Errorf("%s: GPA = %v, honors = %v, gold = %v, label = %s, want %v", test.id, asset.GPA, asset.Honors, asset.GoldCertificate, asset.HonorsLabel, test.honors)
+		}
+	}
+
+	// moving a certificate to another program evaluates its rules
+	other := &program.Program{DocType: lus.CodProgram, ID: "PROG20221122103025", Code: "DEI", Name: "Licenciado en Derecho Internacional", Level: program.Bachelor, FacultyID: testLawID, FirstYear: 1990}
+	err = n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return program.PutProgram(ctx.GetStub(), other)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := newTestAsset("CERT20221122103010", 10)
+	update.HolderID, update.ProgramID = testClassIDs[0], other.ID
+	err = n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateAsset(ctx, update)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset := n.readAsset(t, "CERT20221122103010"); asset.Honors != program.NoHonors || asset.GoldCertificate || asset.GPA != 5 {
+		t.Errorf("honors in the program without rules = %v, gold = %v, GPA = %v", asset.Honors, asset.GoldCertificate, asset.GPA)
+	}
+}
+
+func TestHonorsOfSignedJointDegree(t *testing.T) {
+	n := newTestNetwork(t)
+	n.addPartner(t)
+	if err := n.setHonorsRules(program.HonorsRule{Level: program.Gold, MinGPA: 4.75}); err != nil {
+		t.Fatal(err)
+	}
+	id := "CERT20221122103010"
+	request := newTestAsset(id, 1)
+	request.CoIssuers = []CoIssuer{{EmitterID: testPartnerID, FacultyID: testPartnerFacultyID}}
+	n.createAsset(t, request)
+
+	// the certificate stays new until the partner signs, but the emitter signature locks the honors
+	if err := n.sign(n.secretary, id, testSecretaryID, Secretary); err != nil {
+		t.Fatal(err)
+	}
+	if asset := n.readAsset(t, id); asset.Status != New {
+		t.Fatalf("status = %v, want %v", asset.Status, New)
+	}
+	courses := []CourseRecord{
+		{Code: "DER-101", Name: "Derecho Romano", Credits: 4, Grade: 5},
+		{Code: "DER-102", Name: "Teoría del Estado", Credits: 3, Grade: 5},
+	}
+	if err := n.createTranscript(n.member, "TRSC20221122103011", id, courses); err != nil {
+		t.Fatal(err)
+	}
+	if asset := n.readAsset(t, id); asset.Honors != program.NoHonors || asset.GPA != 0 {
+		t.Errorf("honors after the transcript = %v, GPA = %v", asset.Honors, asset.GPA)
+	}
+}
+
+func TestOverrideHonors(t *testing.T) {
+	n := newTestNetwork(t)
+	if err := n.setHonorsRules(program.HonorsRule{Level: program.Gold, MinGPA: 4.75}); err != nil {
+		t.Fatal(err)
+	}
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+	courses := []CourseRecord{
+		{Code: "DER-101", Name: "Derecho Romano", Credits: 4, Grade: 5},
+		{Code: "DER-102", Name: "Teoría del Estado", Credits: 1, Grade: 4},
+	}
+	if err := n.createTranscript(n.member, "TRSC20221122103011", id, courses); err != nil {
+		t.Fatal(err)
+	}
+
+	expectError(t, n.overrideHonors(id, program.Distinction, " ", testDeanID), lus.ErrorHonorsReason)
+	expectError(t, n.overrideHonors(id, 7, "typo", testDeanID), lus.ErrorInvalidHonorsLevel, 7)
+	// only the dean of the faculty can approve it
+	expectError(t, n.overrideHonors(id, program.Distinction, "plagiarism in the thesis", testSecretaryID), lus.ErrorSignatoryRole, testSecretaryID, Dean)
+
+	if err := n.overrideHonors(id, program.Distinction, "plagiarism in the thesis", testDeanID); err != nil {
+		t.Fatal(err)
+	}
+	asset := n.readAsset(t, id)
+	override := asset.HonorsOverride
+	if asset.Honors != program.Distinction || asset.GoldCertificate || override == nil {
+		t.Fatalf("overridden honors = %v, gold = %v, override = %v", asset.Honors, asset.GoldCertificate, override)
+	}
+	if override.Computed != program.Gold || override.Reason != "plagiarism in the thesis" || override.DeanID != testDeanID || override.Dean != "Luis Gómez" || override.Date != "2022-11-22" {
+		t.Errorf("override = %+v", override)
+	}
+
+	// the override is kept by the updates
+	err := n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		update := newTestAsset(id, 1)
+		update.GoldCertificate = true
+		return n.contract.UpdateAsset(ctx, update)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset = n.readAsset(t, id); asset.Honors != program.Distinction || asset.GoldCertificate || asset.GPA != 4.8 {
+		t.Errorf("honors after the update = %v, gold = %v, GPA = %v", asset.Honors, asset.GoldCertificate, asset.GPA)
+	}
+	// and by a later transcript
+	other := newClassAsset(1)
+	n.createAsset(t, other)
+	if err := n.overrideHonors(other.ID, program.Distinction, "national award", testDeanID); err != nil {
+		t.Fatal(err)
+	}
+	if err = n.createTranscript(n.member, "TRSC20221122103012", other.ID, courses); err != nil {
+		t.Fatal(err)
+	}
+	if asset = n.readAsset(t, other.ID); asset.Honors != program.Distinction || asset.HonorsOverride.Computed != program.NoHonors || asset.GPA != 0 {
+		t.Errorf("honors after the transcript = %v, override = %+v, GPA = %v", asset.Honors, asset.HonorsOverride, asset.GPA)
+	}
+
+	for _, err := range []error{n.sign(n.secretary, id, testSecretaryID, Secretary), n.sign(n.dean, id, testDeanID, Dean), n.sign(n.rector, id, testRectorID, Rector)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectError(t, n.overrideHonors(id, program.Gold, "appeal", testDeanID), lus.ErrorHonorsLocked, id)
+}
+
+func TestUpgradeGoldCertificate(t *testing.T) {
+	asset, upgraded, err := upgradeAsset([]byte(`{"ID":"CERT20221122103010","schema_version":1,"date":"2010-11-08","gold_certificate":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upgraded || asset.Honors != program.Gold {
+		t.Errorf("upgraded = %v, honors = %v", upgraded, asset.Honors)
+	}
+}
@@ -0,0 +1,246 @@
+package certificate
+
+import (
+	"encoding/json"
+	"strings"
+
+	"academic_certificates/contracts/institution"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RequestLegalization starts the legalization of a valid certificate, ex: for a graduate emigrating.
+// Administrators and members of the emitter organization can request it, again after a rejection.
+func (s *ContractCertificate) RequestLegalization(ctx contractapi.TransactionContextInterface, request LegalizationRequest) error {
+	asset, err := getValidAsset(ctx.GetStub(), request.ID)
+	if err != nil {
+		return err
+	}
+	emitter, err := institution.GetInstitution(ctx.GetStub(), asset.EmitterID)
+	if err != nil {
+		return err
+	}
+	err = institution.CheckManager(ctx, emitter)
+	if err != nil {
+		return err
+	}
+
+	stored, err := getLegalization(ctx.GetStub(), asset.ID)
+	if err != nil {
+		return err
+	} else if stored != nil && stored.Status != LegalizationRejected {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, stored.ID)
+	}
+
+	clientID, err := lus.GetClientID(ctx)
+	if err != nil {
+		return err
+	}
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return err
+	}
+	if stored != nil {
+		audit.CreatedAt, audit.CreatedBy = stored.CreatedAt, stored.CreatedBy
+	}
+
+	return putLegalization(ctx.GetStub(), &Legalization{
+		DocType:       lus.CodLegalization,
+		ID:            legalizationID(asset.ID),
+		CertificateID: asset.ID,
+		Country:       strings.TrimSpace(request.Country),
+		Status:        LegalizationRequested,
+		RequestedBy:   clientID,
+		Audit:         audit,
+	})
+}
+
+// ReviewLegalization approves or rejects a requested legalization. Only members of the organization
+// of the Ministry of Education, see Settings, can review it.
+func (s *ContractCertificate) ReviewLegalization(ctx contractapi.TransactionContextInterface, request LegalizationReview) error {
+	settings, err := getSettings(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	err = checkClientOrg(ctx, settings.MinistryMSPID, "ministry_msp_id", request.ID)
+	if err != nil {
+		return err
+	}
+	if !request.Approved && strings.TrimSpace(request.Reason) == "" {
+		return lus.Errorf(lus.ErrorLegalizationReason)
+	}
+
+	legalization, err := getPendingLegalization(ctx.GetStub(), request.ID, LegalizationRequested)
+	if err != nil {
+		return err
+	}
+	legalization.ReviewedBy, err = lus.GetClientID(ctx)
+	if err != nil {
+		return err
+	}
+	err = legalization.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	legalization.Status = Legalized
+	if !request.Approved {
+		legalization.Status = LegalizationRejected
+		legalization.RejectReason = strings.TrimSpace(request.Reason)
+	}
+
+	return putLegalization(ctx.GetStub(), legalization)
+}
+
+// ApostilleCertificate records the apostille of a legalized certificate. Only members of the organization
+// of the apostille authority, see Settings, can record it.
+func (s *ContractCertificate) ApostilleCertificate(ctx contractapi.TransactionContextInterface, request ApostilleRequest) error {
+	settings, err := getSettings(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	err = checkClientOrg(ctx, settings.ApostilleMSPID, "apostille_msp_id", request.ID)
+	if err != nil {
+		return err
+	}
+	number := strings.TrimSpace(request.Number)
+	if number == "" {
+		return lus.Errorf(lus.ErrorApostilleNumber)
+	}
+	date, err := lus.ValidatePastDate(ctx.GetStub(), request.Date)
+	if err != nil {
+		return err
+	}
+
+	legalization, err := getPendingLegalization(ctx.GetStub(), request.ID, Legalized)
+	if err != nil {
+		return err
+	}
+	legalization.ApostilledBy, err = lus.GetClientID(ctx)
+	if err != nil {
+		return err
+	}
+	err = legalization.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	legalization.Status = Apostilled
+	legalization.ApostilleNumber = number
+	legalization.ApostilleDate = date
+
+	return putLegalization(ctx.GetStub(), legalization)
+}
+
+// ReadLegalization returns the legalization of a certificate. Its steps can be read with the history of
+// the lus.CodLegalization document.
+func (s *ContractCertificate) ReadLegalization(ctx contractapi.TransactionContextInterface, request GetRequest) (*Legalization, error) {
+	legalization, err := getLegalization(ctx.GetStub(), request.ID)
+	if err != nil {
+		return nil, err
+	} else if legalization == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, legalizationID(request.ID))
+	}
+	legalization.StatusLabel = legalization.Status.Localize(lus.GetLanguage(ctx))
+
+	return legalization, nil
+}
+
+// legalizationID returns the id of the legalization of a certificate, the id of the certificate with
+// the lus.CodLegalization prefix
+func legalizationID(certificateID string) string {
+	return lus.CodLegalization + strings.TrimPrefix(certificateID, lus.CodCert)
+}
+
+// getValidAsset returns the certificate with given id, or an error unless it is valid
+func getValidAsset(stub shim.ChaincodeStubInterface, id string) (*Asset, error) {
+	_, _, assetJSON, err := lus.ExistsAssetFromId(stub, lus.CodCert, id)
+	if err != nil {
+		return nil, err
+	} else if assetJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+	asset, err := unmarshalAsset(assetJSON)
+	if err != nil {
+		return nil, err
+	} else if asset.Status != Valid {
+		return nil, lus.Errorf(lus.ErrorCertificateNotValid, id)
+	}
+
+	return asset, nil
+}
+
+// getLegalization returns the legalization of the certificate with given id, nil if it was never requested
+func getLegalization(stub shim.ChaincodeStubInterface, certificateID string) (*Legalization, error) {
+	err := lus.ValidateID(lus.CodCert, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	_, _, legalizationJSON, err := lus.ExistsAssetFromId(stub, lus.CodLegalization, legalizationID(certificateID))
+	if err != nil || legalizationJSON == nil {
+		return nil, err
+	}
+
+	var legalization Legalization
+	err = json.Unmarshal(legalizationJSON, &legalization)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &legalization, nil
+}
+
+// getPendingLegalization returns the legalization of a valid certificate, or an error unless it has status
+func getPendingLegalization(stub shim.ChaincodeStubInterface, certificateID string, status LegalizationStatus) (*Legalization, error) {
+	_, err := getValidAsset(stub, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	legalization, err := getLegalization(stub, certificateID)
+	if err != nil {
+		return nil, err
+	} else if legalization == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, legalizationID(certificateID))
+	} else if legalization.Status != status {
+		return nil, lus.Errorf(lus.ErrorLegalizationStatus, certificateID, legalization.Status, status)
+	}
+
+	return legalization, nil
+}
+
+// putLegalization writes a legalization to the world state and emits the EventLegalizationUpdated event
+func putLegalization(stub shim.ChaincodeStubInterface, legalization *Legalization) error {
+	key, _, err := lus.CompositeKeyFromID(stub, lus.CodLegalization, legalization.ID)
+	if err != nil {
+		return err
+	}
+	legalization.SchemaVersion = SchemaVersion
+	legalizationJSON, err := json.Marshal(legalization)
+	if err != nil {
+		return err
+	}
+
+	err = stub.PutState(key, legalizationJSON)
+	if err != nil {
+		return err
+	}
+	return stub.SetEvent(lus.EventLegalizationUpdated, legalizationJSON)
+}
+
+// checkClientOrg returns an error unless the client identity belongs to the organization mspID, the
+// value of the setting with given name
+func checkClientOrg(ctx contractapi.TransactionContextInterface, mspID, setting, id string) error {
+	if mspID == "" {
+		return lus.Errorf(lus.ErrorInvalidSettings, setting+" is not configured")
+	}
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return lus.Errorf(lus.ErrorClientIdentity, err)
+	}
+	if clientMSPID != mspID {
+		return lus.Errorf(lus.ErrorForbiddenMSP, clientMSPID, id)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package certificate
+
+import (
+	"testing"
+
+	"academic_certificates/contracts/common"
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func (n *testNetwork) readLegalization(t *testing.T, id string) *Legalization {
+	t.Helper()
+	var legalization *Legalization
+	err := n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) (err error) {
+		legalization, err = n.contract.ReadLegalization(ctx, GetRequest{ID: id})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return legalization
+}
+
+func TestLegalization(t *testing.T) {
+	n := newTestNetwork(t)
+	ministry := mockstub.MustIdentity("MinedMSP", "User1@mined.example.com", nil)
+	apostille := mockstub.MustIdentity("MinrexMSP", "User1@minrex.example.com", nil)
+	other := mockstub.MustIdentity("Org2MSP", "User1@org2.example.com", nil)
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+
+	request := func(identity *mockstub.Identity) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.RequestLegalization(ctx, LegalizationRequest{ID: id, Country: "España"})
+		})
+	}
+	review := func(identity *mockstub.Identity, approved bool, reason string) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.ReviewLegalization(ctx, LegalizationReview{ID: id, Approved: approved, Reason: reason})
+		})
+	}
+	apostilleCertificate := func(identity *mockstub.Identity, number, date string) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.ApostilleCertificate(ctx, ApostilleRequest{ID: id, Number: number, Date: date})
+		})
+	}
+
+	// only valid certificates can be legalized
+	expectError(t, request(n.member), lus.ErrorCertificateNotValid, id)
+	for _, err := range []error{n.sign(n.secretary, id, testSecretaryID, Secretary), n.sign(n.dean, id, testDeanID, Dean), n.sign(n.rector, id, testRectorID, Rector)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectError(t, request(other), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	if err := request(n.member); err != nil {
+		t.Fatal(err)
+	}
+	if event := n.stub.LastEvent(); event.EventName != lus.EventLegalizationUpdated {
+		t.Errorf("event = %s", event.EventName)
+	}
+	expectError(t, request(n.member), lus.ErrorAlreadyExistInState, legalizationID(id))
+
+	// the ministry and the apostille authority are configured in the settings
+	expectError(t, review(ministry, true, ""), lus.ErrorInvalidSettings, "ministry_msp_id is not configured")
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateSettings(ctx, &SettingsUpdate{Fields: []string{"ministry_msp_id", "apostille_msp_id"}, MinistryMSPID: "MinedMSP", ApostilleMSPID: "MinrexMSP"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every step is signed by its organization, in order
+	expectError(t, apostilleCertificate(apostille, "AP-2022-001", "2022-11-22"), lus.ErrorLegalizationStatus, id, LegalizationRequested, Legalized)
+	expectError(t, review(n.member, true, ""), lus.ErrorForbiddenMSP, "Org1MSP", id)
+	expectError(t, review(ministry, false, " "), lus.ErrorLegalizationReason)
+	if err = review(ministry, false, "illegible seal"); err != nil {
+		t.Fatal(err)
+	}
+	if legalization := n.readLegalization(t, id); legalization.Status != LegalizationRejected || legalization.RejectReason != "illegible seal" {
+		t.Errorf("rejected legalization = %+v", legalization)
+	}
+
+	// a rejected legalization can be requested again
+	if err = request(n.member); err != nil {
+		t.Fatal(err)
+	}
+	if err = review(ministry, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, apostilleCertificate(ministry, "AP-2022-001", "2022-11-22"), lus.ErrorForbiddenMSP, "MinedMSP", id)
+	expectError(t, apostilleCertificate(apostille, "", "2022-11-22"), lus.ErrorApostilleNumber)
+	expectError(t, apostilleCertificate(apostille, "AP-2022-001", "2030-01-01"), lus.ErrorFutureDate, "2030-01-01")
+	if err = apostilleCertificate(apostille, "AP-2022-001", "2022-11-22"); err != nil {
+		t.Fatal(err)
+	}
+
+	legalization := n.readLegalization(t, id)
+	if legalization.Status != Apostilled || legalization.ApostilleNumber != "AP-2022-001" || legalization.ApostilleDate != "2022-11-22" ||
+		legalization.Country != "España" || legalization.StatusLabel != "Apostillado" {
+		t.Errorf("legalization = %+v", legalization)
+	}
+	if legalization.RequestedBy == "" || legalization.ReviewedBy == legalization.RequestedBy || legalization.ApostilledBy == "" {
+		t.Errorf("signers = %s, %s, %s", legalization.RequestedBy, legalization.ReviewedBy, legalization.ApostilledBy)
+	}
+
+	// the certificate itself is not modified
+	if asset := n.readAsset(t, id); asset.Status != Valid {
+		t.Errorf("certificate status = %v", asset.Status)
+	}
+
+	var history lus.HistoryQueryResponse
+	err = n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) (err error) {
+		history, err = new(common.ContractCommon).GetHistory(ctx, &lus.GetHistoryRequest{ID: legalizationID(id), DocType: lus.CodLegalization})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(history.Response) != 5 {
+		t.Errorf("history has %d records, want 5", len(history.Response))
+	}
+}
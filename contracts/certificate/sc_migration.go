@@ -0,0 +1,146 @@
+package certificate
+
+import (
+	"encoding/json"
+
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// QueryMigrationPage returns the ids of the certificates stored with an older schema version among
+// request.PageSize keys of the certificates key range after request.Bookmark; invoke it again with the
+// returned bookmark until it is empty, and pass the ids to MigrateAssets. Paginated queries start at the
+// bookmark, but they are only allowed in read only transactions, and range queries can not start at a
+// composite key, so the migration itself takes the ids.
+func (s *ContractCertificate) QueryMigrationPage(ctx contractapi.TransactionContextInterface, request MigrateRequest) (*MigrationPage, error) {
+	err := checkBatchSize(ctx.GetStub(), request.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByPartialCompositeKeyWithPagination(lus.CodCert, []string{}, int32(request.PageSize), request.Bookmark)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	}
+	defer resultsIterator.Close()
+
+	page := MigrationPage{IDs: make([]string, 0)}
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		page.Scanned++
+		asset, upgraded, err := upgradeAsset(result.Value)
+		if err != nil {
+			return nil, err
+		} else if upgraded {
+			page.IDs = append(page.IDs, asset.ID)
+		}
+	}
+	// a short page is the last one
+	if int(metadata.FetchedRecordsCount) == request.PageSize {
+		page.Bookmark = metadata.Bookmark
+	}
+
+	return &page, nil
+}
+
+// MigrateAssets rewrites the certificates with given ids stored with an older schema version, upgraded to
+// SchemaVersion, and writes their index entries and creation audit fields, which the oldest records lack. The certificates whose
+// registry book entry, or holder and program, are taken by another one are upgraded without indexes and
+// reported as conflicts, to be corrected with UpdateAsset or InvalidateAsset. Only administrators can
+// migrate the certificates.
+func (s *ContractCertificate) MigrateAssets(ctx contractapi.TransactionContextInterface, request MigrateAssetsRequest) (*MigrateResponse, error) {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stub := ctx.GetStub()
+	err = checkBatchSize(stub, len(request.IDs))
+	if err != nil {
+		return nil, err
+	}
+
+	response := MigrateResponse{Migrated: make([]string, 0), Conflicts: make([]string, 0)}
+	// writes are not visible to the reads of the same transaction, the keys of the page are checked here
+	batchKeys := make(map[string]bool)
+	for _, id := range request.IDs {
+		compositeKey, _, assetJSON, err := lus.ExistsAssetFromId(stub, lus.CodCert, id)
+		if err != nil {
+			return nil, err
+		} else if assetJSON == nil {
+			return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+		}
+		asset, upgraded, err := upgradeAsset(assetJSON)
+		if err != nil {
+			return nil, err
+		} else if !upgraded {
+			continue
+		}
+		err = asset.Audit.Stamp(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if asset.CreatedAt == "" {
+			err = backfillAudit(stub, compositeKey, &asset.Audit)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = checkIndexes(stub, asset)
+		if err == nil {
+			err = checkBatchKeys(stub, asset, batchKeys)
+		}
+		if err == nil {
+			err = putIndexes(stub, asset)
+			if err != nil {
+				return nil, err
+			}
+		} else if code := lus.ErrorCode(err); code == lus.CodeFolioTaken || code == lus.CodeDuplicateCertificate || code == lus.CodeBatchDuplicated {
+			response.Conflicts = append(response.Conflicts, asset.ID)
+		} else {
+			return nil, err
+		}
+
+		assetJSON, err = json.Marshal(asset)
+		if err != nil {
+			return nil, err
+		}
+		err = stub.PutState(compositeKey, assetJSON)
+		if err != nil {
+			return nil, lus.Errorf(lus.ErrorWorldState, err)
+		}
+		response.Migrated = append(response.Migrated, asset.ID)
+	}
+
+	return &response, nil
+}
+
+// backfillAudit sets the creation of a certificate stored before the audit fields, with key, to the time
+// of the first entry of its history, or the migration time without history, and MigratedCreator
+func backfillAudit(stub shim.ChaincodeStubInterface, key string, audit *lus.Audit) error {
+	history, err := stub.GetHistoryForKey(key)
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	}
+	defer history.Close()
+
+	audit.CreatedAt = audit.UpdatedAt
+	// the history is sorted newest first
+	for history.HasNext() {
+		modification, err := history.Next()
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+		if modification.Timestamp != nil {
+			audit.CreatedAt = lus.GetTimestampRFC3339(modification.Timestamp)
+		}
+	}
+	audit.CreatedBy = MigratedCreator
+
+	return nil
+}
@@ -0,0 +1,176 @@
+package certificate
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// legacyAsset returns a certificate as written before schema versioning
+func legacyAsset(id, holderID string, folio int) string {
+	return fmt.Sprintf(`{"docType":"CERT","ID":%q,"program_id":%q,"emitter_id":%q,"faculty_id":%q,"holder_id":%q,`+
+		`"date":"8 de Noviembre del 2010","volume_folio_faculty":"1,%d","volume_folio_university":"","certificate_status":1}`,
+		id, testLawProgramID, testInstitutionID, testLawID, holderID, folio)
+}
+
+func (n *testNetwork) putLegacyAssets(t *testing.T, ids ...string) {
+	t.Helper()
+	err := n.submit(n.admin, func(ctx contractapi.TransactionContextInterface) error {
+		for i, id := range ids {
+			key, _, err := lus.CompositeKeyFromID(ctx.GetStub(), lus.CodCert, id)
+			if err != nil {
+				return err
+			}
+			err = ctx.GetStub().PutState(key, []byte(legacyAsset(id, testClassIDs[i], i+1)))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpgradeAsset(t *testing.T) {
+	asset, upgraded, err := upgradeAsset([]byte(legacyAsset("CERT20221122103010", testHolderID, 136)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upgraded || asset.SchemaVersion != SchemaVersion {
+		t.Errorf("upgraded = %v, schema version = %d", upgraded, asset.SchemaVersion)
+	}
+	if asset.Date != "2010-11-08" || asset.FacultyVolumeFolio != (VolumeFolio{1, 136}) || asset.UniversityVolumeFolio != (VolumeFolio{}) {
+		t.Errorf("date = %s, folios = %v %v", asset.Date, asset.FacultyVolumeFolio, asset.UniversityVolumeFolio)
+	}
+
+	// the name of a registered graduate is dropped
+	asset, _, err = upgradeAsset([]byte(`{"ID":"CERT20221122103010","holder_id":"GRAD20221122103005","accredited":"María Fernández","schema_version":2}`))
+	if err != nil {
+		t.Fatal(err)
+	} else if asset.Accredited != "" {
+		t.Errorf("accredited = %q, want it blank", asset.Accredited)
+	}
+
+	current, _ := json.Marshal(asset)
+	if _, upgraded, _ = upgradeAsset(current); upgraded {
+		t.Error("a certificate of the current version was upgraded")
+	}
+
+	_, _, err = upgradeAsset([]byte(fmt.Sprintf(`{"ID":"CERT20221122103010","schema_version":%d}`, SchemaVersion+1)))
+	expectError(t, err, lus.ErrorSchemaVersion, "CERT20221122103010", SchemaVersion+1, SchemaVersion)
+}
+
+func TestMigrateAssets(t *testing.T) {
+	n := newTestNetwork(t)
+	n.putLegacyAssets(t, "CERT20221122103010", "CERT20221122103011", "CERT20221122103012")
+	n.createAsset(t, newTestAsset("CERT20221122103013", 4))
+
+	// legacy certificates are upgraded when read
+	if asset := n.readAsset(t, "CERT20221122103011"); asset.Date != "2010-11-08" {
+		t.Errorf("date = %s, want 2010-11-08", asset.Date)
+	}
+
+	query := func(bookmark string) *MigrationPage {
+		t.Helper()
+		var page *MigrationPage
+		err := n.stub.Evaluate(n.admin, func(ctx contractapi.TransactionContextInterface) (err error) {
+			page, err = n.contract.QueryMigrationPage(ctx, MigrateRequest{PageSize: 2, Bookmark: bookmark})
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return page
+	}
+	migrate := func(identity *mockstub.Identity, ids []string) (*MigrateResponse, error) {
+		var response *MigrateResponse
+		err := n.submit(identity, func(ctx contractapi.TransactionContextInterface) (err error) {
+			response, err = n.contract.MigrateAssets(ctx, MigrateAssetsRequest{IDs: ids})
+			return err
+		})
+		return response, err
+	}
+
+	_, err := migrate(n.member, []string{"CERT20221122103010"})
+	expectError(t, err, lus.ErrorNotAdmin)
+
+	// the legacy certificate of the holder of the new one can not take its holder and program index entry
+	var migrated, conflicts []string
+	bookmark := ""
+	for page := 0; page == 0 || bookmark != ""; page++ {
+		response := query(bookmark)
+		if page > 2 || response.Scanned > 2 {
+			t.Fatalf("page %d scanned %d keys", page, response.Scanned)
+		}
+		bookmark = response.Bookmark
+		if len(response.IDs) == 0 {
+			continue
+		}
+		result, err := migrate(n.admin, response.IDs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		migrated = append(migrated, result.Migrated...)
+		conflicts = append(conflicts, result.Conflicts...)
+	}
+	if got := fmt.Sprint(migrated); got != "[CERT20221122103010 CERT20221122103011 CERT20221122103012]" {
+		t.Errorf("migrated = %s", got)
+	}
+	if got := fmt.Sprint(conflicts); got != "[CERT20221122103010]" {
+		t.Errorf("conflicts = %s", got)
+	}
+
+	key, _, _ := lus.CompositeKeyFromID(n.stub, lus.CodCert, "CERT20221122103012")
+	var stored map[string]interface{}
+	_ = json.Unmarshal(n.stub.State(key), &stored)
+	if stored["schema_version"] != float64(SchemaVersion) || stored["date"] != "2010-11-08" || stored["updated_by"] == nil {
+		t.Errorf("stored certificate = %v", stored)
+	}
+	// the creation is taken from the history, the creator is unknown
+	if stored["created_at"] != "2022-11-22T10:30:01Z" || stored["created_by"] != MigratedCreator || stored["updated_at"] == stored["created_at"] {
+		t.Errorf("audit of the migrated certificate = %v, %v, %v", stored["created_at"], stored["created_by"], stored["updated_at"])
+	}
+
+	// the indexes of the migrated certificates are written
+	err = n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		assets, err := n.contract.QueryAssetsByHolder(ctx, GetRequest{ID: testOtherHolderID})
+		if err != nil {
+			return err
+		} else if len(assets) != 1 || assets[0].ID != "CERT20221122103011" {
+			t.Errorf("certificates of the holder = %d", len(assets))
+		}
+		found, err := n.contract.ReadAssetByRegistryEntry(ctx, RegistryEntryRequest{Book: FacultyBook, OwnerID: testLawID, Volume: 1, Folio: 3})
+		if err != nil {
+			return err
+		} else if found.ID != "CERT20221122103012" {
+			t.Errorf("registry entry 1,3 = %s", found.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := newTestAsset("CERT20221122103014", 5)
+	request.HolderID = testClassmateID
+	err = n.submit(n.member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateAsset(ctx, request)
+	})
+	expectError(t, err, lus.ErrorDuplicateCertificate, testClassmateID, "CERT20221122103012", testLawProgramID)
+
+	if page := query(""); len(page.IDs) != 0 {
+		t.Errorf("certificates left to migrate: %v", page.IDs)
+	}
+	response, err := migrate(n.admin, []string{"CERT20221122103011"})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(response.Migrated) != 0 {
+		t.Errorf("a second migration rewrote %v", response.Migrated)
+	}
+}
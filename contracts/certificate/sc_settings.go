@@ -0,0 +1,131 @@
+package certificate
+
+import (
+	"encoding/json"
+
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// DefaultMaxBatchSize items accepted by batch transactions while the settings are not stored
+const DefaultMaxBatchSize = 100
+
+// DefaultMaxDailyIssuance certificates a client identity can issue per day while the setting is not stored
+const DefaultMaxDailyIssuance = 500
+
+// ReadSettings returns the settings of the certificate contract.
+func (s *ContractCertificate) ReadSettings(ctx contractapi.TransactionContextInterface) (*Settings, error) {
+	return getSettings(ctx.GetStub())
+}
+
+// UpdateSettings changes the settings of the certificate contract listed in the fields of the request,
+// the others keep their stored value. Only administrators can update the settings.
+func (s *ContractCertificate) UpdateSettings(ctx contractapi.TransactionContextInterface, request *SettingsUpdate) error {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	} else if len(request.Fields) == 0 {
+		return lus.Errorf(lus.ErrorInvalidSettings, "fields must list the settings to update")
+	}
+
+	settings, err := getSettings(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	for _, field := range request.Fields {
+		set, ok := settingFields[field]
+		if !ok {
+			return lus.Errorf(lus.ErrorInvalidSettings, "unknown setting "+field)
+		}
+		set(settings, request)
+	}
+	err = checkSettings(settings)
+	if err != nil {
+		return err
+	}
+
+	err = settings.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+	if settings.CreatedAt == "" {
+		settings.CreatedAt = settings.UpdatedAt
+		settings.CreatedBy = settings.UpdatedBy
+	}
+	settings.SchemaVersion = SchemaVersion
+
+	key, err := settingsKey(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(key, settingsJSON)
+}
+
+// settingFields copies each setting of an update, by its json name
+var settingFields = map[string]func(settings *Settings, request *SettingsUpdate){
+	"max_batch_size":   func(settings *Settings, request *SettingsUpdate) { settings.MaxBatchSize = request.MaxBatchSize },
+	"ministry_msp_id":  func(settings *Settings, request *SettingsUpdate) { settings.MinistryMSPID = request.MinistryMSPID },
+	"apostille_msp_id": func(settings *Settings, request *SettingsUpdate) { settings.ApostilleMSPID = request.ApostilleMSPID },
+	"max_daily_issuance": func(settings *Settings, request *SettingsUpdate) {
+		settings.MaxDailyIssuance = request.MaxDailyIssuance
+	},
+	"office_hours_start": func(settings *Settings, request *SettingsUpdate) {
+		settings.OfficeHoursStart = request.OfficeHoursStart
+	},
+	"office_hours_end": func(settings *Settings, request *SettingsUpdate) { settings.OfficeHoursEnd = request.OfficeHoursEnd },
+	"utc_offset":       func(settings *Settings, request *SettingsUpdate) { settings.UTCOffset = request.UTCOffset },
+}
+
+// checkSettings validates the settings resulting from an update
+func checkSettings(settings *Settings) error {
+	if settings.MaxBatchSize <= 0 {
+		return lus.Errorf(lus.ErrorInvalidSettings, "max_batch_size must be positive")
+	}
+	if settings.MaxDailyIssuance < 0 {
+		return lus.Errorf(lus.ErrorInvalidSettings, "max_daily_issuance can not be negative")
+	}
+	if settings.OfficeHoursStart < 0 || settings.OfficeHoursEnd > 24 || settings.OfficeHoursStart > settings.OfficeHoursEnd {
+		return lus.Errorf(lus.ErrorInvalidSettings, "the office hours must be between 0 and 24, starting before they end")
+	}
+	if settings.UTCOffset < -12 || settings.UTCOffset > 14 {
+		return lus.Errorf(lus.ErrorInvalidSettings, "utc_offset must be between -12 and 14")
+	}
+	return nil
+}
+
+func settingsKey(stub shim.ChaincodeStubInterface) (string, error) {
+	return stub.CreateCompositeKey(lus.CodSettings, []string{lus.ContractNameCertificate})
+}
+
+// getSettings returns the stored settings, or the default ones if they were never stored
+func getSettings(stub shim.ChaincodeStubInterface) (*Settings, error) {
+	settings := Settings{
+		DocType:      lus.CodSettings,
+		MaxBatchSize: DefaultMaxBatchSize,
+	}
+
+	key, err := settingsKey(stub)
+	if err != nil {
+		return nil, err
+	}
+	settingsJSON, err := stub.GetState(key)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	} else if settingsJSON == nil {
+		return &settings, nil
+	}
+
+	err = json.Unmarshal(settingsJSON, &settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return &settings, nil
+}
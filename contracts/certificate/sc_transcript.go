@@ -0,0 +1,333 @@
+package certificate
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/program"
+	"academic_certificates/contracts/signatory"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// CreateTranscript issues the transcript of a certificate, without signatures. Administrators and members
+// of the emitter organization can issue it, a certificate has at most one transcript. The honors of
+// a certificate without signatures are evaluated again with the GPA of the transcript.
+func (s *ContractCertificate) CreateTranscript(ctx contractapi.TransactionContextInterface, request *Transcript) error {
+	stub := ctx.GetStub()
+	asset, err := getAsset(ctx, request.CertificateID)
+	if err != nil {
+		return err
+	} else if asset.Status == Invalid {
+		return lus.Errorf(lus.ErrorCertificateNotValid, asset.ID)
+	}
+	issuers, err := getIssuers(stub, asset)
+	if err != nil {
+		return err
+	}
+	err = institution.CheckManager(ctx, issuers[0])
+	if err != nil {
+		return err
+	}
+	// the transcript needs the same endorsements as its certificate
+	orgs, err := issuerOrgs(issuers)
+	if err != nil {
+		return err
+	}
+
+	key, _, transcriptJSON, err := lus.ExistsAssetFromId(stub, lus.CodTranscript, request.ID)
+	if err != nil {
+		return err
+	} else if transcriptJSON != nil {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, request.ID)
+	}
+	indexKey, err := transcriptIndexKey(stub, asset.ID)
+	if err != nil {
+		return err
+	}
+	linkedID, err := stub.GetState(indexKey)
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	} else if linkedID != nil {
+		return lus.Errorf(lus.ErrorTranscriptExists, asset.ID, string(linkedID))
+	}
+
+	courses, err := checkCourses(request.Courses)
+	if err != nil {
+		return err
+	}
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return err
+	}
+
+	transcript := Transcript{
+		DocType:       lus.CodTranscript,
+		ID:            request.ID,
+		CertificateID: asset.ID,
+		Courses:       courses,
+		Status:        New,
+		Audit:         audit,
+	}
+	transcript.computeGrades()
+
+	err = stub.PutState(indexKey, []byte(transcript.ID))
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	}
+	err = setEndorsers(stub, key, transcript.ID, orgs)
+	if err != nil {
+		return err
+	}
+	err = setEndorsers(stub, indexKey, transcript.ID, orgs)
+	if err != nil {
+		return err
+	}
+
+	err = putTranscript(stub, &transcript)
+	if err != nil {
+		return err
+	}
+
+	// the honors of a certificate without signatures are evaluated again with the grades of the transcript
+	if asset.signed() || asset.HonorsOverride != nil {
+		return nil
+	}
+	degree, err := program.GetProgram(stub, asset.ProgramID)
+	if err != nil {
+		return err
+	}
+	asset.GPA, asset.FailedCourses = transcript.GPA, transcript.FailedCourses
+	asset.evaluateHonors(degree)
+
+	return s.updateAsset(ctx, asset)
+}
+
+// ReadTranscript returns the transcript stored in the world state with given id. Only the holder and the
+// issuers of the certificate can read it, other organizations read it through a grant.
+func (s *ContractCertificate) ReadTranscript(ctx contractapi.TransactionContextInterface, request GetRequest) (*Transcript, error) {
+	transcript, err := getTranscript(ctx.GetStub(), request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return readableTranscript(ctx, transcript)
+}
+
+// ReadTranscriptByCertificate returns the transcript of the certificate with given id, as ReadTranscript.
+func (s *ContractCertificate) ReadTranscriptByCertificate(ctx contractapi.TransactionContextInterface, request GetRequest) (*Transcript, error) {
+	transcript, err := getTranscriptByCertificate(ctx.GetStub(), request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return readableTranscript(ctx, transcript)
+}
+
+// readableTranscript returns transcript with its label in the language of the request, or an error unless
+// the client identity can read the private data of its certificate
+func readableTranscript(ctx contractapi.TransactionContextInterface, transcript *Transcript) (*Transcript, error) {
+	asset, err := getAsset(ctx, transcript.CertificateID)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAssetReader(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+	transcript.StatusLabel = transcript.Status.Localize(lus.GetLanguage(ctx))
+
+	return transcript, nil
+}
+
+// ValidateTranscript signs a transcript. The chain of signatures and the checks of the signatory are
+// the ones of ValidateAsset for the emitter of the certificate, which must not be invalid, and each role
+// is signed by the signatory that signed the certificate in that role.
+func (s *ContractCertificate) ValidateTranscript(ctx contractapi.TransactionContextInterface, request *ValidateTranscript) error {
+	stub := ctx.GetStub()
+	transcript, err := getTranscript(stub, request.ID)
+	if err != nil {
+		return err
+	}
+	asset, err := getAsset(ctx, transcript.CertificateID)
+	if err != nil {
+		return err
+	} else if asset.Status == Invalid {
+		return lus.Errorf(lus.ErrorCertificateNotValid, asset.ID)
+	}
+
+	chain := CoIssuer{
+		EmitterID:           asset.EmitterID,
+		FacultyID:           asset.FacultyID,
+		SecretaryValidating: transcript.SecretaryValidating,
+		DeanValidating:      transcript.DeanValidating,
+		RectorValidating:    transcript.RectorValidating,
+		Status:              transcript.Status,
+	}
+	err = chain.checkNextSignature(request.ValidatorT)
+	if err != nil {
+		return err
+	}
+	signer, err := signatory.CheckSigner(ctx, request.SignatoryID, request.ValidatorT, chain.EmitterID, chain.FacultyID)
+	if err != nil {
+		return err
+	}
+	// the transcript is signed by the signatories of the certificate, once they signed it
+	signed, _, err := asset.issuerChain(asset.EmitterID)
+	if err != nil {
+		return err
+	} else if signed.signerID(request.ValidatorT) != signer.ID {
+		return lus.Errorf(lus.ErrorTranscriptSigner, signer.ID, asset.ID, request.ValidatorT)
+	}
+	chain.addSignature(request.ValidatorT, signer)
+
+	switch request.ValidatorT {
+	case Secretary:
+		transcript.SecretaryValidating, transcript.SecretaryID = chain.SecretaryValidating, chain.SecretaryID
+	case Dean:
+		transcript.DeanValidating, transcript.DeanID = chain.DeanValidating, chain.DeanID
+	case Rector:
+		transcript.RectorValidating, transcript.RectorID = chain.RectorValidating, chain.RectorID
+	}
+	transcript.Status = chain.Status
+	err = transcript.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	return putTranscript(stub, transcript)
+}
+
+// checkCourses returns the courses with their code and name trimmed, or an error if any of them
+// is incomplete, repeated or has an invalid grade or credits
+func checkCourses(courses []CourseRecord) ([]CourseRecord, error) {
+	if len(courses) == 0 {
+		return nil, lus.Errorf(lus.ErrorEmptyTranscript)
+	}
+
+	checked := make([]CourseRecord, 0, len(courses))
+	codes := make(map[string]bool)
+	for _, course := range courses {
+		course.Code = strings.TrimSpace(course.Code)
+		course.Name = strings.TrimSpace(course.Name)
+		if course.Code == "" || course.Name == "" {
+			return nil, lus.Errorf(lus.ErrorCourseIncomplete)
+		}
+		if codes[course.Code] {
+			return nil, lus.Errorf(lus.ErrorCourseDuplicated, course.Code)
+		}
+		codes[course.Code] = true
+		if course.Grade < MinGrade || course.Grade > MaxGrade {
+			return nil, lus.Errorf(lus.ErrorInvalidGrade, course.Grade, course.Code, MinGrade, MaxGrade)
+		}
+		if course.Credits <= 0 {
+			return nil, lus.Errorf(lus.ErrorInvalidCredits, course.Credits, course.Code)
+		}
+		checked = append(checked, course)
+	}
+
+	return checked, nil
+}
+
+// computeGrades sets the GPA, the credits and the failed courses of the transcript from its courses
+func (tr *Transcript) computeGrades() {
+	points, credits := 0, 0
+	tr.Credits, tr.FailedCourses = 0, 0
+	for _, course := range tr.Courses {
+		points += course.Grade * course.Credits
+		credits += course.Credits
+		if course.Grade < PassingGrade {
+			tr.FailedCourses++
+		} else {
+			tr.Credits += course.Credits
+		}
+	}
+	tr.GPA = 0
+	if credits > 0 {
+		tr.GPA = math.Round(float64(points)/float64(credits)*100) / 100
+	}
+}
+
+// transcriptIndexKey key of the index entry of the transcript of a certificate, its value is the transcript id
+func transcriptIndexKey(stub shim.ChaincodeStubInterface, certificateID string) (string, error) {
+	return stub.CreateCompositeKey(lus.CodCertTranscript, []string{certificateID})
+}
+
+// setTranscriptPolicy sets the key-level endorsement policy of the transcript of a certificate, and of
+// its index entry, if the certificate has one
+func setTranscriptPolicy(stub shim.ChaincodeStubInterface, certificateID string, policy []byte) error {
+	indexKey, err := transcriptIndexKey(stub, certificateID)
+	if err != nil {
+		return err
+	}
+	id, err := stub.GetState(indexKey)
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	} else if id == nil {
+		return nil
+	}
+	key, _, err := lus.CompositeKeyFromID(stub, lus.CodTranscript, string(id))
+	if err != nil {
+		return err
+	}
+	err = stub.SetStateValidationParameter(key, policy)
+	if err != nil {
+		return err
+	}
+
+	return stub.SetStateValidationParameter(indexKey, policy)
+}
+
+// getTranscript returns the transcript stored in the world state with given id
+func getTranscript(stub shim.ChaincodeStubInterface, id string) (*Transcript, error) {
+	_, _, transcriptJSON, err := lus.ExistsAssetFromId(stub, lus.CodTranscript, id)
+	if err != nil {
+		return nil, err
+	} else if transcriptJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+
+	var transcript Transcript
+	err = json.Unmarshal(transcriptJSON, &transcript)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &transcript, nil
+}
+
+// getTranscriptByCertificate returns the transcript of the certificate with given id
+func getTranscriptByCertificate(stub shim.ChaincodeStubInterface, certificateID string) (*Transcript, error) {
+	indexKey, err := transcriptIndexKey(stub, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	id, err := stub.GetState(indexKey)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	} else if id == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, certificateID)
+	}
+
+	return getTranscript(stub, string(id))
+}
+
+// putTranscript writes a transcript to the world state
+func putTranscript(stub shim.ChaincodeStubInterface, transcript *Transcript) error {
+	key, _, err := lus.CompositeKeyFromID(stub, lus.CodTranscript, transcript.ID)
+	if err != nil {
+		return err
+	}
+	transcript.SchemaVersion = SchemaVersion
+	transcript.StatusLabel = ""
+	transcriptJSON, err := json.Marshal(transcript)
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(key, transcriptJSON)
+}
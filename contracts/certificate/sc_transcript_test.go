@@ -0,0 +1,106 @@
+package certificate
+
+import (
+	"testing"
+
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// testCourses courses of a law transcript, with a failed one
+func testCourses() []CourseRecord {
+	return []CourseRecord{
+		{Code: "DER-101", Name: "Derecho Romano", Year: 1, Credits: 4, Grade: 5},
+		{Code: "DER-102", Name: "Teoría del Estado", Year: 1, Credits: 3, Grade: 4},
+		{Code: "DER-201", Name: "Derecho Civil", Year: 2, Credits: 5, Grade: 3},
+		{Code: "DER-202", Name: "Derecho Penal", Year: 2, Credits: 2, Grade: 2},
+	}
+}
+
+func (n *testNetwork) createTranscript(identity *mockstub.Identity, id, certificateID string, courses []CourseRecord) error {
+	return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateTranscript(ctx, &Transcript{ID: id, CertificateID: certificateID, Courses: courses})
+	})
+}
+
+func (n *testNetwork) signTranscript(identity *mockstub.Identity, id, signatoryID string, role ValidatorType) error {
+	return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.ValidateTranscript(ctx, &ValidateTranscript{ID: id, SignatoryID: signatoryID, ValidatorT: role})
+	})
+}
+
+func TestTranscript(t *testing.T) {
+	n := newTestNetwork(t)
+	certificateID, id := "CERT20221122103010", "TRSC20221122103011"
+	n.createAsset(t, newTestAsset(certificateID, 1))
+	other := mockstub.MustIdentity("Org2MSP", "User1@org2.example.com", nil)
+
+	expectError(t, n.createTranscript(n.member, id, "CERT20000101000000", testCourses()), lus.ErrorNotExistInState, "CERT20000101000000")
+	expectError(t, n.createTranscript(other, id, certificateID, testCourses()), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	expectError(t, n.createTranscript(n.member, id, certificateID, nil), lus.ErrorEmptyTranscript)
+	invalid := []struct {
+		course CourseRecord
+		format string
+		args   []interface{}
+	}{
+		{CourseRecord{Code: " ", Name: "Derecho Romano", Credits: 4, Grade: 5}, lus.ErrorCourseIncomplete, nil},
+		{CourseRecord{Code: "DER-101", Name: "Derecho Romano", Credits: 4, Grade: 5}, lus.ErrorCourseDuplicated, []interface{}{"DER-101"}},
+		{CourseRecord{Code: "DER-301", Name: "Derecho Mercantil", Credits: 4, Grade: 6}, lus.ErrorInvalidGrade, []interface{}{6, "DER-301", MinGrade, MaxGrade}},
+		{CourseRecord{Code: "DER-301", Name: "Derecho Mercantil", Credits: 0, Grade: 4}, lus.ErrorInvalidCredits, []interface{}{0, "DER-301"}},
+	}
+	for _, test := range invalid {
+		err := n.createTranscript(n.member, id, certificateID, append(testCourses(), test.course))
+		expectError(t, err, test.format, test.args...)
+	}
+
+	if err := n.createTranscript(n.member, id, certificateID, testCourses()); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, n.createTranscript(n.member, "TRSC20221122103012", certificateID, testCourses()), lus.ErrorTranscriptExists, certificateID, id)
+
+	var transcript *Transcript
+	err := n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) (err error) {
+		transcript, err = n.contract.ReadTranscriptByCertificate(ctx, GetRequest{ID: certificateID})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (5*4 + 4*3 + 3*5 + 2*2) / 14 = 3.64
+	if transcript.ID != id || transcript.GPA != 3.64 || transcript.Credits != 12 || transcript.FailedCourses != 1 || transcript.Status != New {
+		t.Errorf("transcript = %+v", transcript)
+	}
+
+	// the transcript is signed through the validation chain of the certificate, by its signatories
+	expectError(t, n.signTranscript(n.dean, id, testDeanID, Dean), lus.ErrorInconsistentValidation)
+	expectError(t, n.signTranscript(n.dean, id, testSecretaryID, Secretary), lus.ErrorSignerIdentity, testSecretaryID)
+	expectError(t, n.signTranscript(n.secretary, id, testSecretaryID, Secretary), lus.ErrorTranscriptSigner, testSecretaryID, certificateID, Secretary)
+	for _, err := range []error{
+		n.sign(n.secretary, certificateID, testSecretaryID, Secretary),
+		n.signTranscript(n.secretary, id, testSecretaryID, Secretary),
+		n.sign(n.dean, certificateID, testDeanID, Dean),
+		n.sign(n.rector, certificateID, testRectorID, Rector),
+		n.signTranscript(n.dean, id, testDeanID, Dean),
+		n.signTranscript(n.rector, id, testRectorID, Rector),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = n.stub.Evaluate(n.member, func(ctx contractapi.TransactionContextInterface) (err error) {
+		transcript, err = n.contract.ReadTranscript(ctx, GetRequest{ID: id})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transcript.Status != Valid || transcript.RectorValidating != "Miriam Nicado" || transcript.StatusLabel != "Válido" {
+		t.Errorf("signed transcript = %+v", transcript)
+	}
+	// the certificate keeps its own chain
+	if asset := n.readAsset(t, certificateID); asset.Status != Valid || asset.SecretaryValidating != "Ana Pérez" {
+		t.Errorf("certificate = %+v", asset)
+	}
+}
@@ -0,0 +1,186 @@
+package certificate
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"academic_certificates/contracts/institution"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// VerifyCertificate returns the publicly disclosable data and the status of a certificate. Any member of
+// the channel can verify a certificate, and the verification contract of other channels through InvokeChaincode.
+func (s *ContractCertificate) VerifyCertificate(ctx contractapi.TransactionContextInterface, request GetRequest) (*Verification, error) {
+	asset, err := getAsset(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newVerification(asset, lus.GetLanguage(ctx)), nil
+}
+
+// newVerification returns the publicly disclosable data of asset, with the status label in lang
+func newVerification(asset *Asset, lang lus.Language) *Verification {
+	var coIssuers []string
+	for _, coIssuer := range asset.CoIssuers {
+		coIssuers = append(coIssuers, coIssuer.Emitter)
+	}
+
+	return &Verification{
+		ID:              asset.ID,
+		Certification:   asset.Certification,
+		GoldCertificate: asset.GoldCertificate,
+		EmitterID:       asset.EmitterID,
+		Emitter:         asset.Emitter,
+		CoIssuers:       coIssuers,
+		Date:            asset.Date,
+		Status:          asset.Status,
+		StatusLabel:     asset.Status.Localize(lang),
+		Valid:           asset.Status == Valid,
+	}
+}
+
+// RecordVerification verifies a certificate like VerifyCertificate and records the verification, with the
+// organization of the client identity and its purpose, in the verification log of the certificate. It is
+// submitted instead of evaluated, and emits the EventCertificateVerified event.
+func (s *ContractCertificate) RecordVerification(ctx contractapi.TransactionContextInterface, request VerificationLogRequest) (*Verification, error) {
+	stub := ctx.GetStub()
+	purpose := strings.TrimSpace(request.Purpose)
+	if purpose == "" {
+		return nil, lus.Errorf(lus.ErrorVerificationPurpose)
+	}
+	asset, err := getAsset(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorClientIdentity, err)
+	}
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := VerificationLogEntry{
+		DocType:       lus.CodVerifyLog,
+		SchemaVersion: SchemaVersion,
+		CertificateID: asset.ID,
+		VerifierMSP:   mspID,
+		Purpose:       purpose,
+		Status:        asset.Status,
+		Valid:         asset.Status == Valid,
+		Audit:         audit,
+	}
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	key, err := stub.CreateCompositeKey(lus.CodVerifyLog, []string{asset.ID, stub.GetTxID()})
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorCompositeKey, err)
+	}
+	err = stub.PutState(key, entryJSON)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	}
+	err = stub.SetEvent(lus.EventCertificateVerified, entryJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return newVerification(asset, lus.GetLanguage(ctx)), nil
+}
+
+// QueryVerificationLog returns the verifications recorded for a certificate, oldest first. The holder,
+// as in CreateGrant, administrators and members of the organizations of the issuers can read it.
+func (s *ContractCertificate) QueryVerificationLog(ctx contractapi.TransactionContextInterface, request GetRequest) ([]*VerificationLogEntry, error) {
+	stub := ctx.GetStub()
+	asset, err := getAsset(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+	err = checkAssetReader(ctx, asset)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(lus.CodVerifyLog, []string{asset.ID})
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	}
+	defer resultsIterator.Close()
+
+	lang := lus.GetLanguage(ctx)
+	entries := make([]*VerificationLogEntry, 0)
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, lus.Errorf(lus.ErrorWorldState, err)
+		}
+		var entry VerificationLogEntry
+		err = json.Unmarshal(result.Value, &entry)
+		if err != nil {
+			return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+		}
+		entry.StatusLabel = entry.Status.Localize(lang)
+		entries = append(entries, &entry)
+	}
+	// the keys are sorted by transaction id
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].CreatedAt < entries[j].CreatedAt
+	})
+
+	return entries, nil
+}
+
+// checkAssetReader returns an error unless the client identity is the holder of asset, an administrator
+// or a member of the organization of one of its issuers, who can read its private data: signatories,
+// registry book entries, transcript and verification log
+func checkAssetReader(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	if _, found, _ := ctx.GetClientIdentity().GetAttributeValue(lus.AttrGraduate); found {
+		return checkHolder(ctx, asset)
+	}
+
+	issuers, err := getIssuers(ctx.GetStub(), asset)
+	if err != nil {
+		return err
+	}
+	for _, issuer := range issuers[1:] {
+		if institution.CheckManager(ctx, issuer) == nil {
+			return nil
+		}
+	}
+	return institution.CheckManager(ctx, issuers[0])
+}
+
+// redactAsset clears the signatories, the registry book entries and the grades of asset unless the client identity
+// can read them, see checkAssetReader. Other organizations read them through a grant, see ReadSharedCertificate.
+func redactAsset(ctx contractapi.TransactionContextInterface, asset *Asset) error {
+	err := checkAssetReader(ctx, asset)
+	if err == nil {
+		return nil
+	} else if lus.AsError(err).Category != lus.CategoryForbidden {
+		return err
+	}
+
+	asset.SecretaryValidating, asset.DeanValidating, asset.RectorValidating = "", "", ""
+	asset.SecretaryID, asset.DeanID, asset.RectorID = "", "", ""
+	for i := range asset.CoIssuers {
+		coIssuer := &asset.CoIssuers[i]
+		coIssuer.SecretaryValidating, coIssuer.DeanValidating, coIssuer.RectorValidating = "", "", ""
+		coIssuer.SecretaryID, coIssuer.DeanID, coIssuer.RectorID = "", "", ""
+	}
+	if asset.HonorsOverride != nil {
+		asset.HonorsOverride.DeanID, asset.HonorsOverride.Dean = "", ""
+	}
+	asset.FacultyVolumeFolio = VolumeFolio{}
+	asset.UniversityVolumeFolio = VolumeFolio{}
+	// the grades come from the transcript
+	asset.GPA, asset.FailedCourses = 0, 0
+
+	return nil
+}
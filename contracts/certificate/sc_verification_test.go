@@ -0,0 +1,80 @@
+package certificate
+
+import (
+	"testing"
+	"time"
+
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestVerificationLog(t *testing.T) {
+	n := newTestNetwork(t)
+	employer := mockstub.MustIdentity("EmployerMSP", "User1@employer.example.com", nil)
+	holder := mockstub.MustIdentity("Org1MSP", "Graduate1@org1.example.com", map[string]string{lus.AttrGraduate: testHolderID})
+	otherHolder := mockstub.MustIdentity("Org1MSP", "Graduate2@org1.example.com", map[string]string{lus.AttrGraduate: testOtherHolderID})
+	id := "CERT20221122103010"
+	n.createAsset(t, newTestAsset(id, 1))
+
+	record := func(purpose string) (*Verification, error) {
+		var verification *Verification
+		err := n.submit(employer, func(ctx contractapi.TransactionContextInterface) (err error) {
+			verification, err = n.contract.RecordVerification(ctx, VerificationLogRequest{ID: id, Purpose: purpose})
+			return err
+		})
+		return verification, err
+	}
+	query := func(identity *mockstub.Identity) ([]*VerificationLogEntry, error) {
+		var entries []*VerificationLogEntry
+		err := n.stub.Evaluate(identity, func(ctx contractapi.TransactionContextInterface) (err error) {
+			entries, err = n.contract.QueryVerificationLog(ctx, GetRequest{ID: id})
+			return err
+		})
+		return entries, err
+	}
+
+	_, err := record(" ")
+	expectError(t, err, lus.ErrorVerificationPurpose)
+	if _, err = record("hiring"); err != nil {
+		t.Fatal(err)
+	}
+	if event := n.stub.LastEvent(); event.EventName != lus.EventCertificateVerified {
+		t.Errorf("event = %s", event.EventName)
+	}
+	for _, err := range []error{n.sign(n.secretary, id, testSecretaryID, Secretary), n.sign(n.dean, id, testDeanID, Dean), n.sign(n.rector, id, testRectorID, Rector)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	n.stub.SetTime(time.Date(2022, 11, 23, 9, 0, 0, 0, time.UTC))
+	verification, err := record("background check")
+	if err != nil {
+		t.Fatal(err)
+	} else if !verification.Valid {
+		t.Errorf("verification = %+v", verification)
+	}
+
+	// the verifier can not read the log, the holder and the issuers can
+	_, err = query(employer)
+	expectError(t, err, lus.ErrorForbiddenMSP, "EmployerMSP", testInstitutionID)
+	_, err = query(otherHolder)
+	expectError(t, err, lus.ErrorNotHolder, testHolderID)
+	for _, identity := range []*mockstub.Identity{holder, n.member, n.admin} {
+		entries, err := query(identity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("entries = %d, want 2", len(entries))
+		}
+		first, last := entries[0], entries[1]
+		if first.Purpose != "hiring" || first.Valid || first.Status != New || first.VerifierMSP != "EmployerMSP" {
+			t.Errorf("first entry = %+v", first)
+		}
+		if last.Purpose != "background check" || !last.Valid || last.CreatedAt != "2022-11-23T09:00:00Z" || last.StatusLabel != Valid.Localize(lus.LangEs) {
+			t.Errorf("last entry = %+v", last)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package certificate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"academic_certificates/contracts/program"
+	lus "academic_certificates/libutils"
+)
+
+// SchemaVersion version of the documents written by this package, stored in their schema_version field when
+// they are written. The other contracts version their documents the same way, with their own SchemaVersion.
+// Increase it with every change of the stored Asset, and register the upgrader of the previous version.
+const SchemaVersion = 3
+
+// assetUpgrader upgrades a stored certificate from its schema version to the next one. It works on the
+// decoded JSON document, so fields can be renamed, retyped or removed.
+type assetUpgrader func(doc map[string]interface{}) error
+
+// assetUpgraders upgraders of the stored certificates, by the version they upgrade from
+var assetUpgraders = make(map[int]assetUpgrader)
+
+// registerAssetUpgrader registers the upgrader of the certificates stored with version from
+func registerAssetUpgrader(from int, upgrader assetUpgrader) {
+	if _, ok := assetUpgraders[from]; ok {
+		panic(fmt.Sprintf("certificate upgrader from version %d already registered", from))
+	}
+	assetUpgraders[from] = upgrader
+}
+
+func init() {
+	registerAssetUpgrader(0, upgradeAssetV0)
+	registerAssetUpgrader(1, upgradeAssetV1)
+	registerAssetUpgrader(2, upgradeAssetV2)
+}
+
+// upgradeAssetV0 upgrades the certificates written before schema versioning, which may hold the date
+// in the Spanish long format and the registry book entries as "volume,folio" strings.
+func upgradeAssetV0(doc map[string]interface{}) error {
+	if date, ok := doc["date"].(string); ok && date != "" {
+		normalized, err := lus.NormalizeDate(date)
+		if err != nil {
+			return err
+		}
+		doc["date"] = normalized
+	}
+	for _, field := range []string{"volume_folio_faculty", "volume_folio_university"} {
+		if value, ok := doc[field].(string); ok {
+			entry, err := ParseVolumeFolio(value)
+			if err != nil {
+				return err
+			}
+			doc[field] = entry
+		}
+	}
+	return nil
+}
+
+// upgradeAssetV1 upgrades the certificates written before the honors rules, the gold certificates get gold honors
+func upgradeAssetV1(doc map[string]interface{}) error {
+	if gold, _ := doc["gold_certificate"].(bool); gold {
+		doc["honors"] = program.Gold
+	}
+	return nil
+}
+
+// upgradeAssetV2 upgrades the certificates that stored the name of a registered graduate, which is only
+// kept in the graduates private data collection
+func upgradeAssetV2(doc map[string]interface{}) error {
+	if holderID, _ := doc["holder_id"].(string); holderID != "" {
+		doc["accredited"] = ""
+	}
+	return nil
+}
+
+// upgradeAsset decodes a stored certificate upgraded to SchemaVersion, and reports if it had an older version
+func upgradeAsset(data []byte) (*Asset, bool, error) {
+	var doc map[string]interface{}
+	err := json.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, false, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+	id, _ := doc["ID"].(string)
+	version := 0
+	if value, ok := doc["schema_version"].(float64); ok {
+		version = int(value)
+	}
+	if version > SchemaVersion {
+		return nil, false, lus.Errorf(lus.ErrorSchemaVersion, id, version, SchemaVersion)
+	}
+
+	upgraded := version < SchemaVersion
+	for ; version < SchemaVersion; version++ {
+		upgrader, ok := assetUpgraders[version]
+		if !ok {
+			return nil, false, lus.Errorf(lus.ErrorSchemaUpgrade, id, version, "no upgrader registered")
+		}
+		err = upgrader(doc)
+		if err != nil {
+			return nil, false, lus.Errorf(lus.ErrorSchemaUpgrade, id, version, err)
+		}
+		doc["schema_version"] = version + 1
+	}
+	if upgraded {
+		data, err = json.Marshal(doc)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	var asset Asset
+	err = json.Unmarshal(data, &asset)
+	if err != nil {
+		return nil, false, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+	return &asset, upgraded, nil
+}
+
+// unmarshalAsset decodes a stored certificate, upgraded to SchemaVersion
+func unmarshalAsset(data []byte) (*Asset, error) {
+	asset, _, err := upgradeAsset(data)
+	return asset, err
+}
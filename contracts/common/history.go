@@ -5,8 +5,16 @@ import (
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
 
+// GetHistory returns the changes of the document with given docType and id, newest first. Only
+// administrators read the history of the privateDocTypes.
 func (cio *ContractCommon) GetHistory(ctx contractapi.TransactionContextInterface, request *lus.GetHistoryRequest) (lus.HistoryQueryResponse, error) {
 	response := lus.HistoryQueryResponse{Response: make([]lus.HistoryAssetPayload, 0)}
+	if privateDocTypes[request.DocType] {
+		err := lus.AssertAdmin(ctx)
+		if err != nil {
+			return response, err
+		}
+	}
 	keyAsset, _, err := lus.CompositeKeyFromID(ctx.GetStub(), request.DocType, request.ID)
 	if err != nil {
 		return response, err
@@ -0,0 +1,65 @@
+package common
+
+import (
+	"testing"
+
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func TestGetHistory(t *testing.T) {
+	stub := newTestStub(t)
+	contract := new(ContractCommon)
+	id := "CERT20221122103000"
+
+	putDocuments(t, stub, map[string]interface{}{"docType": lus.CodCert, "ID": id, "folio": 10})
+	err := stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		key, _, err := lus.CompositeKeyFromID(ctx.GetStub(), lus.CodCert, id)
+		if err != nil {
+			return err
+		}
+		return ctx.GetStub().DelState(key)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stub.Evaluate(member, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := contract.GetHistory(ctx, &lus.GetHistoryRequest{ID: id, DocType: lus.CodCert})
+		return err
+	})
+	if lus.ErrorCode(err) != lus.CodeNotAdmin {
+		t.Errorf("history of a certificate read by a member: %v", err)
+	}
+
+	err = stub.Evaluate(admin, func(ctx contractapi.TransactionContextInterface) error {
+		history, err := contract.GetHistory(ctx, &lus.GetHistoryRequest{ID: id, DocType: lus.CodCert})
+		if err != nil {
+			return err
+		}
+		records := history.Response
+		if len(records) != 3 {
+			t.Fatalf("history has %d records, want 3", len(records))
+		}
+		// newest first, the deletion has an empty asset
+		if len(records[0].Asset) != 0 || records[1].Asset["folio"] != 10.0 || records[2].Asset["folio"] != 0.0 {
+			t.Errorf("history = %+v", records)
+		}
+		if records[0].Time <= records[2].Time || records[0].TxID == records[1].TxID {
+			t.Errorf("history times and transactions = %+v", records)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = stub.Evaluate(member, func(ctx contractapi.TransactionContextInterface) error {
+		_, err := contract.GetHistory(ctx, &lus.GetHistoryRequest{ID: id, DocType: lus.CodInstitution})
+		return err
+	})
+	if err == nil {
+		t.Error("expected an error for an id of another document type")
+	}
+}
@@ -1 +1,34 @@
 package common
+
+import (
+	lus "academic_certificates/libutils"
+)
+
+// ValidatorType role of the officer signing a certificate. It is shared by the
+// certificate contract and the signatory registry.
+type ValidatorType uint
+
+const (
+	NoValidator ValidatorType = iota
+	Secretary                 // faculty secretary, first signature
+	Dean                      // faculty dean, second signature
+	Rector                    // university rector, last signature
+)
+
+// FacultyScoped reports if the role is held per faculty (Secretary, Dean) instead of per institution (Rector)
+func (v ValidatorType) FacultyScoped() bool {
+	return v == Secretary || v == Dean
+}
+
+func (v ValidatorType) String() string {
+	names := []string{"NoValidator", "Secretary", "Dean", "Rector"}
+	if v > Rector {
+		return "unknown"
+	}
+	return names[v]
+}
+
+// Localize returns the name of the role in lang
+func (v ValidatorType) Localize(lang lus.Language) string {
+	return lus.Translate(lang, v.String())
+}
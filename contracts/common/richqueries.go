@@ -2,7 +2,6 @@ package common
 
 import (
 	lus "academic_certificates/libutils"
-	"fmt"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 	jsoniter "github.com/json-iterator/go"
 )
@@ -18,6 +17,7 @@ type ContractCommon struct {
 // Query string matching state database syntax is passed in and executed as is.
 // Supports ad hoc queries that can be defined at runtime by the client.
 // Param Ex: {"selector":{"docType":"","id":"myID"}}
+// Documents of the privateDocTypes are only returned to administrators.
 //
 // Arguments:
 //		0: queryStruct map[string]interface{}
@@ -28,7 +28,11 @@ func (cc *ContractCommon) QueryAssetsBy(ctx contractapi.TransactionContextInterf
 	if err != nil {
 		return nil, err
 	}
-	return lus.GetQueryResultForQueryString(ctx, queryString)
+	records, err := lus.GetQueryResultForQueryString(ctx, queryString)
+	if err != nil {
+		return nil, err
+	}
+	return readableRecords(ctx, records), nil
 }
 
 // QueryAssetsWithPagination uses a query string, page size and a bookmark to perform a query
@@ -39,15 +43,43 @@ func (cc *ContractCommon) QueryAssetsBy(ctx contractapi.TransactionContextInterf
 // Only available on state databases that support rich query (e.g. CouchDB)
 // Paginated queries are only valid for read only transactions.
 // Example: Pagination with Ad hoc Rich Query
+// Documents of the privateDocTypes are only returned to administrators, and not counted for the others.
 func (cc *ContractCommon) QueryAssetsWithPagination(ctx contractapi.TransactionContextInterface, request lus.RichQuerySelector) (*lus.PaginatedQueryResponse, error) {
 	queryString, err := json.MarshalToString(request.QueryString)
 	if err != nil {
 		return nil, err
 	} else if queryString == "" {
-		return nil, fmt.Errorf("missing query string")
+		return nil, lus.Errorf(lus.ErrorMissingQuery)
 	}
 
-	return lus.GetQueryResultForQueryStringWithPagination(ctx, queryString, int32(request.PageSize), request.Bookmark)
+	response, err := lus.GetQueryResultForQueryStringWithPagination(ctx, queryString, int32(request.PageSize), request.Bookmark)
+	if err != nil {
+		return nil, err
+	}
+	response.Records = readableRecords(ctx, response.Records)
+	response.FetchedRecordsCount = int32(len(response.Records))
+	return response, nil
+}
+
+// privateDocTypes documents with private data of the certificates, the certificate contract checks who reads
+// them. The generic queries and the history only return them to administrators.
+var privateDocTypes = map[string]bool{lus.CodCert: true, lus.CodTranscript: true, lus.CodGrant: true, lus.CodVerifyLog: true}
+
+// readableRecords returns the records of a query the client identity can read, all of them for administrators
+func readableRecords(ctx contractapi.TransactionContextInterface, records []interface{}) []interface{} {
+	if lus.AssertAdmin(ctx) == nil {
+		return records
+	}
+	readable := make([]interface{}, 0, len(records))
+	for _, record := range records {
+		if doc, ok := record.(map[string]interface{}); ok {
+			if docType, _ := doc["docType"].(string); privateDocTypes[docType] {
+				continue
+			}
+		}
+		readable = append(readable, record)
+	}
+	return readable
 }
 
 func (cc *ContractCommon) GetEvaluateTransactions() []string {
@@ -0,0 +1,143 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var (
+	member = mockstub.MustIdentity("Org1MSP", "User1@org1.example.com", nil)
+	admin  = mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+)
+
+// putDocuments commits the documents, keyed by the composite key of their docType and ID
+func putDocuments(t *testing.T, stub *mockstub.Stub, docs ...map[string]interface{}) {
+	t.Helper()
+	err := stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		for _, doc := range docs {
+			key, _, err := lus.CompositeKeyFromID(ctx.GetStub(), doc["docType"].(string), doc["ID"].(string))
+			if err != nil {
+				return err
+			}
+			docJSON, err := json.Marshal(doc)
+			if err != nil {
+				return err
+			}
+			err = ctx.GetStub().PutState(key, docJSON)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestStub(t *testing.T) *mockstub.Stub {
+	stub := mockstub.NewStub("mychannel")
+	docs := make([]map[string]interface{}, 0)
+	for i := 0; i < 5; i++ {
+		docs = append(docs, map[string]interface{}{"docType": lus.CodCert, "ID": fmt.Sprintf("CERT2022112210300%d", i), "folio": i})
+	}
+	docs = append(docs, map[string]interface{}{"docType": lus.CodInstitution, "ID": "INST20221122103000", "name": "UH"})
+	putDocuments(t, stub, docs...)
+	return stub
+}
+
+func TestQueryAssetsBy(t *testing.T) {
+	stub := newTestStub(t)
+	contract := new(ContractCommon)
+
+	err := stub.Evaluate(admin, func(ctx contractapi.TransactionContextInterface) error {
+		assets, err := contract.QueryAssetsBy(ctx, map[string]interface{}{
+			"selector": map[string]interface{}{"docType": lus.CodCert, "folio": map[string]interface{}{"$gte": 3}},
+		})
+		if err != nil {
+			return err
+		}
+		if len(assets) != 2 {
+			t.Fatalf("found %d assets, want 2", len(assets))
+		}
+		if id := assets[0].(map[string]interface{})["ID"]; id != "CERT20221122103003" {
+			t.Errorf("first asset = %v, want CERT20221122103003", id)
+		}
+
+		_, err = contract.QueryAssetsBy(ctx, map[string]interface{}{"selector": map[string]interface{}{"folio": map[string]interface{}{"$near": 1}}})
+		if err == nil {
+			t.Error("expected an error for an unsupported operator")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// certificates are only returned to administrators
+	err = stub.Evaluate(member, func(ctx contractapi.TransactionContextInterface) error {
+		assets, err := contract.QueryAssetsBy(ctx, map[string]interface{}{"selector": map[string]interface{}{}})
+		if err != nil {
+			return err
+		}
+		if len(assets) != 1 || assets[0].(map[string]interface{})["docType"] != lus.CodInstitution {
+			t.Errorf("assets read by a member = %v", assets)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQueryAssetsWithPagination(t *testing.T) {
+	stub := newTestStub(t)
+	contract := new(ContractCommon)
+	query := map[string]interface{}{"selector": map[string]interface{}{"docType": lus.CodCert}}
+
+	ids := make([]interface{}, 0)
+	bookmark := ""
+	for page := 0; page < 3; page++ {
+		err := stub.Evaluate(admin, func(ctx contractapi.TransactionContextInterface) error {
+			response, err := contract.QueryAssetsWithPagination(ctx, lus.RichQuerySelector{QueryString: query, PageSize: 2, Bookmark: bookmark})
+			if err != nil {
+				return err
+			}
+			if int(response.FetchedRecordsCount) != len(response.Records) {
+				t.Errorf("fetched records count = %d, records = %d", response.FetchedRecordsCount, len(response.Records))
+			}
+			for _, record := range response.Records {
+				ids = append(ids, record.(map[string]interface{})["ID"])
+			}
+			bookmark = response.Bookmark
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := fmt.Sprint(ids); got != "[CERT20221122103000 CERT20221122103001 CERT20221122103002 CERT20221122103003 CERT20221122103004]" {
+		t.Errorf("pages = %s", got)
+	}
+
+	// certificates are not counted for members
+	err := stub.Evaluate(member, func(ctx contractapi.TransactionContextInterface) error {
+		response, err := contract.QueryAssetsWithPagination(ctx, lus.RichQuerySelector{QueryString: query, PageSize: 2})
+		if err != nil {
+			return err
+		}
+		if len(response.Records) != 0 || response.FetchedRecordsCount != 0 {
+			t.Errorf("page read by a member = %+v", response)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,37 @@
+package graduate
+
+import lus "academic_certificates/libutils"
+
+const SchemaVersion = 1
+
+// TransientIdentity key of the transient map holding a GraduateIdentity
+const TransientIdentity = "graduate"
+
+// TransientIdentityKey key of the transient map holding the secret key of the national id index
+const TransientIdentityKey = "identity_key"
+
+// MinIdentityKeyLength shortest secret key accepted for the national id index
+const MinIdentityKeyLength = 32
+
+// Graduate public record of a certificate holder. The ID is a stable pseudonym, the
+// identity of the person is only stored in the lus.CollectionGraduates private data collection.
+type Graduate struct {
+	DocType       string `json:"docType"`
+	SchemaVersion int    `json:"schema_version" metadata:",optional"`
+	ID            string `json:"ID"`
+	InstitutionID string `json:"institution_id"` // institution that registered the graduate
+	lus.Audit
+}
+
+// GraduateIdentity private identity fields of a graduate
+type GraduateIdentity struct {
+	ID            string `json:"ID" metadata:",optional"`
+	FullName      string `json:"full_name"`
+	NationalID    string `json:"national_id"` // carné de identidad or passport number
+	BirthDate     string `json:"birth_date" metadata:",optional"`
+	SchemaVersion int    `json:"schema_version" metadata:",optional"`
+}
+
+type GetRequest struct {
+	ID string `json:"id"`
+}
@@ -0,0 +1,419 @@
+package graduate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"academic_certificates/contracts/institution"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ContractGraduate provides functions for managing the registry of certificate holders
+type ContractGraduate struct {
+	contractapi.Contract
+}
+
+// CreateGraduate registers a certificate holder. The identity of the person is passed in the
+// transient field "graduate" (GraduateIdentity) so it never reaches the public ledger.
+// Administrators and members of the institution organization can register graduates.
+func (s *ContractGraduate) CreateGraduate(ctx contractapi.TransactionContextInterface, request *Graduate) error {
+	emitter, err := institution.GetInstitution(ctx.GetStub(), request.InstitutionID)
+	if err != nil {
+		return err
+	} else if !emitter.Active {
+		return lus.Errorf(lus.ErrorInactive, emitter.ID)
+	}
+	err = institution.CheckManager(ctx, emitter)
+	if err != nil {
+		return err
+	}
+
+	compositeKey, _, graduateJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodGraduate, request.ID)
+	if err != nil {
+		return err
+	} else if graduateJSON != nil {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, request.ID)
+	}
+
+	identity, err := getTransientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	identity.ID = request.ID
+	err = putIdentity(ctx.GetStub(), compositeKey, identity)
+	if err != nil {
+		return err
+	}
+
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return err
+	}
+
+	graduate := Graduate{
+		DocType:       lus.CodGraduate,
+		ID:            request.ID,
+		InstitutionID: emitter.ID,
+		Audit:         audit,
+	}
+
+	return PutGraduate(ctx.GetStub(), &graduate)
+}
+
+// ReadGraduate returns the public record of the graduate with given id.
+func (s *ContractGraduate) ReadGraduate(ctx contractapi.TransactionContextInterface, request GetRequest) (*Graduate, error) {
+	return GetGraduate(ctx.GetStub(), request.ID)
+}
+
+// ReadGraduateIdentity returns the identity of the graduate with given id. Only available on peers
+// of the organizations in the graduates collection, for members of the registering institution.
+func (s *ContractGraduate) ReadGraduateIdentity(ctx contractapi.TransactionContextInterface, request GetRequest) (*GraduateIdentity, error) {
+	graduate, _, err := s.manageGraduate(ctx, request.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetIdentity(ctx.GetStub(), graduate.ID)
+}
+
+// UpdateGraduateIdentity replaces the identity of the graduate with given id with the one
+// passed in the transient field "graduate".
+func (s *ContractGraduate) UpdateGraduateIdentity(ctx contractapi.TransactionContextInterface, request GetRequest) error {
+	graduate, compositeKey, err := s.manageGraduate(ctx, request.ID)
+	if err != nil {
+		return err
+	}
+
+	identity, err := getTransientIdentity(ctx)
+	if err != nil {
+		return err
+	}
+	identity.ID = graduate.ID
+
+	// drop the index entry of the previous national id
+	storedJSON, err := ctx.GetStub().GetPrivateData(lus.CollectionGraduates, compositeKey)
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	} else if storedJSON != nil {
+		var stored GraduateIdentity
+		err = json.Unmarshal(storedJSON, &stored)
+		if err != nil {
+			return lus.Errorf(lus.ErrorUnmarshal, err)
+		}
+		secret, err := getIdentityKey(ctx.GetStub())
+		if err != nil {
+			return err
+		}
+		indexKey, err := identityIndexKey(ctx.GetStub(), secret, stored.NationalID)
+		if err != nil {
+			return err
+		}
+		err = ctx.GetStub().DelPrivateData(lus.CollectionGraduates, indexKey)
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+	err = putIdentity(ctx.GetStub(), compositeKey, identity)
+	if err != nil {
+		return err
+	}
+
+	err = graduate.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	return PutGraduate(ctx.GetStub(), graduate)
+}
+
+// FindGraduate returns the public record of the graduate with the national id passed in the
+// transient field "graduate". Only available on peers of the organizations in the graduates collection.
+func (s *ContractGraduate) FindGraduate(ctx contractapi.TransactionContextInterface) (*Graduate, error) {
+	identity, err := readTransientIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := getIdentityKey(ctx.GetStub())
+	if err != nil {
+		return nil, err
+	}
+	indexKey, err := identityIndexKey(ctx.GetStub(), secret, identity.NationalID)
+	if err != nil {
+		return nil, err
+	}
+	graduateID, err := ctx.GetStub().GetPrivateData(lus.CollectionGraduates, indexKey)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	} else if graduateID == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, TransientIdentity)
+	}
+
+	return GetGraduate(ctx.GetStub(), string(graduateID))
+}
+
+// SetIdentityKey sets the secret key of the national id index, passed in the transient field "identity_key",
+// and indexes the registered graduates again with it. Only administrators can set it, on peers of the
+// graduates collection; the key never leaves the collection.
+func (s *ContractGraduate) SetIdentityKey(ctx contractapi.TransactionContextInterface) error {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	stub := ctx.GetStub()
+	transientMap, err := stub.GetTransient()
+	if err != nil {
+		return err
+	}
+	secret, ok := transientMap[TransientIdentityKey]
+	if !ok {
+		return lus.Errorf(lus.ErrorTransientMissing, TransientIdentityKey)
+	} else if len(secret) < MinIdentityKeyLength {
+		return lus.Errorf(lus.ErrorIdentityKey, MinIdentityKeyLength)
+	}
+
+	secretKey, err := stub.CreateCompositeKey(lus.CodIdentityKey, []string{})
+	if err != nil {
+		return lus.Errorf(lus.ErrorCompositeKey, lus.CodIdentityKey)
+	}
+	err = stub.PutPrivateData(lus.CollectionGraduates, secretKey, secret)
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	}
+
+	// drop the entries keyed with the previous key, or hashed without any by older versions
+	entries, err := stub.GetPrivateDataByPartialCompositeKey(lus.CollectionGraduates, lus.CodIdentity, []string{})
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	}
+	defer entries.Close()
+	for entries.HasNext() {
+		entry, err := entries.Next()
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+		err = stub.DelPrivateData(lus.CollectionGraduates, entry.Key)
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+
+	identities, err := stub.GetPrivateDataByPartialCompositeKey(lus.CollectionGraduates, lus.CodGraduate, []string{})
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	}
+	defer identities.Close()
+	for identities.HasNext() {
+		record, err := identities.Next()
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+		var identity GraduateIdentity
+		err = json.Unmarshal(record.Value, &identity)
+		if err != nil {
+			return lus.Errorf(lus.ErrorUnmarshal, err)
+		}
+		indexKey, err := identityIndexKey(stub, secret, identity.NationalID)
+		if err != nil {
+			return err
+		}
+		err = stub.PutPrivateData(lus.CollectionGraduates, indexKey, []byte(identity.ID))
+		if err != nil {
+			return lus.Errorf(lus.ErrorWorldState, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *ContractGraduate) GetEvaluateTransactions() []string {
+	return []string{"ReadGraduate", "ReadGraduateIdentity", "FindGraduate"}
+}
+
+// manageGraduate returns the graduate with given id, and its key, if the client identity
+// is allowed to manage it.
+func (s *ContractGraduate) manageGraduate(ctx contractapi.TransactionContextInterface, id string) (*Graduate, string, error) {
+	compositeKey, _, err := lus.CompositeKeyFromID(ctx.GetStub(), lus.CodGraduate, id)
+	if err != nil {
+		return nil, "", err
+	}
+	graduate, err := GetGraduate(ctx.GetStub(), id)
+	if err != nil {
+		return nil, "", err
+	}
+	emitter, err := institution.GetInstitution(ctx.GetStub(), graduate.InstitutionID)
+	if err != nil {
+		return nil, "", err
+	}
+	err = institution.CheckManager(ctx, emitter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return graduate, compositeKey, nil
+}
+
+// readTransientIdentity returns the identity passed in the transient map as is
+func readTransientIdentity(ctx contractapi.TransactionContextInterface) (*GraduateIdentity, error) {
+	transientMap, err := ctx.GetStub().GetTransient()
+	if err != nil {
+		return nil, err
+	}
+	identityJSON, ok := transientMap[TransientIdentity]
+	if !ok {
+		return nil, lus.Errorf(lus.ErrorTransientMissing, TransientIdentity)
+	}
+
+	var identity GraduateIdentity
+	err = json.Unmarshal(identityJSON, &identity)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &identity, nil
+}
+
+// getTransientIdentity returns the identity passed in the transient map, normalized and validated
+func getTransientIdentity(ctx contractapi.TransactionContextInterface) (*GraduateIdentity, error) {
+	identity, err := readTransientIdentity(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identity.FullName = strings.TrimSpace(identity.FullName)
+	identity.NationalID = normalizeNationalID(identity.NationalID)
+	if identity.FullName == "" || identity.NationalID == "" {
+		return nil, lus.Errorf(lus.ErrorIdentityIncomplete)
+	}
+	if identity.BirthDate != "" {
+		identity.BirthDate, err = lus.NormalizeDate(identity.BirthDate)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return identity, nil
+}
+
+// putIdentity writes the identity, and its entry in the national id index, to the private data
+// collection. A national id can only be registered once.
+func putIdentity(stub shim.ChaincodeStubInterface, compositeKey string, identity *GraduateIdentity) error {
+	secret, err := getIdentityKey(stub)
+	if err != nil {
+		return err
+	}
+	indexKey, err := identityIndexKey(stub, secret, identity.NationalID)
+	if err != nil {
+		return err
+	}
+	registered, err := stub.GetPrivateData(lus.CollectionGraduates, indexKey)
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	} else if registered != nil && string(registered) != identity.ID {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, TransientIdentity)
+	}
+
+	identity.SchemaVersion = SchemaVersion
+	identityJSON, err := json.Marshal(identity)
+	if err != nil {
+		return err
+	}
+	err = stub.PutPrivateData(lus.CollectionGraduates, indexKey, []byte(identity.ID))
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	}
+
+	return stub.PutPrivateData(lus.CollectionGraduates, compositeKey, identityJSON)
+}
+
+// normalizeNationalID removes separators and letter case from a national id or passport number
+func normalizeNationalID(nationalID string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "", ".", "").Replace(nationalID))
+}
+
+// identityIndexKey key of a national id in the private index. The id is replaced by its HMAC with the
+// secret identity key, only held by the graduates collection, so the hashes of the private data written
+// to the public ledger can not be matched against the national ids by brute force.
+func identityIndexKey(stub shim.ChaincodeStubInterface, secret []byte, nationalID string) (string, error) {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(normalizeNationalID(nationalID)))
+	return stub.CreateCompositeKey(lus.CodIdentity, []string{hex.EncodeToString(mac.Sum(nil))})
+}
+
+// getIdentityKey returns the secret key of the national id index, set with SetIdentityKey
+func getIdentityKey(stub shim.ChaincodeStubInterface) ([]byte, error) {
+	secretKey, err := stub.CreateCompositeKey(lus.CodIdentityKey, []string{})
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorCompositeKey, lus.CodIdentityKey)
+	}
+	secret, err := stub.GetPrivateData(lus.CollectionGraduates, secretKey)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	} else if secret == nil {
+		return nil, lus.Errorf(lus.ErrorIdentityKeyMissing)
+	}
+
+	return secret, nil
+}
+
+// GetGraduate returns the public record of the graduate with given id.
+func GetGraduate(stub shim.ChaincodeStubInterface, id string) (*Graduate, error) {
+	_, _, graduateJSON, err := lus.ExistsAssetFromId(stub, lus.CodGraduate, id)
+	if err != nil {
+		return nil, err
+	} else if graduateJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+
+	var graduate Graduate
+	err = json.Unmarshal(graduateJSON, &graduate)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &graduate, nil
+}
+
+// GetIdentity returns the identity of the graduate with given id, from the private data collection.
+// Callers must check the client identity is allowed to read it.
+func GetIdentity(stub shim.ChaincodeStubInterface, id string) (*GraduateIdentity, error) {
+	compositeKey, _, err := lus.CompositeKeyFromID(stub, lus.CodGraduate, id)
+	if err != nil {
+		return nil, err
+	}
+	identityJSON, err := stub.GetPrivateData(lus.CollectionGraduates, compositeKey)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	} else if identityJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+
+	var identity GraduateIdentity
+	err = json.Unmarshal(identityJSON, &identity)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &identity, nil
+}
+
+// PutGraduate writes the public record of the graduate to the world state
+func PutGraduate(stub shim.ChaincodeStubInterface, graduate *Graduate) error {
+	compositeKey, _, err := lus.CompositeKeyFromID(stub, lus.CodGraduate, graduate.ID)
+	if err != nil {
+		return err
+	}
+	graduate.SchemaVersion = SchemaVersion
+	graduateJSON, err := json.Marshal(graduate)
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(compositeKey, graduateJSON)
+}
@@ -0,0 +1,265 @@
+package graduate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"academic_certificates/contracts/institution"
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	testInstitutionID = "INST20221122103000"
+	testGraduateID    = "GRAD20221122103005"
+	testIdentityKey   = "0123456789abcdef0123456789abcdef"
+	testIdentity      = `{"full_name":"Juan Pérez","national_id":"85010112345"}`
+)
+
+var (
+	admin    = mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+	member   = mockstub.MustIdentity("Org1MSP", "User1@org1.example.com", nil)
+	outsider = mockstub.MustIdentity("Org2MSP", "User1@org2.example.com", nil)
+)
+
+type testNetwork struct {
+	stub     *mockstub.Stub
+	contract *ContractGraduate
+}
+
+// newTestNetwork returns a network with an active institution of Org1MSP
+func newTestNetwork(t *testing.T) *testNetwork {
+	t.Helper()
+	n := &testNetwork{stub: mockstub.NewStub("mychannel"), contract: new(ContractGraduate)}
+	n.stub.SetTime(time.Date(2022, 11, 22, 10, 30, 0, 0, time.UTC))
+
+	err := n.stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return institution.PutInstitution(ctx.GetStub(), &institution.Institution{DocType: lus.CodInstitution, ID: testInstitutionID, Name: "Universidad de La Habana", MSPID: "Org1MSP", Active: true})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func (n *testNetwork) setIdentityKey(identity *mockstub.Identity, key string) error {
+	n.stub.SetTransient(TransientIdentityKey, []byte(key))
+	return n.stub.Submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.SetIdentityKey(ctx)
+	})
+}
+
+func (n *testNetwork) createGraduate(identity *mockstub.Identity, id, identityJSON string) error {
+	n.stub.SetTransient(TransientIdentity, []byte(identityJSON))
+	return n.stub.Submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateGraduate(ctx, &Graduate{ID: id, InstitutionID: testInstitutionID})
+	})
+}
+
+func (n *testNetwork) updateIdentity(identity *mockstub.Identity, id, identityJSON string) error {
+	n.stub.SetTransient(TransientIdentity, []byte(identityJSON))
+	return n.stub.Submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateGraduateIdentity(ctx, GetRequest{ID: id})
+	})
+}
+
+func (n *testNetwork) findGraduate(identityJSON string) (found *Graduate, err error) {
+	n.stub.SetTransient(TransientIdentity, []byte(identityJSON))
+	err = n.stub.Evaluate(member, func(ctx contractapi.TransactionContextInterface) error {
+		found, err = n.contract.FindGraduate(ctx)
+		return err
+	})
+	return found, err
+}
+
+// indexEntries returns the keys of the committed entries of the national id index
+func (n *testNetwork) indexEntries(t *testing.T) []string {
+	t.Helper()
+	var keys []string
+	err := n.stub.Evaluate(admin, func(ctx contractapi.TransactionContextInterface) error {
+		entries, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(lus.CollectionGraduates, lus.CodIdentity, []string{})
+		if err != nil {
+			return err
+		}
+		defer entries.Close()
+		for entries.HasNext() {
+			entry, err := entries.Next()
+			if err != nil {
+				return err
+			}
+			keys = append(keys, entry.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return keys
+}
+
+func expectError(t *testing.T, err error, format string, args ...interface{}) {
+	t.Helper()
+	want := fmt.Sprintf(format, args...)
+	if err == nil {
+		t.Fatalf("expected error %q, got nil", want)
+	} else if !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error %q, got %q", want, err)
+	}
+}
+
+func TestIdentityKey(t *testing.T) {
+	n := newTestNetwork(t)
+
+	// graduates can not be registered before the key is set
+	expectError(t, n.createGraduate(member, testGraduateID, testIdentity), lus.ErrorIdentityKeyMissing)
+	expectError(t, n.setIdentityKey(member, testIdentityKey), lus.ErrorNotAdmin)
+	expectError(t, n.setIdentityKey(admin, "short"), lus.ErrorIdentityKey, MinIdentityKeyLength)
+	if err := n.setIdentityKey(admin, testIdentityKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.createGraduate(member, testGraduateID, testIdentity); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := n.findGraduate(`{"national_id":"8501-0112345"}`)
+	if err != nil {
+		t.Fatal(err)
+	} else if found.ID != testGraduateID {
+		t.Errorf("found %s, want %s", found.ID, testGraduateID)
+	}
+
+	// the index key can not be computed from the national id alone
+	hash := sha256.Sum256([]byte("85010112345"))
+	entries := n.indexEntries(t)
+	if len(entries) != 1 || strings.Contains(entries[0], hex.EncodeToString(hash[:])) {
+		t.Fatalf("index entries = %q, want one keyed with the secret key", entries)
+	}
+
+	// a new key indexes the graduates again
+	if err := n.setIdentityKey(admin, strings.Repeat("k", MinIdentityKeyLength)); err != nil {
+		t.Fatal(err)
+	}
+	if rekeyed := n.indexEntries(t); len(rekeyed) != 1 || rekeyed[0] == entries[0] {
+		t.Fatalf("index entries after the new key = %q", rekeyed)
+	}
+	found, err = n.findGraduate(`{"national_id":"85010112345"}`)
+	if err != nil {
+		t.Fatal(err)
+	} else if found.ID != testGraduateID {
+		t.Errorf("found %s after the new key, want %s", found.ID, testGraduateID)
+	}
+}
+
+func TestGetGraduateMalformed(t *testing.T) {
+	n := newTestNetwork(t)
+	err := n.stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+		key, _, err := lus.CompositeKeyFromID(ctx.GetStub(), lus.CodGraduate, testGraduateID)
+		if err != nil {
+			return err
+		}
+		return ctx.GetStub().PutState(key, []byte(`{"ID":1}`))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = GetGraduate(n.stub, testGraduateID)
+	if code := lus.ErrorCode(err); code != lus.CodeInvalidJSON {
+		t.Errorf("error code = %s, want %s: %v", code, lus.CodeInvalidJSON, err)
+	}
+}
+
+func TestCreateGraduate(t *testing.T) {
+	n := newTestNetwork(t)
+	if err := n.setIdentityKey(admin, testIdentityKey); err != nil {
+		t.Fatal(err)
+	}
+	inactiveID := "INST20221122103001"
+	err := n.stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return institution.PutInstitution(ctx.GetStub(), &institution.Institution{DocType: lus.CodInstitution, ID: inactiveID, Name: "Universidad de Oriente", MSPID: "Org1MSP"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	createIn := func(institutionID string) error {
+		n.stub.SetTransient(TransientIdentity, []byte(testIdentity))
+		return n.stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.CreateGraduate(ctx, &Graduate{ID: testGraduateID, InstitutionID: institutionID})
+		})
+	}
+
+	expectError(t, createIn("INST20000101000000"), lus.ErrorNotExistInState, "INST20000101000000")
+	expectError(t, createIn(inactiveID), lus.ErrorInactive, inactiveID)
+	expectError(t, n.createGraduate(outsider, testGraduateID, testIdentity), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	err = n.stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateGraduate(ctx, &Graduate{ID: testGraduateID, InstitutionID: testInstitutionID})
+	})
+	expectError(t, err, lus.ErrorTransientMissing, TransientIdentity)
+	expectError(t, n.createGraduate(member, testGraduateID, `{"full_name":"  ","national_id":"85010112345"}`), lus.ErrorIdentityIncomplete)
+	expectError(t, n.createGraduate(member, testGraduateID, `{"full_name":"Juan Pérez","national_id":" - "}`), lus.ErrorIdentityIncomplete)
+	expectError(t, n.createGraduate(member, testGraduateID, `{"full_name":"Juan Pérez","national_id":"85010112345","birth_date":"1985/01/01"}`), lus.ErrorInvalidDate, "1985/01/01")
+	if err := n.createGraduate(member, testGraduateID, testIdentity); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, n.createGraduate(member, testGraduateID, `{"full_name":"Ana Díaz","national_id":"90020254321"}`), lus.ErrorAlreadyExistInState, testGraduateID)
+	expectError(t, n.createGraduate(admin, "GRAD20221122103006", `{"full_name":"Juan Pérez","national_id":"8501 0112 345"}`), lus.ErrorAlreadyExistInState, TransientIdentity)
+
+	graduate, err := GetGraduate(n.stub, testGraduateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graduate.InstitutionID != testInstitutionID || graduate.CreatedBy == "" {
+		t.Errorf("graduate = %+v", graduate)
+	}
+}
+
+func TestUpdateGraduateIdentity(t *testing.T) {
+	n := newTestNetwork(t)
+	if err := n.setIdentityKey(admin, testIdentityKey); err != nil {
+		t.Fatal(err)
+	}
+	otherID := "GRAD20221122103006"
+	if err := n.createGraduate(member, testGraduateID, testIdentity); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.createGraduate(member, otherID, `{"full_name":"Ana Díaz","national_id":"90020254321"}`); err != nil {
+		t.Fatal(err)
+	}
+	updated := `{"full_name":"Juan Pérez García","national_id":"85010112346","birth_date":"1985-01-01"}`
+
+	expectError(t, n.updateIdentity(member, "GRAD20000101000000", updated), lus.ErrorNotExistInState, "GRAD20000101000000")
+	expectError(t, n.updateIdentity(outsider, testGraduateID, updated), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	err := n.stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateGraduateIdentity(ctx, GetRequest{ID: testGraduateID})
+	})
+	expectError(t, err, lus.ErrorTransientMissing, TransientIdentity)
+	expectError(t, n.updateIdentity(member, testGraduateID, `{"full_name":"Juan Pérez"}`), lus.ErrorIdentityIncomplete)
+	expectError(t, n.updateIdentity(member, testGraduateID, `{"full_name":"Juan Pérez","national_id":"90020254321"}`), lus.ErrorAlreadyExistInState, TransientIdentity)
+	if err := n.updateIdentity(member, testGraduateID, updated); err != nil {
+		t.Fatal(err)
+	}
+
+	// the previous national id is released
+	if _, err := n.findGraduate(testIdentity); lus.ErrorCode(err) != lus.CodeNotFound {
+		t.Errorf("find by the previous national id: %v", err)
+	}
+	found, err := n.findGraduate(updated)
+	if err != nil {
+		t.Fatal(err)
+	} else if found.ID != testGraduateID {
+		t.Errorf("found %s, want %s", found.ID, testGraduateID)
+	}
+	identity, err := GetIdentity(n.stub, testGraduateID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.FullName != "Juan Pérez García" || identity.BirthDate != "1985-01-01" {
+		t.Errorf("identity = %+v", identity)
+	}
+}
@@ -0,0 +1,39 @@
+package institution
+
+import lus "academic_certificates/libutils"
+
+const SchemaVersion = 1
+
+// Institution describes a university allowed to emit certificates
+type Institution struct {
+	DocType       string `json:"docType"`
+	SchemaVersion int    `json:"schema_version" metadata:",optional"`
+	ID            string `json:"ID"`
+	Name          string `json:"name"`
+	Acronym       string `json:"acronym"`
+	MSPID         string `json:"msp_id"` // organization of the institution in the network
+	Seal          string `json:"seal"`   // official seal, base64 encoded
+	Active        bool   `json:"active"`
+	lus.Audit
+}
+
+// Faculty describes a faculty of an institution
+type Faculty struct {
+	DocType       string `json:"docType"`
+	SchemaVersion int    `json:"schema_version" metadata:",optional"`
+	ID            string `json:"ID"`
+	InstitutionID string `json:"institution_id"`
+	Name          string `json:"name"`
+	Seal          string `json:"seal"` // official seal, base64 encoded
+	Active        bool   `json:"active"`
+	lus.Audit
+}
+
+type GetRequest struct {
+	ID string `json:"id"`
+}
+
+type SetActiveRequest struct {
+	ID     string `json:"id"`
+	Active bool   `json:"active"`
+}
@@ -0,0 +1,341 @@
+package institution
+
+import (
+	"encoding/json"
+
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ContractInstitution provides functions for managing the registry of institutions and faculties
+type ContractInstitution struct {
+	contractapi.Contract
+}
+
+// CreateInstitution registers a new institution. Only administrators can register institutions.
+func (s *ContractInstitution) CreateInstitution(ctx contractapi.TransactionContextInterface, request *Institution) error {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the peers of the institution endorse its certificates
+	if request.MSPID == "" {
+		return lus.Errorf(lus.ErrorMissingMSP, request.ID)
+	}
+
+	_, _, instJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodInstitution, request.ID)
+	if err != nil {
+		return err
+	} else if instJSON != nil {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, request.ID)
+	}
+
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return err
+	}
+
+	institution := Institution{
+		DocType: lus.CodInstitution,
+		ID:      request.ID,
+		Name:    request.Name,
+		Acronym: request.Acronym,
+		MSPID:   request.MSPID,
+		Seal:    request.Seal,
+		Active:  true,
+		Audit:   audit,
+	}
+
+	return PutInstitution(ctx.GetStub(), &institution)
+}
+
+// ReadInstitution returns the institution stored in the world state with given id.
+func (s *ContractInstitution) ReadInstitution(ctx contractapi.TransactionContextInterface, request GetRequest) (*Institution, error) {
+	return GetInstitution(ctx.GetStub(), request.ID)
+}
+
+// UpdateInstitution updates the name, acronym, MSP and seal of an existing institution.
+// Only administrators can update institutions, which sets the MSP of the ones registered without it.
+func (s *ContractInstitution) UpdateInstitution(ctx contractapi.TransactionContextInterface, request *Institution) error {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	} else if request.MSPID == "" {
+		return lus.Errorf(lus.ErrorMissingMSP, request.ID)
+	}
+
+	institution, err := GetInstitution(ctx.GetStub(), request.ID)
+	if err != nil {
+		return err
+	}
+	err = institution.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	institution.Name = request.Name
+	institution.Acronym = request.Acronym
+	institution.MSPID = request.MSPID
+	institution.Seal = request.Seal
+
+	return PutInstitution(ctx.GetStub(), institution)
+}
+
+// SetInstitutionActive activates or deactivates an institution. Inactive institutions can not emit certificates.
+func (s *ContractInstitution) SetInstitutionActive(ctx contractapi.TransactionContextInterface, request SetActiveRequest) error {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+
+	institution, err := GetInstitution(ctx.GetStub(), request.ID)
+	if err != nil {
+		return err
+	}
+	err = institution.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+	institution.Active = request.Active
+
+	return PutInstitution(ctx.GetStub(), institution)
+}
+
+// CreateFaculty registers a new faculty of an active institution.
+// Administrators and members of the institution organization can register faculties.
+func (s *ContractInstitution) CreateFaculty(ctx contractapi.TransactionContextInterface, request *Faculty) error {
+	institution, err := GetInstitution(ctx.GetStub(), request.InstitutionID)
+	if err != nil {
+		return err
+	} else if !institution.Active {
+		return lus.Errorf(lus.ErrorInactive, institution.ID)
+	}
+	err = CheckManager(ctx, institution)
+	if err != nil {
+		return err
+	}
+
+	_, _, facultyJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodFaculty, request.ID)
+	if err != nil {
+		return err
+	} else if facultyJSON != nil {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, request.ID)
+	}
+
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return err
+	}
+
+	faculty := Faculty{
+		DocType:       lus.CodFaculty,
+		ID:            request.ID,
+		InstitutionID: institution.ID,
+		Name:          request.Name,
+		Seal:          request.Seal,
+		Active:        true,
+		Audit:         audit,
+	}
+
+	return PutFaculty(ctx.GetStub(), &faculty)
+}
+
+// ReadFaculty returns the faculty stored in the world state with given id.
+func (s *ContractInstitution) ReadFaculty(ctx contractapi.TransactionContextInterface, request GetRequest) (*Faculty, error) {
+	return GetFaculty(ctx.GetStub(), request.ID)
+}
+
+// UpdateFaculty updates the name and seal of an existing faculty. A faculty can not be moved to another institution.
+func (s *ContractInstitution) UpdateFaculty(ctx contractapi.TransactionContextInterface, request *Faculty) error {
+	faculty, err := s.manageFaculty(ctx, request.ID)
+	if err != nil {
+		return err
+	}
+
+	faculty.Name = request.Name
+	faculty.Seal = request.Seal
+
+	return PutFaculty(ctx.GetStub(), faculty)
+}
+
+// SetFacultyActive activates or deactivates a faculty. Inactive faculties can not emit certificates.
+func (s *ContractInstitution) SetFacultyActive(ctx contractapi.TransactionContextInterface, request SetActiveRequest) error {
+	faculty, err := s.manageFaculty(ctx, request.ID)
+	if err != nil {
+		return err
+	}
+	faculty.Active = request.Active
+
+	return PutFaculty(ctx.GetStub(), faculty)
+}
+
+// QueryFacultiesByInstitution returns the faculties of the institution with given id.
+// Only available on state databases that support rich query (e.g. CouchDB)
+func (s *ContractInstitution) QueryFacultiesByInstitution(ctx contractapi.TransactionContextInterface, request GetRequest) ([]*Faculty, error) {
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType":        lus.CodFaculty,
+			"institution_id": request.ID,
+		},
+	}
+	queryString, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryString))
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	faculties := make([]*Faculty, 0)
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var faculty Faculty
+		err = json.Unmarshal(queryResult.Value, &faculty)
+		if err != nil {
+			return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+		}
+		faculties = append(faculties, &faculty)
+	}
+
+	return faculties, nil
+}
+
+func (s *ContractInstitution) GetEvaluateTransactions() []string {
+	return []string{"ReadInstitution", "ReadFaculty", "QueryFacultiesByInstitution"}
+}
+
+// manageFaculty returns the faculty with given id, stamped for modification,
+// if the client identity is allowed to manage it.
+func (s *ContractInstitution) manageFaculty(ctx contractapi.TransactionContextInterface, id string) (*Faculty, error) {
+	faculty, err := GetFaculty(ctx.GetStub(), id)
+	if err != nil {
+		return nil, err
+	}
+	institution, err := GetInstitution(ctx.GetStub(), faculty.InstitutionID)
+	if err != nil {
+		return nil, err
+	}
+	err = CheckManager(ctx, institution)
+	if err != nil {
+		return nil, err
+	}
+	err = faculty.Audit.Stamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return faculty, nil
+}
+
+// CheckManager returns an error unless the client identity is an administrator
+// or a member of the institution organization
+func CheckManager(ctx contractapi.TransactionContextInterface, institution *Institution) error {
+	if lus.AssertAdmin(ctx) == nil {
+		return nil
+	}
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return lus.Errorf(lus.ErrorClientIdentity, err)
+	}
+	if mspID != institution.MSPID {
+		return lus.Errorf(lus.ErrorForbiddenMSP, mspID, institution.ID)
+	}
+	return nil
+}
+
+// GetInstitution returns the institution stored in the world state with given id.
+func GetInstitution(stub shim.ChaincodeStubInterface, id string) (*Institution, error) {
+	_, _, instJSON, err := lus.ExistsAssetFromId(stub, lus.CodInstitution, id)
+	if err != nil {
+		return nil, err
+	} else if instJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+
+	var institution Institution
+	err = json.Unmarshal(instJSON, &institution)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &institution, nil
+}
+
+// GetFaculty returns the faculty stored in the world state with given id.
+func GetFaculty(stub shim.ChaincodeStubInterface, id string) (*Faculty, error) {
+	_, _, facultyJSON, err := lus.ExistsAssetFromId(stub, lus.CodFaculty, id)
+	if err != nil {
+		return nil, err
+	} else if facultyJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+
+	var faculty Faculty
+	err = json.Unmarshal(facultyJSON, &faculty)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &faculty, nil
+}
+
+// GetActiveFaculty returns the institution and faculty with given ids if both are
+// registered and active, and the faculty belongs to the institution.
+func GetActiveFaculty(stub shim.ChaincodeStubInterface, institutionID, facultyID string) (*Institution, *Faculty, error) {
+	institution, err := GetInstitution(stub, institutionID)
+	if err != nil {
+		return nil, nil, err
+	} else if !institution.Active {
+		return nil, nil, lus.Errorf(lus.ErrorInactive, institution.ID)
+	}
+
+	faculty, err := GetFaculty(stub, facultyID)
+	if err != nil {
+		return nil, nil, err
+	} else if faculty.InstitutionID != institution.ID {
+		return nil, nil, lus.Errorf(lus.ErrorFacultyInstitution, faculty.ID, institution.ID)
+	} else if !faculty.Active {
+		return nil, nil, lus.Errorf(lus.ErrorInactive, faculty.ID)
+	}
+
+	return institution, faculty, nil
+}
+
+// PutInstitution writes the institution to the world state
+func PutInstitution(stub shim.ChaincodeStubInterface, institution *Institution) error {
+	compositeKey, _, err := lus.CompositeKeyFromID(stub, lus.CodInstitution, institution.ID)
+	if err != nil {
+		return err
+	}
+	institution.SchemaVersion = SchemaVersion
+	instJSON, err := json.Marshal(institution)
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(compositeKey, instJSON)
+}
+
+// PutFaculty writes the faculty to the world state
+func PutFaculty(stub shim.ChaincodeStubInterface, faculty *Faculty) error {
+	compositeKey, _, err := lus.CompositeKeyFromID(stub, lus.CodFaculty, faculty.ID)
+	if err != nil {
+		return err
+	}
+	faculty.SchemaVersion = SchemaVersion
+	facultyJSON, err := json.Marshal(faculty)
+	if err != nil {
+		return err
+	}
+
+	return stub.PutState(compositeKey, facultyJSON)
+}
@@ -0,0 +1,208 @@
+package institution
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	testInstitutionID = "INST20221122103000"
+	testFacultyID     = "FACU20221122103001"
+)
+
+var (
+	admin    = mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+	member   = mockstub.MustIdentity("Org1MSP", "User1@org1.example.com", nil)
+	outsider = mockstub.MustIdentity("Org2MSP", "User1@org2.example.com", nil)
+)
+
+type testNetwork struct {
+	stub     *mockstub.Stub
+	contract *ContractInstitution
+}
+
+// newTestNetwork returns a network with an active institution of Org1MSP and one of its faculties
+func newTestNetwork(t *testing.T) *testNetwork {
+	t.Helper()
+	n := &testNetwork{stub: mockstub.NewStub("mychannel"), contract: new(ContractInstitution)}
+	n.stub.SetTime(time.Date(2022, 11, 22, 10, 30, 0, 0, time.UTC))
+
+	err := n.submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateInstitution(ctx, &Institution{ID: testInstitutionID, Name: "Universidad de La Habana", Acronym: "UH", MSPID: "Org1MSP"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = n.submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateFaculty(ctx, &Faculty{ID: testFacultyID, InstitutionID: testInstitutionID, Name: "Facultad de Derecho"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func (n *testNetwork) submit(identity *mockstub.Identity, fn mockstub.TxFunc) error {
+	return n.stub.Submit(identity, fn)
+}
+
+func (n *testNetwork) setActive(identity *mockstub.Identity, setActive func(contractapi.TransactionContextInterface, SetActiveRequest) error, id string, active bool) error {
+	return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return setActive(ctx, SetActiveRequest{ID: id, Active: active})
+	})
+}
+
+func expectError(t *testing.T, err error, format string, args ...interface{}) {
+	t.Helper()
+	want := fmt.Sprintf(format, args...)
+	if err == nil {
+		t.Fatalf("expected error %q, got nil", want)
+	} else if !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error %q, got %q", want, err)
+	}
+}
+
+func TestCreateInstitution(t *testing.T) {
+	n := newTestNetwork(t)
+	create := func(identity *mockstub.Identity, request *Institution) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.CreateInstitution(ctx, request)
+		})
+	}
+	id := "INST20221122103002"
+
+	expectError(t, create(member, &Institution{ID: id, Name: "Universidad de Oriente", MSPID: "Org2MSP"}), lus.ErrorNotAdmin)
+	expectError(t, create(admin, &Institution{ID: id, Name: "Universidad de Oriente"}), lus.ErrorMissingMSP, id)
+	expectError(t, create(admin, &Institution{ID: testInstitutionID, Name: "Universidad de Oriente", MSPID: "Org2MSP"}), lus.ErrorAlreadyExistInState, testInstitutionID)
+	if err := create(admin, &Institution{ID: id, Name: "Universidad de Oriente", MSPID: "Org2MSP", Active: false}); err != nil {
+		t.Fatal(err)
+	}
+
+	inst, err := GetInstitution(n.stub, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inst.Active || inst.DocType != lus.CodInstitution || inst.CreatedBy == "" {
+		t.Errorf("institution = %+v", inst)
+	}
+}
+
+func TestUpdateInstitution(t *testing.T) {
+	n := newTestNetwork(t)
+	update := func(identity *mockstub.Identity, request *Institution) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.UpdateInstitution(ctx, request)
+		})
+	}
+
+	expectError(t, update(member, &Institution{ID: testInstitutionID, Name: "UH", MSPID: "Org1MSP"}), lus.ErrorNotAdmin)
+	expectError(t, update(admin, &Institution{ID: testInstitutionID, Name: "UH"}), lus.ErrorMissingMSP, testInstitutionID)
+	expectError(t, update(admin, &Institution{ID: "INST20000101000000", Name: "UH", MSPID: "Org1MSP"}), lus.ErrorNotExistInState, "INST20000101000000")
+	if err := update(admin, &Institution{ID: testInstitutionID, Name: "Universidad de La Habana (UH)", Acronym: "UH", MSPID: "Org3MSP", Active: false}); err != nil {
+		t.Fatal(err)
+	}
+
+	inst, err := GetInstitution(n.stub, testInstitutionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the update does not change the status of the institution
+	if inst.Name != "Universidad de La Habana (UH)" || inst.MSPID != "Org3MSP" || !inst.Active || inst.UpdatedAt == inst.CreatedAt {
+		t.Errorf("institution = %+v", inst)
+	}
+}
+
+func TestSetInstitutionActive(t *testing.T) {
+	n := newTestNetwork(t)
+
+	expectError(t, n.setActive(member, n.contract.SetInstitutionActive, testInstitutionID, false), lus.ErrorNotAdmin)
+	expectError(t, n.setActive(admin, n.contract.SetInstitutionActive, "INST20000101000000", false), lus.ErrorNotExistInState, "INST20000101000000")
+	if err := n.setActive(admin, n.contract.SetInstitutionActive, testInstitutionID, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// inactive institutions can not register faculties nor emit certificates
+	err := n.submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateFaculty(ctx, &Faculty{ID: "FACU20221122103002", InstitutionID: testInstitutionID, Name: "Facultad de Química"})
+	})
+	expectError(t, err, lus.ErrorInactive, testInstitutionID)
+	_, _, err = GetActiveFaculty(n.stub, testInstitutionID, testFacultyID)
+	expectError(t, err, lus.ErrorInactive, testInstitutionID)
+
+	if err := n.setActive(admin, n.contract.SetInstitutionActive, testInstitutionID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = GetActiveFaculty(n.stub, testInstitutionID, testFacultyID); err != nil {
+		t.Errorf("reactivated institution: %v", err)
+	}
+}
+
+func TestCreateFaculty(t *testing.T) {
+	n := newTestNetwork(t)
+	create := func(identity *mockstub.Identity, request *Faculty) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.CreateFaculty(ctx, request)
+		})
+	}
+	id := "FACU20221122103002"
+
+	expectError(t, create(member, &Faculty{ID: id, InstitutionID: "INST20000101000000", Name: "Facultad de Química"}), lus.ErrorNotExistInState, "INST20000101000000")
+	expectError(t, create(outsider, &Faculty{ID: id, InstitutionID: testInstitutionID, Name: "Facultad de Química"}), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	expectError(t, create(member, &Faculty{ID: testFacultyID, InstitutionID: testInstitutionID, Name: "Facultad de Química"}), lus.ErrorAlreadyExistInState, testFacultyID)
+	if err := create(admin, &Faculty{ID: id, InstitutionID: testInstitutionID, Name: "Facultad de Química"}); err != nil {
+		t.Fatal(err)
+	}
+
+	faculty, err := GetFaculty(n.stub, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !faculty.Active || faculty.InstitutionID != testInstitutionID || faculty.CreatedBy == "" {
+		t.Errorf("faculty = %+v", faculty)
+	}
+}
+
+func TestUpdateFaculty(t *testing.T) {
+	n := newTestNetwork(t)
+	update := func(identity *mockstub.Identity, request *Faculty) error {
+		return n.submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.UpdateFaculty(ctx, request)
+		})
+	}
+
+	expectError(t, update(member, &Faculty{ID: "FACU20000101000000", Name: "Facultad de Derecho"}), lus.ErrorNotExistInState, "FACU20000101000000")
+	expectError(t, update(outsider, &Faculty{ID: testFacultyID, Name: "Facultad de Derecho"}), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	if err := update(member, &Faculty{ID: testFacultyID, InstitutionID: "INST20221122103002", Name: "Facultad de Ciencias Jurídicas", Active: false}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a faculty can not be moved to another institution, nor deactivated, by an update
+	faculty, err := GetFaculty(n.stub, testFacultyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if faculty.Name != "Facultad de Ciencias Jurídicas" || faculty.InstitutionID != testInstitutionID || !faculty.Active || faculty.UpdatedAt == faculty.CreatedAt {
+		t.Errorf("faculty = %+v", faculty)
+	}
+}
+
+func TestSetFacultyActive(t *testing.T) {
+	n := newTestNetwork(t)
+
+	expectError(t, n.setActive(outsider, n.contract.SetFacultyActive, testFacultyID, false), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	expectError(t, n.setActive(member, n.contract.SetFacultyActive, "FACU20000101000000", false), lus.ErrorNotExistInState, "FACU20000101000000")
+	if err := n.setActive(member, n.contract.SetFacultyActive, testFacultyID, false); err != nil {
+		t.Fatal(err)
+	}
+	_, _, err := GetActiveFaculty(n.stub, testInstitutionID, testFacultyID)
+	expectError(t, err, lus.ErrorInactive, testFacultyID)
+	_, _, err = GetActiveFaculty(n.stub, "INST20221122103002", testFacultyID)
+	expectError(t, err, lus.ErrorNotExistInState, "INST20221122103002")
+}
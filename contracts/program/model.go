@@ -0,0 +1,95 @@
+package program
+
+import lus "academic_certificates/libutils"
+
+const SchemaVersion = 1
+
+type Level uint
+
+const (
+	Bachelor  Level = iota // licenciatura, ingeniería, arquitectura
+	Master                 // maestría
+	Doctorate              // doctorado
+	Specialty              // especialidad de posgrado
+)
+
+func (level Level) String() string {
+	names := []string{"Bachelor", "Master", "Doctorate", "Specialty"}
+	if level > Specialty {
+		return "unknown"
+	}
+	return names[level]
+}
+
+// HonorsLevel graduation honors of a certificate
+type HonorsLevel uint
+
+const (
+	NoHonors    HonorsLevel = iota
+	Distinction             // graduated with distinction
+	Gold                    // título de oro, the gold certificate
+)
+
+func (level HonorsLevel) String() string {
+	names := []string{"No honors", "With distinction", "Gold certificate"}
+	if level > Gold {
+		return "unknown"
+	}
+	return names[level]
+}
+
+// Localize returns the label of the honors level in lang
+func (level HonorsLevel) Localize(lang lus.Language) string {
+	return lus.Translate(lang, level.String())
+}
+
+// MaxGPA top of the five-point grading scale
+const MaxGPA = 5
+
+// HonorsRule requirements of an honors level of a program
+type HonorsRule struct {
+	Level            HonorsLevel `json:"level"`
+	MinGPA           float64     `json:"min_gpa"`
+	MaxFailedCourses int         `json:"max_failed_courses"` // 0 requires no failed courses
+}
+
+// Program describes a degree program in the catalog of an institution
+type Program struct {
+	DocType       string       `json:"docType"`
+	SchemaVersion int          `json:"schema_version" metadata:",optional"`
+	ID            string       `json:"ID"`
+	Code          string       `json:"code"` // official program code, unique in the institution
+	Name          string       `json:"name"` // official degree name, ex: Licenciado en Derecho
+	Level         Level        `json:"level"`
+	InstitutionID string       `json:"institution_id" metadata:",optional"` // taken from the faculty
+	FacultyID     string       `json:"faculty_id"`
+	Accreditation string       `json:"accreditation_resolution"` // resolution approving the program
+	FirstYear     int          `json:"first_year"`
+	LastYear      int          `json:"last_year" metadata:",optional"`              // 0 while the program is offered
+	HonorsRules   []HonorsRule `json:"honors_rules,omitempty" metadata:",optional"` // evaluated when a certificate is issued
+	lus.Audit
+}
+
+// offeredIn reports if the program was offered in year
+func (p *Program) offeredIn(year int) bool {
+	return p.FirstYear <= year && (p.LastYear == 0 || year <= p.LastYear)
+}
+
+// Honors returns the highest honors level whose rule is met by a GPA and a number of failed courses.
+// Without a GPA there are no honors.
+func (p *Program) Honors(gpa float64, failedCourses int) HonorsLevel {
+	honors := NoHonors
+	if gpa <= 0 {
+		return honors
+	}
+	for _, rule := range p.HonorsRules {
+		if rule.Level > honors && gpa >= rule.MinGPA && failedCourses <= rule.MaxFailedCourses {
+			honors = rule.Level
+		}
+	}
+	return honors
+}
+
+type GetRequest struct {
+	ID string `json:"id"`
+}
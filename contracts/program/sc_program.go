@@ -0,0 +1,271 @@
+package program
+
+import (
+	"encoding/json"
+
+	"academic_certificates/contracts/institution"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ContractProgram provides functions for managing the catalog of degree programs
+type ContractProgram struct {
+	contractapi.Contract
+}
+
+// CreateProgram adds a degree program offered by an active faculty to the catalog.
+// Administrators and members of the institution organization can register programs.
+func (s *ContractProgram) CreateProgram(ctx contractapi.TransactionContextInterface, request *Program) error {
+	if request.Level > Specialty {
+		return lus.Errorf(lus.ErrorInvalidLevel, request.Level)
+	}
+	err := checkYears(request.FirstYear, request.LastYear)
+	if err != nil {
+		return err
+	}
+	err = CheckHonorsRules(request.HonorsRules)
+	if err != nil {
+		return err
+	}
+
+	faculty, err := institution.GetFaculty(ctx.GetStub(), request.FacultyID)
+	if err != nil {
+		return err
+	}
+	emitter, _, err := institution.GetActiveFaculty(ctx.GetStub(), faculty.InstitutionID, faculty.ID)
+	if err != nil {
+		return err
+	}
+	err = institution.CheckManager(ctx, emitter)
+	if err != nil {
+		return err
+	}
+
+	_, _, programJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodProgram, request.ID)
+	if err != nil {
+		return err
+	} else if programJSON != nil {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, request.ID)
+	}
+
+	// the official code identifies the program, it can not be registered twice
+	codeKey, err := codeIndexKey(ctx.GetStub(), emitter.ID, request.Code)
+	if err != nil {
+		return err
+	}
+	codeIndex, err := ctx.GetStub().GetState(codeKey)
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	} else if codeIndex != nil {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, request.Code)
+	}
+
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return err
+	}
+
+	program := Program{
+		DocType:       lus.CodProgram,
+		ID:            request.ID,
+		Code:          request.Code,
+		Name:          request.Name,
+		Level:         request.Level,
+		InstitutionID: emitter.ID,
+		FacultyID:     faculty.ID,
+		Accreditation: request.Accreditation,
+		FirstYear:     request.FirstYear,
+		LastYear:      request.LastYear,
+		HonorsRules:   request.HonorsRules,
+		Audit:         audit,
+	}
+
+	return PutProgram(ctx.GetStub(), &program)
+}
+
+// ReadProgram returns the program stored in the world state with given id.
+func (s *ContractProgram) ReadProgram(ctx contractapi.TransactionContextInterface, request GetRequest) (*Program, error) {
+	return GetProgram(ctx.GetStub(), request.ID)
+}
+
+// UpdateProgram updates the name, level, accreditation, active years and honors rules of a program.
+// The official code and the faculty offering the program can not change.
+func (s *ContractProgram) UpdateProgram(ctx contractapi.TransactionContextInterface, request *Program) error {
+	if request.Level > Specialty {
+		return lus.Errorf(lus.ErrorInvalidLevel, request.Level)
+	}
+	err := checkYears(request.FirstYear, request.LastYear)
+	if err != nil {
+		return err
+	}
+	err = CheckHonorsRules(request.HonorsRules)
+	if err != nil {
+		return err
+	}
+
+	program, err := GetProgram(ctx.GetStub(), request.ID)
+	if err != nil {
+		return err
+	}
+	emitter, err := institution.GetInstitution(ctx.GetStub(), program.InstitutionID)
+	if err != nil {
+		return err
+	}
+	err = institution.CheckManager(ctx, emitter)
+	if err != nil {
+		return err
+	}
+	err = program.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+
+	program.Name = request.Name
+	program.Level = request.Level
+	program.Accreditation = request.Accreditation
+	program.FirstYear = request.FirstYear
+	program.LastYear = request.LastYear
+	program.HonorsRules = request.HonorsRules
+
+	return PutProgram(ctx.GetStub(), program)
+}
+
+// QueryProgramsByFaculty returns the programs offered by the faculty with given id.
+// Only available on state databases that support rich query (e.g. CouchDB)
+func (s *ContractProgram) QueryProgramsByFaculty(ctx contractapi.TransactionContextInterface, request GetRequest) ([]*Program, error) {
+	query := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"docType":    lus.CodProgram,
+			"faculty_id": request.ID,
+		},
+	}
+	queryString, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetQueryResult(string(queryString))
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	programs := make([]*Program, 0)
+	for resultsIterator.HasNext() {
+		queryResult, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		var program Program
+		err = json.Unmarshal(queryResult.Value, &program)
+		if err != nil {
+			return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+		}
+		programs = append(programs, &program)
+	}
+
+	return programs, nil
+}
+
+func (s *ContractProgram) GetEvaluateTransactions() []string {
+	return []string{"ReadProgram", "QueryProgramsByFaculty"}
+}
+
+// CheckHonorsRules returns an error if a rule has an invalid level, GPA or failed courses, or
+// there is more than one rule for a level
+func CheckHonorsRules(rules []HonorsRule) error {
+	levels := make(map[HonorsLevel]bool)
+	for _, rule := range rules {
+		if rule.Level == NoHonors || rule.Level > Gold {
+			return lus.Errorf(lus.ErrorInvalidHonorsLevel, uint(rule.Level))
+		}
+		if rule.MinGPA < 0 || rule.MinGPA > MaxGPA || rule.MaxFailedCourses < 0 {
+			return lus.Errorf(lus.ErrorInvalidHonorsRule, rule.Level)
+		}
+		if levels[rule.Level] {
+			return lus.Errorf(lus.ErrorHonorsRuleDuplicated, rule.Level)
+		}
+		levels[rule.Level] = true
+	}
+	return nil
+}
+
+func checkYears(firstYear, lastYear int) error {
+	if firstYear <= 0 || (lastYear != 0 && lastYear < firstYear) {
+		return lus.Errorf(lus.ErrorInvalidYears, firstYear, lastYear)
+	}
+	return nil
+}
+
+// GetProgram returns the program stored in the world state with given id.
+func GetProgram(stub shim.ChaincodeStubInterface, id string) (*Program, error) {
+	_, _, programJSON, err := lus.ExistsAssetFromId(stub, lus.CodProgram, id)
+	if err != nil {
+		return nil, err
+	} else if programJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+
+	var program Program
+	err = json.Unmarshal(programJSON, &program)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &program, nil
+}
+
+// GetOfferedProgram returns the program with given id if it was offered by the faculty in year.
+func GetOfferedProgram(stub shim.ChaincodeStubInterface, id, facultyID string, year int) (*Program, error) {
+	program, err := GetProgram(stub, id)
+	if err != nil {
+		return nil, err
+	}
+	err = program.CheckOffered(facultyID, year)
+	if err != nil {
+		return nil, err
+	}
+
+	return program, nil
+}
+
+// CheckOffered returns an error unless the program was offered by the faculty in year
+func (p *Program) CheckOffered(facultyID string, year int) error {
+	if p.FacultyID != facultyID {
+		return lus.Errorf(lus.ErrorProgramFaculty, p.ID, facultyID)
+	}
+	if !p.offeredIn(year) {
+		return lus.Errorf(lus.ErrorProgramYear, p.ID, year)
+	}
+	return nil
+}
+
+func codeIndexKey(stub shim.ChaincodeStubInterface, institutionID, code string) (string, error) {
+	return stub.CreateCompositeKey(lus.CodProgramCode, []string{institutionID, code})
+}
+
+// PutProgram writes the program, and its entry in the index of official codes, to the world state
+func PutProgram(stub shim.ChaincodeStubInterface, program *Program) error {
+	compositeKey, _, err := lus.CompositeKeyFromID(stub, lus.CodProgram, program.ID)
+	if err != nil {
+		return err
+	}
+	program.SchemaVersion = SchemaVersion
+	programJSON, err := json.Marshal(program)
+	if err != nil {
+		return err
+	}
+
+	codeKey, err := codeIndexKey(stub, program.InstitutionID, program.Code)
+	if err != nil {
+		return err
+	}
+	err = stub.PutState(codeKey, []byte(program.ID))
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	}
+
+	return stub.PutState(compositeKey, programJSON)
+}
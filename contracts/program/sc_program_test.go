@@ -0,0 +1,189 @@
+package program
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"academic_certificates/contracts/institution"
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	testInstitutionID = "INST20221122103000"
+	testFacultyID     = "FACU20221122103001"
+	testProgramID     = "PROG20221122103002"
+)
+
+var (
+	admin    = mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+	member   = mockstub.MustIdentity("Org1MSP", "User1@org1.example.com", nil)
+	outsider = mockstub.MustIdentity("Org2MSP", "User1@org2.example.com", nil)
+)
+
+type testNetwork struct {
+	stub     *mockstub.Stub
+	contract *ContractProgram
+}
+
+// newTestNetwork returns a network with an active institution of Org1MSP, one of its faculties
+// and a bachelor program of the faculty
+func newTestNetwork(t *testing.T) *testNetwork {
+	t.Helper()
+	n := &testNetwork{stub: mockstub.NewStub("mychannel"), contract: new(ContractProgram)}
+	n.stub.SetTime(time.Date(2022, 11, 22, 10, 30, 0, 0, time.UTC))
+
+	err := n.stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+		err := institution.PutInstitution(ctx.GetStub(), &institution.Institution{DocType: lus.CodInstitution, ID: testInstitutionID, Name: "Universidad de La Habana", MSPID: "Org1MSP", Active: true})
+		if err != nil {
+			return err
+		}
+		return institution.PutFaculty(ctx.GetStub(), &institution.Faculty{DocType: lus.CodFaculty, ID: testFacultyID, InstitutionID: testInstitutionID, Name: "Facultad de Derecho", Active: true})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.create(member, testProgram(testProgramID, "LD-01")); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// testProgram returns a request registering a bachelor program of the test faculty
+func testProgram(id, code string) *Program {
+	return &Program{
+		ID:            id,
+		Code:          code,
+		Name:          "Licenciado en Derecho",
+		Level:         Bachelor,
+		FacultyID:     testFacultyID,
+		Accreditation: "RM 120/2010",
+		FirstYear:     2010,
+		HonorsRules:   []HonorsRule{{Level: Gold, MinGPA: 4.75}},
+	}
+}
+
+func (n *testNetwork) create(identity *mockstub.Identity, request *Program) error {
+	return n.stub.Submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateProgram(ctx, request)
+	})
+}
+
+func (n *testNetwork) update(identity *mockstub.Identity, request *Program) error {
+	return n.stub.Submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.UpdateProgram(ctx, request)
+	})
+}
+
+func expectError(t *testing.T, err error, format string, args ...interface{}) {
+	t.Helper()
+	want := fmt.Sprintf(format, args...)
+	if err == nil {
+		t.Fatalf("expected error %q, got nil", want)
+	} else if !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error %q, got %q", want, err)
+	}
+}
+
+// invalidProgram a request rejected before any lookup, with the expected error
+type invalidProgram struct {
+	request *Program
+	err     string
+}
+
+// invalidPrograms returns the invalid requests for the program with given id
+func invalidPrograms(id string) map[string]invalidProgram {
+	cases := make(map[string]invalidProgram)
+	add := func(name string, edit func(p *Program), err string) {
+		request := testProgram(id, "LD-02")
+		edit(request)
+		cases[name] = invalidProgram{request, err}
+	}
+	add("level", func(p *Program) { p.Level = Specialty + 1 }, fmt.Sprintf(lus.ErrorInvalidLevel, Specialty+1))
+	add("no first year", func(p *Program) { p.FirstYear = 0 }, fmt.Sprintf(lus.ErrorInvalidYears, 0, 0))
+	add("last before first", func(p *Program) { p.LastYear = 2009 }, fmt.Sprintf(lus.ErrorInvalidYears, 2010, 2009))
+	add("honors level", func(p *Program) { p.HonorsRules = []HonorsRule{{Level: NoHonors, MinGPA: 4}} }, fmt.Sprintf(lus.ErrorInvalidHonorsLevel, uint(NoHonors)))
+	add("honors GPA", func(p *Program) { p.HonorsRules = []HonorsRule{{Level: Gold, MinGPA: MaxGPA + 1}} }, fmt.Sprintf(lus.ErrorInvalidHonorsRule, Gold))
+	add("honors failed courses", func(p *Program) { p.HonorsRules = []HonorsRule{{Level: Distinction, MinGPA: 4, MaxFailedCourses: -1}} }, fmt.Sprintf(lus.ErrorInvalidHonorsRule, Distinction))
+	add("honors duplicated", func(p *Program) {
+		p.HonorsRules = []HonorsRule{{Level: Gold, MinGPA: 4.75}, {Level: Gold, MinGPA: 4.5}}
+	}, fmt.Sprintf(lus.ErrorHonorsRuleDuplicated, Gold))
+	return cases
+}
+
+func TestCreateProgram(t *testing.T) {
+	n := newTestNetwork(t)
+	id := "PROG20221122103003"
+
+	for name, tc := range invalidPrograms(id) {
+		t.Run(name, func(t *testing.T) {
+			expectError(t, n.create(member, tc.request), "%s", tc.err)
+		})
+	}
+
+	request := testProgram(id, "LD-02")
+	request.FacultyID = "FACU20000101000000"
+	expectError(t, n.create(member, request), lus.ErrorNotExistInState, "FACU20000101000000")
+	expectError(t, n.create(outsider, testProgram(id, "LD-02")), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	expectError(t, n.create(member, testProgram(testProgramID, "LD-02")), lus.ErrorAlreadyExistInState, testProgramID)
+	expectError(t, n.create(member, testProgram(id, "LD-01")), lus.ErrorAlreadyExistInState, "LD-01")
+	if err := n.create(admin, testProgram(id, "LD-02")); err != nil {
+		t.Fatal(err)
+	}
+
+	program, err := GetProgram(n.stub, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if program.InstitutionID != testInstitutionID || program.FacultyID != testFacultyID || program.CreatedBy == "" {
+		t.Errorf("program = %+v", program)
+	}
+}
+
+func TestCreateProgramInactive(t *testing.T) {
+	n := newTestNetwork(t)
+	err := n.stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+		return new(institution.ContractInstitution).SetFacultyActive(ctx, institution.SetActiveRequest{ID: testFacultyID, Active: false})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectError(t, n.create(member, testProgram("PROG20221122103003", "LD-02")), lus.ErrorInactive, testFacultyID)
+}
+
+func TestUpdateProgram(t *testing.T) {
+	n := newTestNetwork(t)
+
+	for name, tc := range invalidPrograms(testProgramID) {
+		t.Run(name, func(t *testing.T) {
+			expectError(t, n.update(member, tc.request), "%s", tc.err)
+		})
+	}
+	expectError(t, n.update(member, testProgram("PROG20000101000000", "LD-01")), lus.ErrorNotExistInState, "PROG20000101000000")
+	expectError(t, n.update(outsider, testProgram(testProgramID, "LD-01")), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+
+	request := testProgram(testProgramID, "LD-09")
+	request.FacultyID = "FACU20221122103009"
+	request.Name = "Licenciado en Derecho (plan E)"
+	request.LastYear = 2022
+	request.HonorsRules = nil
+	if err := n.update(member, request); err != nil {
+		t.Fatal(err)
+	}
+
+	// the code and the faculty are kept
+	program, err := GetProgram(n.stub, testProgramID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if program.Code != "LD-01" || program.FacultyID != testFacultyID || program.Name != request.Name ||
+		program.LastYear != 2022 || len(program.HonorsRules) != 0 || program.UpdatedAt == program.CreatedAt {
+		t.Errorf("program = %+v", program)
+	}
+	expectError(t, program.CheckOffered(testFacultyID, 2023), lus.ErrorProgramYear, testProgramID, 2023)
+}
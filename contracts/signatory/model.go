@@ -0,0 +1,49 @@
+package signatory
+
+import (
+	"academic_certificates/contracts/common"
+	lus "academic_certificates/libutils"
+)
+
+const SchemaVersion = 1
+
+// Signatory describes an officer allowed to sign certificates during a term of office
+type Signatory struct {
+	DocType       string               `json:"docType"`
+	SchemaVersion int                  `json:"schema_version" metadata:",optional"`
+	ID            string               `json:"ID"`
+	Name          string               `json:"name"`
+	Role          common.ValidatorType `json:"role"`
+	InstitutionID string               `json:"institution_id"`
+	FacultyID     string               `json:"faculty_id" metadata:",optional"`           // empty for rectors
+	TermStart     string               `json:"term_start"`                                // YYYY-MM-DD
+	TermEnd       string               `json:"term_end" metadata:",optional"`             // YYYY-MM-DD, empty while in office
+	Certificate   string               `json:"certificate"`                               // PEM x509 certificate or public key of the signing identity
+	RoleLabel     string               `json:"role_label,omitempty" metadata:",optional"` // name of the role in the language of the request, never stored
+	lus.Audit
+}
+
+// inOffice reports if date (YYYY-MM-DD) is inside the term of office of the signatory
+func (sig *Signatory) inOffice(date string) bool {
+	return sig.TermStart <= date && (sig.TermEnd == "" || date <= sig.TermEnd)
+}
+
+type GetRequest struct {
+	ID string `json:"id"`
+}
+
+type EndTermRequest struct {
+	ID      string `json:"id"`
+	TermEnd string `json:"term_end"` // YYYY-MM-DD
+}
+
+type UpdateCertificateRequest struct {
+	ID          string `json:"id"`
+	Certificate string `json:"certificate"`
+}
+
+// ScopeRequest selects the signatories of an institution, or of one of its faculties
+type ScopeRequest struct {
+	InstitutionID string `json:"institution_id"`
+	FacultyID     string `json:"faculty_id" metadata:",optional"`
+}
@@ -0,0 +1,335 @@
+package signatory
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"academic_certificates/contracts/common"
+	"academic_certificates/contracts/institution"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ContractSignatory provides functions for managing the registry of officers signing certificates
+type ContractSignatory struct {
+	contractapi.Contract
+}
+
+// CreateSignatory registers an officer holding a role during a term of office. Secretaries and
+// deans hold office in a faculty, rectors in an institution. Terms of the same role can not overlap.
+// Administrators and members of the institution organization can register signatories.
+func (s *ContractSignatory) CreateSignatory(ctx contractapi.TransactionContextInterface, request *Signatory) error {
+	if request.Role < common.Secretary || request.Role > common.Rector {
+		return lus.Errorf(lus.ErrorInvalidRole, request.Role)
+	}
+
+	emitter, err := institution.GetInstitution(ctx.GetStub(), request.InstitutionID)
+	if err != nil {
+		return err
+	} else if !emitter.Active {
+		return lus.Errorf(lus.ErrorInactive, emitter.ID)
+	}
+	facultyID := ""
+	if request.Role.FacultyScoped() {
+		_, faculty, err := institution.GetActiveFaculty(ctx.GetStub(), emitter.ID, request.FacultyID)
+		if err != nil {
+			return err
+		}
+		facultyID = faculty.ID
+	}
+	err = institution.CheckManager(ctx, emitter)
+	if err != nil {
+		return err
+	}
+
+	_, _, signatoryJSON, err := lus.ExistsAssetFromId(ctx.GetStub(), lus.CodSignatory, request.ID)
+	if err != nil {
+		return err
+	} else if signatoryJSON != nil {
+		return lus.Errorf(lus.ErrorAlreadyExistInState, request.ID)
+	}
+
+	_, err = lus.PublicKeyFromPEM(request.Certificate)
+	if err != nil {
+		return err
+	}
+
+	audit, err := lus.NewAudit(ctx)
+	if err != nil {
+		return err
+	}
+
+	signatory := Signatory{
+		DocType:       lus.CodSignatory,
+		ID:            request.ID,
+		Name:          request.Name,
+		Role:          request.Role,
+		InstitutionID: emitter.ID,
+		FacultyID:     facultyID,
+		Certificate:   request.Certificate,
+		Audit:         audit,
+	}
+	err = SetTerm(&signatory, request.TermStart, request.TermEnd)
+	if err != nil {
+		return err
+	}
+	err = CheckOverlap(ctx.GetStub(), &signatory, nil)
+	if err != nil {
+		return err
+	}
+
+	return PutSignatory(ctx.GetStub(), &signatory)
+}
+
+// ReadSignatory returns the signatory stored in the world state with given id.
+func (s *ContractSignatory) ReadSignatory(ctx contractapi.TransactionContextInterface, request GetRequest) (*Signatory, error) {
+	signatory, err := GetSignatory(ctx.GetStub(), request.ID)
+	if err != nil {
+		return nil, err
+	}
+	signatory.RoleLabel = signatory.Role.Localize(lus.GetLanguage(ctx))
+
+	return signatory, nil
+}
+
+// EndTerm sets the last day in office of a signatory.
+func (s *ContractSignatory) EndTerm(ctx contractapi.TransactionContextInterface, request EndTermRequest) error {
+	signatory, err := s.manageSignatory(ctx, request.ID)
+	if err != nil {
+		return err
+	}
+
+	err = SetTerm(signatory, signatory.TermStart, request.TermEnd)
+	if err != nil {
+		return err
+	}
+	err = CheckOverlap(ctx.GetStub(), signatory, nil)
+	if err != nil {
+		return err
+	}
+
+	return PutSignatory(ctx.GetStub(), signatory)
+}
+
+// UpdateSignatoryCertificate replaces the certificate of the signing identity, ex: after it was renewed.
+func (s *ContractSignatory) UpdateSignatoryCertificate(ctx contractapi.TransactionContextInterface, request UpdateCertificateRequest) error {
+	signatory, err := s.manageSignatory(ctx, request.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = lus.PublicKeyFromPEM(request.Certificate)
+	if err != nil {
+		return err
+	}
+	signatory.Certificate = request.Certificate
+
+	return PutSignatory(ctx.GetStub(), signatory)
+}
+
+// QuerySignatories returns the signatories of an institution, or of one of its faculties.
+func (s *ContractSignatory) QuerySignatories(ctx contractapi.TransactionContextInterface, request ScopeRequest) ([]*Signatory, error) {
+	attributes := []string{request.InstitutionID}
+	if request.FacultyID != "" {
+		attributes = append(attributes, request.FacultyID)
+	}
+
+	signatories, err := querySignatories(ctx.GetStub(), attributes)
+	if err != nil {
+		return nil, err
+	}
+	lang := lus.GetLanguage(ctx)
+	for _, signatory := range signatories {
+		signatory.RoleLabel = signatory.Role.Localize(lang)
+	}
+
+	return signatories, nil
+}
+
+func (s *ContractSignatory) GetEvaluateTransactions() []string {
+	return []string{"ReadSignatory", "QuerySignatories"}
+}
+
+// manageSignatory returns the signatory with given id, stamped for modification,
+// if the client identity is allowed to manage it.
+func (s *ContractSignatory) manageSignatory(ctx contractapi.TransactionContextInterface, id string) (*Signatory, error) {
+	signatory, err := GetSignatory(ctx.GetStub(), id)
+	if err != nil {
+		return nil, err
+	}
+	emitter, err := institution.GetInstitution(ctx.GetStub(), signatory.InstitutionID)
+	if err != nil {
+		return nil, err
+	}
+	err = institution.CheckManager(ctx, emitter)
+	if err != nil {
+		return nil, err
+	}
+	err = signatory.Audit.Stamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return signatory, nil
+}
+
+// SetTerm normalizes and sets the term of office of the signatory
+func SetTerm(signatory *Signatory, termStart, termEnd string) error {
+	start, err := lus.NormalizeDate(termStart)
+	if err != nil {
+		return err
+	}
+	end := ""
+	if termEnd != "" {
+		end, err = lus.NormalizeDate(termEnd)
+		if err != nil {
+			return err
+		} else if end < start {
+			return lus.Errorf(lus.ErrorInvalidTerm, end, start)
+		}
+	}
+
+	signatory.TermStart = start
+	signatory.TermEnd = end
+	return nil
+}
+
+// CheckOverlap returns an error if another signatory holds the same role, in the same
+// faculty or institution, during any day of the term of office of signatory. pending are the
+// signatories written by the transaction, the world state does not reflect them yet.
+func CheckOverlap(stub shim.ChaincodeStubInterface, signatory *Signatory, pending []*Signatory) error {
+	holders, err := querySignatories(stub, []string{signatory.InstitutionID, signatory.FacultyID, strconv.Itoa(int(signatory.Role))})
+	if err != nil {
+		return err
+	}
+
+	for _, holder := range append(holders, pending...) {
+		if holder.ID == signatory.ID || holder.InstitutionID != signatory.InstitutionID ||
+			holder.FacultyID != signatory.FacultyID || holder.Role != signatory.Role {
+			continue
+		}
+		startsBeforeEnd := signatory.TermEnd == "" || holder.TermStart <= signatory.TermEnd
+		endsAfterStart := holder.TermEnd == "" || signatory.TermStart <= holder.TermEnd
+		if startsBeforeEnd && endsAfterStart {
+			return lus.Errorf(lus.ErrorTermOverlap, holder.ID)
+		}
+	}
+
+	return nil
+}
+
+// querySignatories returns the signatories in the role index matching the partial key attributes
+// (institution, faculty, role)
+func querySignatories(stub shim.ChaincodeStubInterface, attributes []string) ([]*Signatory, error) {
+	resultsIterator, err := stub.GetStateByPartialCompositeKey(lus.CodSignRole, attributes)
+	if err != nil {
+		return nil, err
+	}
+	defer resultsIterator.Close()
+
+	signatories := make([]*Signatory, 0)
+	for resultsIterator.HasNext() {
+		result, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		_, keyParts, err := stub.SplitCompositeKey(result.Key)
+		if err != nil {
+			return nil, err
+		}
+		signatory, err := GetSignatory(stub, keyParts[len(keyParts)-1])
+		if err != nil {
+			return nil, err
+		}
+		signatories = append(signatories, signatory)
+	}
+
+	return signatories, nil
+}
+
+func roleIndexKey(stub shim.ChaincodeStubInterface, signatory *Signatory) (string, error) {
+	return stub.CreateCompositeKey(lus.CodSignRole, []string{signatory.InstitutionID, signatory.FacultyID, strconv.Itoa(int(signatory.Role)), signatory.ID})
+}
+
+// PutSignatory writes the signatory, and its entry in the role index, to the world state
+func PutSignatory(stub shim.ChaincodeStubInterface, signatory *Signatory) error {
+	compositeKey, _, err := lus.CompositeKeyFromID(stub, lus.CodSignatory, signatory.ID)
+	if err != nil {
+		return err
+	}
+	signatory.SchemaVersion = SchemaVersion
+	signatoryJSON, err := json.Marshal(signatory)
+	if err != nil {
+		return err
+	}
+
+	indexKey, err := roleIndexKey(stub, signatory)
+	if err != nil {
+		return err
+	}
+	// Only the key name is needed in the index, the value is the null character
+	err = stub.PutState(indexKey, []byte{0x00})
+	if err != nil {
+		return lus.Errorf(lus.ErrorWorldState, err)
+	}
+
+	return stub.PutState(compositeKey, signatoryJSON)
+}
+
+// GetSignatory returns the signatory stored in the world state with given id.
+func GetSignatory(stub shim.ChaincodeStubInterface, id string) (*Signatory, error) {
+	_, _, signatoryJSON, err := lus.ExistsAssetFromId(stub, lus.CodSignatory, id)
+	if err != nil {
+		return nil, err
+	} else if signatoryJSON == nil {
+		return nil, lus.Errorf(lus.ErrorNotExistInState, id)
+	}
+
+	var signatory Signatory
+	err = json.Unmarshal(signatoryJSON, &signatory)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &signatory, nil
+}
+
+// CheckSigner returns the signatory with given id if, at the transaction timestamp, it is the
+// officer in office holding role in the institution (and faculty, for faculty scoped roles),
+// and the client identity owns the signatory certificate.
+func CheckSigner(ctx contractapi.TransactionContextInterface, id string, role common.ValidatorType, institutionID, facultyID string) (*Signatory, error) {
+	signatory, err := GetSignatory(ctx.GetStub(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if signatory.Role != role {
+		return nil, lus.Errorf(lus.ErrorSignatoryRole, signatory.ID, role)
+	}
+	if signatory.InstitutionID != institutionID {
+		return nil, lus.Errorf(lus.ErrorSignatoryScope, signatory.ID, institutionID)
+	}
+	if role.FacultyScoped() && signatory.FacultyID != facultyID {
+		return nil, lus.Errorf(lus.ErrorSignatoryScope, signatory.ID, facultyID)
+	}
+
+	txTime, err := lus.GetTxTime(ctx.GetStub())
+	if err != nil {
+		return nil, err
+	}
+	txDate := txTime.Format(lus.DateLayout)
+	if !signatory.inOffice(txDate) {
+		return nil, lus.Errorf(lus.ErrorSignatoryTerm, signatory.ID, txDate)
+	}
+
+	owner, err := lus.ClientHasPublicKey(ctx, signatory.Certificate)
+	if err != nil {
+		return nil, err
+	} else if !owner {
+		return nil, lus.Errorf(lus.ErrorSignerIdentity, signatory.ID)
+	}
+
+	return signatory, nil
+}
@@ -0,0 +1,216 @@
+package signatory
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"academic_certificates/contracts/common"
+	"academic_certificates/contracts/institution"
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+const (
+	testInstitutionID = "INST20221122103000"
+	testFacultyID     = "FACU20221122103001"
+	testSignatoryID   = "SIGN20221122103002"
+)
+
+var (
+	admin    = mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+	member   = mockstub.MustIdentity("Org1MSP", "User1@org1.example.com", nil)
+	outsider = mockstub.MustIdentity("Org2MSP", "User1@org2.example.com", nil)
+	dean     = mockstub.MustIdentity("Org1MSP", "Dean@org1.example.com", nil)
+)
+
+type testNetwork struct {
+	stub     *mockstub.Stub
+	contract *ContractSignatory
+}
+
+// newTestNetwork returns a network with an active institution of Org1MSP, one of its faculties
+// and the dean of the faculty, in office since 2020-01-01
+func newTestNetwork(t *testing.T) *testNetwork {
+	t.Helper()
+	n := &testNetwork{stub: mockstub.NewStub("mychannel"), contract: new(ContractSignatory)}
+	n.stub.SetTime(time.Date(2022, 11, 22, 10, 30, 0, 0, time.UTC))
+
+	err := n.stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+		err := institution.PutInstitution(ctx.GetStub(), &institution.Institution{DocType: lus.CodInstitution, ID: testInstitutionID, Name: "Universidad de La Habana", MSPID: "Org1MSP", Active: true})
+		if err != nil {
+			return err
+		}
+		return institution.PutFaculty(ctx.GetStub(), &institution.Faculty{DocType: lus.CodFaculty, ID: testFacultyID, InstitutionID: testInstitutionID, Name: "Facultad de Derecho", Active: true})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.create(member, testDean(testSignatoryID, "2020-01-01", "")); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// testDean returns a request registering a dean of the test faculty
+func testDean(id, termStart, termEnd string) *Signatory {
+	return &Signatory{
+		ID:            id,
+		Name:          "María Gómez",
+		Role:          common.Dean,
+		InstitutionID: testInstitutionID,
+		FacultyID:     testFacultyID,
+		TermStart:     termStart,
+		TermEnd:       termEnd,
+		Certificate:   dean.CertificatePEM,
+	}
+}
+
+func (n *testNetwork) create(identity *mockstub.Identity, request *Signatory) error {
+	return n.stub.Submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.CreateSignatory(ctx, request)
+	})
+}
+
+func (n *testNetwork) endTerm(identity *mockstub.Identity, id, termEnd string) error {
+	return n.stub.Submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+		return n.contract.EndTerm(ctx, EndTermRequest{ID: id, TermEnd: termEnd})
+	})
+}
+
+func expectError(t *testing.T, err error, format string, args ...interface{}) {
+	t.Helper()
+	want := fmt.Sprintf(format, args...)
+	if err == nil {
+		t.Fatalf("expected error %q, got nil", want)
+	} else if !strings.Contains(err.Error(), want) {
+		t.Fatalf("expected error %q, got %q", want, err)
+	}
+}
+
+func TestCreateSignatory(t *testing.T) {
+	n := newTestNetwork(t)
+	id := "SIGN20221122103003"
+
+	request := testDean(id, "2018-01-01", "2019-12-31")
+	request.Role = common.NoValidator
+	expectError(t, n.create(member, request), lus.ErrorInvalidRole, common.NoValidator)
+	request.Role = common.Rector + 1
+	expectError(t, n.create(member, request), lus.ErrorInvalidRole, common.Rector+1)
+
+	request = testDean(id, "2018-01-01", "2019-12-31")
+	request.InstitutionID = "INST20000101000000"
+	expectError(t, n.create(member, request), lus.ErrorNotExistInState, "INST20000101000000")
+	request = testDean(id, "2018-01-01", "2019-12-31")
+	request.FacultyID = "FACU20000101000000"
+	expectError(t, n.create(member, request), lus.ErrorNotExistInState, "FACU20000101000000")
+	expectError(t, n.create(outsider, testDean(id, "2018-01-01", "2019-12-31")), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	expectError(t, n.create(member, testDean(testSignatoryID, "2018-01-01", "2019-12-31")), lus.ErrorAlreadyExistInState, testSignatoryID)
+
+	request = testDean(id, "2018-01-01", "2019-12-31")
+	request.Certificate = "not a certificate"
+	expectError(t, n.create(member, request), lus.ErrorParseX509)
+	expectError(t, n.create(member, testDean(id, "2018-01-01", "2018/12/31")), lus.ErrorInvalidDate, "2018/12/31")
+	expectError(t, n.create(member, testDean(id, "2018-01-01", "2017-12-31")), lus.ErrorInvalidTerm, "2017-12-31", "2018-01-01")
+	expectError(t, n.create(member, testDean(id, "2018-01-01", "2020-01-01")), lus.ErrorTermOverlap, testSignatoryID)
+
+	// the previous dean, and the secretary of the same term, do not overlap
+	if err := n.create(member, testDean(id, "2018-01-01", "2019-12-31")); err != nil {
+		t.Fatal(err)
+	}
+	request = testDean("SIGN20221122103004", "2020-01-01", "")
+	request.Role = common.Secretary
+	if err := n.create(admin, request); err != nil {
+		t.Fatal(err)
+	}
+
+	// rectors hold office in the institution, the faculty is ignored
+	request = testDean("SIGN20221122103005", "2020-01-01", "")
+	request.Role = common.Rector
+	if err := n.create(member, request); err != nil {
+		t.Fatal(err)
+	}
+	rector, err := GetSignatory(n.stub, "SIGN20221122103005")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rector.FacultyID != "" || rector.InstitutionID != testInstitutionID || rector.CreatedBy == "" {
+		t.Errorf("rector = %+v", rector)
+	}
+}
+
+func TestCreateSignatoryInactive(t *testing.T) {
+	n := newTestNetwork(t)
+	setActive := func(active bool, setActive func(contractapi.TransactionContextInterface, institution.SetActiveRequest) error, id string) {
+		t.Helper()
+		err := n.stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+			return setActive(ctx, institution.SetActiveRequest{ID: id, Active: active})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	contract := new(institution.ContractInstitution)
+	request := testDean("SIGN20221122103003", "2018-01-01", "2019-12-31")
+
+	setActive(false, contract.SetFacultyActive, testFacultyID)
+	expectError(t, n.create(member, request), lus.ErrorInactive, testFacultyID)
+	setActive(false, contract.SetInstitutionActive, testInstitutionID)
+	expectError(t, n.create(member, request), lus.ErrorInactive, testInstitutionID)
+}
+
+func TestEndTerm(t *testing.T) {
+	n := newTestNetwork(t)
+
+	expectError(t, n.endTerm(member, "SIGN20000101000000", "2022-12-31"), lus.ErrorNotExistInState, "SIGN20000101000000")
+	expectError(t, n.endTerm(outsider, testSignatoryID, "2022-12-31"), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	expectError(t, n.endTerm(member, testSignatoryID, "31/12/2022"), lus.ErrorInvalidDate, "31/12/2022")
+	expectError(t, n.endTerm(member, testSignatoryID, "2019-12-31"), lus.ErrorInvalidTerm, "2019-12-31", "2020-01-01")
+
+	// the dean of the next term can only be registered once the current term ends
+	next := testDean("SIGN20221122103003", "2023-01-01", "")
+	expectError(t, n.create(member, next), lus.ErrorTermOverlap, testSignatoryID)
+	if err := n.endTerm(member, testSignatoryID, "2022-12-31"); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.create(member, next); err != nil {
+		t.Fatal(err)
+	}
+	expectError(t, n.endTerm(member, testSignatoryID, "2023-06-30"), lus.ErrorTermOverlap, next.ID)
+
+	signatory, err := GetSignatory(n.stub, testSignatoryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signatory.TermStart != "2020-01-01" || signatory.TermEnd != "2022-12-31" || signatory.UpdatedBy == "" {
+		t.Errorf("signatory = %+v", signatory)
+	}
+}
+
+func TestUpdateSignatoryCertificate(t *testing.T) {
+	n := newTestNetwork(t)
+	update := func(identity *mockstub.Identity, id, certificate string) error {
+		return n.stub.Submit(identity, func(ctx contractapi.TransactionContextInterface) error {
+			return n.contract.UpdateSignatoryCertificate(ctx, UpdateCertificateRequest{ID: id, Certificate: certificate})
+		})
+	}
+	renewed := mockstub.MustIdentity("Org1MSP", "Dean@org1.example.com", nil)
+
+	expectError(t, update(member, "SIGN20000101000000", renewed.CertificatePEM), lus.ErrorNotExistInState, "SIGN20000101000000")
+	expectError(t, update(outsider, testSignatoryID, renewed.CertificatePEM), lus.ErrorForbiddenMSP, "Org2MSP", testInstitutionID)
+	expectError(t, update(member, testSignatoryID, "not a certificate"), lus.ErrorParseX509)
+	if err := update(admin, testSignatoryID, renewed.CertificatePEM); err != nil {
+		t.Fatal(err)
+	}
+
+	signatory, err := GetSignatory(n.stub, testSignatoryID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signatory.Certificate != renewed.CertificatePEM {
+		t.Errorf("certificate was not replaced")
+	}
+}
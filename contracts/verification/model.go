@@ -0,0 +1,22 @@
+package verification
+
+import lus "academic_certificates/libutils"
+
+const SchemaVersion = 1
+
+// VerifyFunction transaction of the certificate chaincode returning the public data of a certificate
+const VerifyFunction = lus.ContractNameCertificate + ":VerifyCertificate"
+
+// VerificationSettings location of the certificate chaincode queried by the verification contract,
+// stored in the world state of the verification channel
+type VerificationSettings struct {
+	DocType       string `json:"docType" metadata:",optional"`
+	SchemaVersion int    `json:"schema_version" metadata:",optional"`
+	ChaincodeName string `json:"chaincode_name"`                  // name of the certificate chaincode
+	ChannelID     string `json:"channel_id" metadata:",optional"` // issuance channel, the channel of the contract if empty
+	lus.Audit
+}
+
+type VerifyRequest struct {
+	ID string `json:"id"`
+}
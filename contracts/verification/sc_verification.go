@@ -0,0 +1,125 @@
+package verification
+
+import (
+	"encoding/json"
+
+	"academic_certificates/contracts/certificate"
+	lus "academic_certificates/libutils"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ContractVerification lets the members of a verification channel, ex: ministries and foreign universities,
+// verify the certificates of the issuance channel without access to it. The peers running it must also
+// be joined to the issuance channel, the certificate chaincode is queried there through InvokeChaincode.
+type ContractVerification struct {
+	contractapi.Contract
+}
+
+// VerifyCertificate returns the publicly disclosable data and the status of a certificate of the issuance channel.
+func (s *ContractVerification) VerifyCertificate(ctx contractapi.TransactionContextInterface, request VerifyRequest) (*certificate.Verification, error) {
+	settings, err := getSettings(ctx.GetStub())
+	if err != nil {
+		return nil, err
+	}
+	if settings.ChaincodeName == "" {
+		return nil, lus.Errorf(lus.ErrorInvalidSettings, "the certificate chaincode is not configured")
+	}
+
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorMarshal, err)
+	}
+	response := ctx.GetStub().InvokeChaincode(settings.ChaincodeName, [][]byte{[]byte(VerifyFunction), requestJSON}, settings.ChannelID)
+	if response.Status >= shim.ERRORTHRESHOLD {
+		if e, ok := lus.ParseError(response.Message); ok {
+			return nil, e
+		}
+		return nil, lus.Errorf(lus.ErrorInvokeChaincode, settings.ChaincodeName, settings.ChannelID, response.Message)
+	}
+
+	// only the fields of certificate.Verification are decoded, whatever the version of the certificate chaincode
+	var verification certificate.Verification
+	err = json.Unmarshal(response.Payload, &verification)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &verification, nil
+}
+
+// ReadSettings returns the location of the certificate chaincode.
+func (s *ContractVerification) ReadSettings(ctx contractapi.TransactionContextInterface) (*VerificationSettings, error) {
+	return getSettings(ctx.GetStub())
+}
+
+// UpdateSettings replaces the location of the certificate chaincode. Only administrators can update the settings.
+func (s *ContractVerification) UpdateSettings(ctx contractapi.TransactionContextInterface, request *VerificationSettings) error {
+	err := lus.AssertAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	if request.ChaincodeName == "" {
+		return lus.Errorf(lus.ErrorInvalidSettings, "chaincode_name is required")
+	}
+
+	settings, err := getSettings(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	err = settings.Audit.Stamp(ctx)
+	if err != nil {
+		return err
+	}
+	if settings.CreatedAt == "" {
+		settings.CreatedAt = settings.UpdatedAt
+		settings.CreatedBy = settings.UpdatedBy
+	}
+
+	settings.ChaincodeName = request.ChaincodeName
+	settings.ChannelID = request.ChannelID
+	settings.SchemaVersion = SchemaVersion
+
+	key, err := settingsKey(ctx.GetStub())
+	if err != nil {
+		return err
+	}
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	return ctx.GetStub().PutState(key, settingsJSON)
+}
+
+func (s *ContractVerification) GetEvaluateTransactions() []string {
+	return []string{"VerifyCertificate", "ReadSettings"}
+}
+
+func settingsKey(stub shim.ChaincodeStubInterface) (string, error) {
+	return stub.CreateCompositeKey(lus.CodSettings, []string{lus.ContractNameVerification})
+}
+
+// getSettings returns the stored settings, or empty ones if they were never stored
+func getSettings(stub shim.ChaincodeStubInterface) (*VerificationSettings, error) {
+	settings := VerificationSettings{DocType: lus.CodSettings}
+
+	key, err := settingsKey(stub)
+	if err != nil {
+		return nil, err
+	}
+	settingsJSON, err := stub.GetState(key)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorWorldState, err)
+	} else if settingsJSON == nil {
+		return &settings, nil
+	}
+
+	err = json.Unmarshal(settingsJSON, &settings)
+	if err != nil {
+		return nil, lus.Errorf(lus.ErrorUnmarshal, err)
+	}
+
+	return &settings, nil
+}
@@ -0,0 +1,117 @@
+package verification
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"academic_certificates/contracts/certificate"
+	lus "academic_certificates/libutils"
+	"academic_certificates/libutils/mockstub"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// testFixtures seed data of the issuance channel, with a valid certificate
+const testFixtures = `{
+  "institutions": [{"ID": "INST20221122103000", "name": "Universidad de La Habana", "acronym": "UH", "msp_id": "Org1MSP", "active": true}],
+  "faculties": [{"ID": "FACU20221122103001", "institution_id": "INST20221122103000", "name": "Facultad de Derecho", "active": true}],
+  "programs": [{"ID": "PROG20221122103003", "code": "DER", "name": "Licenciado en Derecho", "level": 0, "faculty_id": "FACU20221122103001", "first_year": 1990}],
+  "graduates": [{"ID": "GRAD20221122103005", "institution_id": "INST20221122103000"}],
+  "certificates": [{
+    "ID": "CERT20221122103007", "program_id": "PROG20221122103003", "gold_certificate": true,
+    "emitter_id": "INST20221122103000", "faculty_id": "FACU20221122103001", "holder_id": "GRAD20221122103005",
+    "accredited": "", "date": "2010-11-08",
+    "secretary_validating": "Ana Pérez", "dean_validating": "Luis Gómez", "rector_validating": "Miriam Nicado",
+    "volume_folio_faculty": {"volume": 254, "folio": 136}, "volume_folio_university": {"volume": 12, "folio": 48},
+    "invalid_reason": "", "certificate_status": 4
+  }]
+}`
+
+func newChaincode(t *testing.T, contract contractapi.ContractInterface) shim.Chaincode {
+	t.Helper()
+	chaincode, err := contractapi.NewChaincode(contract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lus.NewLocalizedChaincode(chaincode)
+}
+
+func expectCode(t *testing.T, message, code string) {
+	t.Helper()
+	if e, ok := lus.ParseError(message); !ok || e.Code != code {
+		t.Errorf("error = %s, want code %s", message, code)
+	}
+}
+
+func TestVerifyCertificateAcrossChannels(t *testing.T) {
+	admin := mockstub.MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{lus.AttrType: lus.AdminType})
+	ministry := mockstub.MustIdentity("Org3MSP", "User1@org3.example.com", map[string]string{lus.AttrType: "client"})
+
+	issuance := mockstub.NewStub("issuance")
+	certContract := new(certificate.ContractCertificate)
+	certContract.Name = lus.ContractNameCertificate
+	certificates := newChaincode(t, certContract)
+	if response := issuance.Invoke(admin, certificates, "certificate:InitLedger", testFixtures); response.Status != shim.OK {
+		t.Fatalf("InitLedger: %s", response.Message)
+	}
+
+	channel := mockstub.NewStub("verification")
+	channel.RegisterChaincode("certificates", certificates, issuance)
+	contract := new(ContractVerification)
+	contract.Name = lus.ContractNameVerification
+	verifier := newChaincode(t, contract)
+
+	request := `{"id":"CERT20221122103007"}`
+	response := channel.Invoke(ministry, verifier, "verification:VerifyCertificate", request)
+	expectCode(t, response.Message, lus.CodeInvalidSettings)
+
+	settings := `{"chaincode_name":"certificates","channel_id":"issuance"}`
+	response = channel.Invoke(ministry, verifier, "verification:UpdateSettings", settings)
+	expectCode(t, response.Message, lus.CodeNotAdmin)
+	if response = channel.Invoke(admin, verifier, "verification:UpdateSettings", settings); response.Status != shim.OK {
+		t.Fatalf("UpdateSettings: %s", response.Message)
+	}
+
+	response = channel.Invoke(ministry, verifier, "verification:VerifyCertificate", request)
+	if response.Status != shim.OK {
+		t.Fatalf("VerifyCertificate: %s", response.Message)
+	}
+	var verification certificate.Verification
+	if err := json.Unmarshal(response.Payload, &verification); err != nil {
+		t.Fatal(err)
+	}
+	if verification.ID != "CERT20221122103007" || verification.Certification != "Licenciado en Derecho" ||
+		verification.Emitter != "Universidad de La Habana" || !verification.GoldCertificate || verification.Date != "2010-11-08" {
+		t.Errorf("verification = %+v", verification)
+	}
+	if !verification.Valid || verification.Status != certificate.Valid || verification.StatusLabel != "Válido" {
+		t.Errorf("status = %d %s, valid %v", verification.Status, verification.StatusLabel, verification.Valid)
+	}
+	// the holder, the signatories and the registry book entries are not disclosed
+	for _, private := range []string{"GRAD20221122103005", "holder_id", "Miriam Nicado", "volume_folio"} {
+		if strings.Contains(string(response.Payload), private) {
+			t.Errorf("the verification discloses %s: %s", private, response.Payload)
+		}
+	}
+
+	// the language of the request reaches the certificate chaincode
+	channel.SetTransient(lus.TransientLanguage, []byte("en"))
+	response = channel.Invoke(ministry, verifier, "verification:VerifyCertificate", request)
+	_ = json.Unmarshal(response.Payload, &verification)
+	if verification.StatusLabel != "Valid" {
+		t.Errorf("English status label = %s", verification.StatusLabel)
+	}
+
+	// the errors of the certificate chaincode keep their code
+	response = channel.Invoke(ministry, verifier, "verification:VerifyCertificate", `{"id":"CERT20000101000000"}`)
+	expectCode(t, response.Message, lus.CodeNotFound)
+
+	settings = `{"chaincode_name":"unknown","channel_id":"issuance"}`
+	if response = channel.Invoke(admin, verifier, "verification:UpdateSettings", settings); response.Status != shim.OK {
+		t.Fatalf("UpdateSettings: %s", response.Message)
+	}
+	response = channel.Invoke(ministry, verifier, "verification:VerifyCertificate", request)
+	expectCode(t, response.Message, lus.CodeInvokeChaincode)
+}
@@ -0,0 +1,46 @@
+package lib_utils
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Audit fields stamped by the chaincode on every document it writes. Values sent by
+// the client are always overwritten, they are optional only for the request schema.
+type Audit struct {
+	CreatedAt string `json:"created_at" metadata:",optional"`
+	CreatedBy string `json:"created_by" metadata:",optional"`
+	UpdatedAt string `json:"updated_at" metadata:",optional"`
+	UpdatedBy string `json:"updated_by" metadata:",optional"`
+	LastTxID  string `json:"last_tx_id" metadata:",optional"`
+}
+
+// NewAudit returns the audit fields of a document created in the current transaction
+func NewAudit(ctx contractapi.TransactionContextInterface) (Audit, error) {
+	var audit Audit
+	err := audit.Stamp(ctx)
+	if err != nil {
+		return audit, err
+	}
+	audit.CreatedAt = audit.UpdatedAt
+	audit.CreatedBy = audit.UpdatedBy
+
+	return audit, nil
+}
+
+// Stamp records the current transaction as the last modification of the document
+func (a *Audit) Stamp(ctx contractapi.TransactionContextInterface) error {
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return err
+	}
+	clientID, err := GetClientID(ctx)
+	if err != nil {
+		return err
+	}
+
+	a.UpdatedAt = GetTimestampRFC3339(timestamp)
+	a.UpdatedBy = clientID
+	a.LastTxID = ctx.GetStub().GetTxID()
+
+	return nil
+}
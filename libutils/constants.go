@@ -1,7 +1,7 @@
 package lib_utils
 
 // Error responses
-// errorXXX occurs when XXX
+// errorXXX occurs when XXX. Create them with Errorf, every format needs its code in errors.go
 const (
 	ErrorParseJws                 = `error parsing into JWS`
 	ErrorParseX509                = `error parsing into X509`
@@ -20,17 +20,148 @@ const (
 	ErrorInconsistentStatus       = "validators data is inconsistent with info from status"
 	ErrorInconsistentInvalidation = "if asset is invalid it needs a description why"
 	ErrorInconsistentValidation   = "error validating certificate"
+	ErrorInvalidDate              = "invalid date %s, expected YYYY-MM-DD"
+	ErrorFutureDate               = "the date %s is later than the transaction date"
+	ErrorInvalidDateRange         = "invalid date range, %s is later than %s"
+	ErrorClientIdentity           = "unable to get the client identity: %v"
+	ErrorNotAdmin                 = "the client identity is not an administrator"
+	ErrorForbiddenMSP             = "members of %s are not allowed to manage %s"
+	ErrorInactive                 = "%s is not active"
+	ErrorFacultyInstitution       = "faculty %s does not belong to institution %s"
+	ErrorInvalidRole              = "invalid signatory role %v"
+	ErrorInvalidTerm              = "the term end %s is earlier than the term start %s"
+	ErrorTermOverlap              = "the term of office overlaps with the term of signatory %s"
+	ErrorSignatoryRole            = "signatory %s does not hold the role %v"
+	ErrorSignatoryScope           = "signatory %s does not hold office in %s"
+	ErrorSignatoryTerm            = "signatory %s is not in office on %s"
+	ErrorSignerIdentity           = "the client identity does not match the certificate of signatory %s"
+	ErrorInvalidLevel             = "invalid program level %v"
+	ErrorInvalidYears             = "invalid active years %d-%d"
+	ErrorProgramFaculty           = "program %s is not offered by faculty %s"
+	ErrorProgramYear              = "program %s is not offered in %d"
+	ErrorTransientMissing         = "the transient field %s is required"
+	ErrorIdentityIncomplete       = "the graduate full name and national id are required"
+	ErrorInvalidVolumeFolio       = "invalid registry book entry %s, expected volume,folio"
+	ErrorInvalidRegistryBook      = "invalid registry book %s"
+	ErrorFolioTaken               = "the entry %s of the %s registry book of %s is already assigned to %s"
+	ErrorInvalidSettings          = "invalid settings: %s"
+	ErrorBatchSize                = "the batch has %d items, the limit is between 1 and %d"
+	ErrorBatchDuplicated          = "%s is repeated in the batch"
+	ErrorBatchFailed              = "%d of %d items of the batch failed: %s"
+	ErrorAlreadyInitialized       = "the ledger was already initialized"
+	ErrorFixture                  = "invalid fixture %s: %v"
+	ErrorSchemaVersion            = "%s has schema version %d, later than the supported version %d"
+	ErrorSchemaUpgrade            = "unable to upgrade %s from schema version %d: %v"
+	ErrorInvalidID                = "invalid id %s, expected a %s id"
+	ErrorCompositeKey             = "error creating compound key for: %v"
+	ErrorInvalidFunction          = "invalid function %s passed with args %v"
+	ErrorMissingQuery             = "missing query string"
+	ErrorEndorsementPolicy        = "invalid endorsement policy of %s: %v"
+	ErrorNotIssuer                = "institution %s is not an issuer of %s"
+	ErrorIssuerDuplicated         = "institution %s is repeated among the issuers of %s"
+	ErrorInvokeChaincode          = "error invoking chaincode %s on channel %s: %s"
+	ErrorCertificateNotValid      = "certificate %s is not valid"
+	ErrorLegalizationStatus       = "the legalization of %s is %v, expected %v"
+	ErrorLegalizationReason       = "a rejected legalization requires the reason of the rejection"
+	ErrorApostilleNumber          = "the apostille number is required"
+	ErrorEmptyTranscript          = "the transcript has no courses"
+	ErrorCourseIncomplete         = "the code and name of every course are required"
+	ErrorCourseDuplicated         = "course %s is repeated in the transcript"
+	ErrorInvalidGrade             = "invalid grade %d of course %s, expected %d to %d"
+	ErrorInvalidCredits           = "invalid credits %d of course %s"
+	ErrorTranscriptExists         = "certificate %s already has the transcript %s"
+	ErrorTranscriptSigner         = "signatory %s did not sign the certificate %s as %v"
+	ErrorInvalidHonorsLevel       = "invalid honors level %v"
+	ErrorInvalidHonorsRule        = "invalid honors rule of level %v, the minimum GPA must be between 0 and 5 and the failed courses not negative"
+	ErrorHonorsRuleDuplicated     = "level %v has more than one honors rule"
+	ErrorHonorsReason             = "an honors override requires its reason"
+	ErrorHonorsLocked             = "the honors of %s can not change once it is valid or invalid"
+	ErrorInvalidGrantScope        = "invalid grant scope %s"
+	ErrorGrantAudience            = "the audience of the grant is required"
+	ErrorGrantExpiry              = "invalid grant expiry %s, expected a time after the transaction and at most %d days later"
+	ErrorGrantToken               = "the grant token must have at least %d characters"
+	ErrorNotHolder                = "the client identity is not the holder %s"
+	ErrorGrantDenied              = "grant %s does not allow the access: %s"
+	ErrorVerificationPurpose      = "the purpose of the verification is required"
+	ErrorIssuanceLimit            = "the client identity reached its limit of %d certificates on %s"
+	ErrorCertificateFlagged       = "certificate %s is flagged as suspicious and waits for review"
+	ErrorNotFlagged               = "certificate %s does not wait for review"
+	ErrorReviewReason             = "a rejected review requires the reason of the rejection"
+	ErrorSelfReview               = "certificate %s can not be reviewed by the identity that issued it"
+	ErrorDuplicateCertificate     = "graduate %s already has the certificate %s of program %s"
+	ErrorInvalidReissue           = "certificate %s can not be reissued as %s, expected a certificate of the same emitter, holder and program that is not invalid"
+	ErrorMissingMSP               = "institution %s has no MSP id, set its msp_id with UpdateInstitution"
+	ErrorSignedLocked             = "the %s of %s can not change once it is signed"
+	ErrorIdentityKey              = "the identity key must have at least %d bytes"
+	ErrorIdentityKeyMissing       = "the identity key is not set, an administrator must set it with SetIdentityKey"
 )
 
 // Each code must be 4 characters
 
 const (
-	CodCert        = "CERT"
-	DocTypeDeleted = "DELETED"
+	CodCert           = "CERT"
+	CodInstitution    = "INST"
+	CodFaculty        = "FACU"
+	CodSignatory      = "SIGN"
+	CodSignRole       = "SGRL" // index of signatories by institution, faculty and role
+	CodProgram        = "PROG"
+	CodProgramCode    = "PRCD" // index of programs by institution and official code
+	CodGraduate       = "GRAD"
+	CodIdentity       = "IDNT" // private index of graduates by national id
+	CodIdentityKey    = "IDKY" // secret key of the private index of graduates
+	CodHolder         = "HLDR" // index of certificates by graduate
+	CodHolderProgram  = "HLPG" // unique index of the certificates not invalid by graduate and program
+	CodFacultyBook    = "FOLF" // index of certificates by faculty registry book entry
+	CodUnivBook       = "FOLU" // index of certificates by university registry book entry
+	CodSettings       = "CONF"
+	CodLegalization   = "LGLZ" // legalization of a certificate, with the digits of the certificate id
+	CodTranscript     = "TRSC"
+	CodCertTranscript = "TRCT" // index of transcripts by certificate
+	CodGrant          = "GRNT" // access to the private data of a certificate granted by its holder
+	CodVerifyLog      = "VLOG" // verification log entries, by certificate and transaction
+	CodIssuance       = "ISSU" // certificates issued by a client identity in a day, for the rate limit
+	CodFlagged        = "FLAG" // index of the certificates waiting for review, by emitter
+	DocTypeDeleted    = "DELETED"
 )
 
+// keys of the CodSettings documents
+const (
+	InitLedgerKey = "init"
+)
+
+// chaincode events
+const (
+	EventCertificatesValidated = "CertificatesValidated"
+	EventLegalizationUpdated   = "LegalizationUpdated"
+	EventCertificateVerified   = "CertificateVerified"
+)
+
+// client identity attributes
+const (
+	AttrType     = "hf.Type" // added by Fabric CA to every enrollment certificate
+	AdminType    = "admin"
+	AttrLanguage = "lang"        // preferred language of the client, see i18n.go
+	AttrGraduate = "graduate_id" // graduate registry id of a holder, added by the CA of the registering institution
+)
+
+// transient field selecting the language of a request, it takes precedence over AttrLanguage
+const TransientLanguage = "lang"
+
+// transient field holding the secret token of a grant, only its hash is stored
+const TransientGrantToken = "grant_token"
+
 // contract name
 const (
-	ContractNameCommon      = "common"
-	ContractNameCertificate = "certificate"
+	ContractNameCommon       = "common"
+	ContractNameCertificate  = "certificate"
+	ContractNameInstitution  = "institution"
+	ContractNameSignatory    = "signatory"
+	ContractNameProgram      = "program"
+	ContractNameGraduate     = "graduate"
+	ContractNameVerification = "verification"
+)
+
+// private data collections, see collections_config.json
+const (
+	CollectionGraduates = "collectionGraduates"
 )
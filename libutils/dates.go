@@ -0,0 +1,103 @@
+package lib_utils
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+)
+
+// DateLayout ISO-8601 calendar date layout used for every date stored in the ledger.
+// Dates in this layout sort lexicographically, so they can be range-queried as strings.
+const DateLayout = "2006-01-02"
+
+var spanishMonths = map[string]time.Month{
+	"enero":      time.January,
+	"febrero":    time.February,
+	"marzo":      time.March,
+	"abril":      time.April,
+	"mayo":       time.May,
+	"junio":      time.June,
+	"julio":      time.July,
+	"agosto":     time.August,
+	"septiembre": time.September,
+	"setiembre":  time.September,
+	"octubre":    time.October,
+	"noviembre":  time.November,
+	"diciembre":  time.December,
+}
+
+// ex: "8 de Noviembre del 2010", "10 de julio de 2018"
+var spanishLongDate = regexp.MustCompile(`^(\d{1,2})\s+de\s+(\p{L}+)\s+(?:del?\s+)?(\d{4})$`)
+
+// ParseDate parses a date either in ISO-8601 (2010-11-08) or in the Spanish long
+// format used by the first records of the ledger (8 de Noviembre del 2010).
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	if date, err := time.Parse(DateLayout, value); err == nil {
+		return date, nil
+	}
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+
+	match := spanishLongDate.FindStringSubmatch(strings.ToLower(value))
+	if match == nil {
+		return time.Time{}, Errorf(ErrorInvalidDate, value)
+	}
+	month, ok := spanishMonths[match[2]]
+	if !ok {
+		return time.Time{}, Errorf(ErrorInvalidDate, value)
+	}
+	day, _ := strconv.Atoi(match[1])
+	year, _ := strconv.Atoi(match[3])
+
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	// time.Date normalizes out of range days (31 de Febrero), reject them instead
+	if date.Day() != day {
+		return time.Time{}, Errorf(ErrorInvalidDate, value)
+	}
+
+	return date, nil
+}
+
+// NormalizeDate parses value with ParseDate and returns it formatted with DateLayout
+func NormalizeDate(value string) (string, error) {
+	date, err := ParseDate(value)
+	if err != nil {
+		return "", err
+	}
+	return date.Format(DateLayout), nil
+}
+
+// GetTxTime returns the timestamp of the transaction proposal in UTC
+func GetTxTime(stub shim.ChaincodeStubInterface) (time.Time, error) {
+	timestamp, err := stub.GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC(), nil
+}
+
+// ValidatePastDate checks that the date in value (see ParseDate) is not later than
+// the day of the transaction timestamp, and returns it normalized with DateLayout.
+func ValidatePastDate(stub shim.ChaincodeStubInterface, value string) (string, error) {
+	date, err := ParseDate(value)
+	if err != nil {
+		return "", err
+	}
+	txTime, err := GetTxTime(stub)
+	if err != nil {
+		return "", err
+	}
+
+	normalized := date.Format(DateLayout)
+	if normalized > txTime.Format(DateLayout) {
+		return "", Errorf(ErrorFutureDate, normalized)
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,380 @@
+package lib_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ErrorCategory HTTP-like category of an error, clients can map it to a status without reading the code
+type ErrorCategory string
+
+const (
+	CategoryInvalid   ErrorCategory = "invalid"   // the request is malformed or breaks a business rule
+	CategoryForbidden ErrorCategory = "forbidden" // the client identity is not allowed to do it
+	CategoryNotFound  ErrorCategory = "not_found" // a referenced document does not exist
+	CategoryConflict  ErrorCategory = "conflict"  // the current state of the ledger does not allow it
+	CategoryInternal  ErrorCategory = "internal"  // the chaincode or the peer failed
+)
+
+// Status returns the HTTP status code matching the category
+func (category ErrorCategory) Status() int {
+	switch category {
+	case CategoryInvalid:
+		return 400
+	case CategoryForbidden:
+		return 403
+	case CategoryNotFound:
+		return 404
+	case CategoryConflict:
+		return 409
+	}
+	return 500
+}
+
+// Error error returned by the transactions. Its message is the JSON encoding of the error, so
+// client SDKs can decode it from the response instead of matching the text.
+type Error struct {
+	Code     string                 `json:"code"`
+	Category ErrorCategory          `json:"category"`
+	Status   int                    `json:"status"`
+	Message  string                 `json:"message"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+	// Messages message in every language of the catalog, NewLocalizedChaincode keeps the one of the request
+	Messages map[Language]string `json:"messages,omitempty"`
+	cause    error
+}
+
+func (e *Error) Error() string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(e); err != nil {
+		return e.Message
+	}
+	return string(bytes.TrimRight(buffer.Bytes(), "\n"))
+}
+
+// Unwrap returns the error that caused this one, if any
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an *Error with the same code, ex: errors.Is(err, &Error{Code: CodeNotFound})
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.Code == e.Code
+}
+
+// WithDetail adds a detail to the error and returns it
+func (e *Error) WithDetail(name string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[name] = value
+	return e
+}
+
+// stable codes of the errors, clients rely on them so they must never change
+const (
+	CodeInvalidJWS           = "INVALID_JWS"
+	CodeInvalidX509          = "INVALID_X509"
+	CodeInvalidBase64        = "INVALID_BASE64"
+	CodeInvalidSignature     = "INVALID_SIGNATURE"
+	CodeInvalidOperation     = "INVALID_OPERATION"
+	CodeWorldState           = "WORLD_STATE"
+	CodeNotFound             = "NOT_FOUND"
+	CodeAlreadyExists        = "ALREADY_EXISTS"
+	CodeSameID               = "SAME_ID"
+	CodeInvalidJSON          = "INVALID_JSON"
+	CodeMarshal              = "MARSHAL"
+	CodeInvalidKey           = "INVALID_KEY"
+	CodeInconsistentStatus   = "INCONSISTENT_STATUS"
+	CodeMissingReason        = "MISSING_INVALIDATION_REASON"
+	CodeValidation           = "VALIDATION_FAILED"
+	CodeInvalidDate          = "INVALID_DATE"
+	CodeFutureDate           = "FUTURE_DATE"
+	CodeInvalidDateRange     = "INVALID_DATE_RANGE"
+	CodeClientIdentity       = "CLIENT_IDENTITY"
+	CodeNotAdmin             = "NOT_ADMIN"
+	CodeForbiddenMSP         = "FORBIDDEN_MSP"
+	CodeInactive             = "INACTIVE"
+	CodeFacultyInstitution   = "FACULTY_INSTITUTION_MISMATCH"
+	CodeInvalidRole          = "INVALID_ROLE"
+	CodeInvalidTerm          = "INVALID_TERM"
+	CodeTermOverlap          = "TERM_OVERLAP"
+	CodeSignatoryRole        = "SIGNATORY_ROLE"
+	CodeSignatoryScope       = "SIGNATORY_SCOPE"
+	CodeSignatoryTerm        = "SIGNATORY_TERM"
+	CodeSignerIdentity       = "SIGNER_IDENTITY"
+	CodeInvalidLevel         = "INVALID_LEVEL"
+	CodeInvalidYears         = "INVALID_YEARS"
+	CodeProgramFaculty       = "PROGRAM_FACULTY_MISMATCH"
+	CodeProgramYear          = "PROGRAM_YEAR"
+	CodeTransientMissing     = "TRANSIENT_MISSING"
+	CodeIdentityIncomplete   = "IDENTITY_INCOMPLETE"
+	CodeInvalidVolumeFolio   = "INVALID_VOLUME_FOLIO"
+	CodeInvalidRegistryBook  = "INVALID_REGISTRY_BOOK"
+	CodeFolioTaken           = "FOLIO_TAKEN"
+	CodeInvalidSettings      = "INVALID_SETTINGS"
+	CodeBatchSize            = "BATCH_SIZE"
+	CodeBatchDuplicated      = "BATCH_DUPLICATED"
+	CodeBatchFailed          = "BATCH_FAILED"
+	CodeAlreadyInitialized   = "ALREADY_INITIALIZED"
+	CodeInvalidFixture       = "INVALID_FIXTURE"
+	CodeSchemaVersion        = "SCHEMA_VERSION"
+	CodeSchemaUpgrade        = "SCHEMA_UPGRADE"
+	CodeInvalidID            = "INVALID_ID"
+	CodeInvalidFunction      = "INVALID_FUNCTION"
+	CodeMissingQuery         = "MISSING_QUERY"
+	CodeEndorsementPolicy    = "INVALID_ENDORSEMENT_POLICY"
+	CodeNotIssuer            = "NOT_ISSUER"
+	CodeIssuerDuplicated     = "ISSUER_DUPLICATED"
+	CodeInvokeChaincode      = "INVOKE_CHAINCODE"
+	CodeCertificateNotValid  = "CERTIFICATE_NOT_VALID"
+	CodeLegalizationStatus   = "LEGALIZATION_STATUS"
+	CodeLegalizationReason   = "MISSING_LEGALIZATION_REASON"
+	CodeApostilleNumber      = "MISSING_APOSTILLE_NUMBER"
+	CodeEmptyTranscript      = "EMPTY_TRANSCRIPT"
+	CodeCourseIncomplete     = "COURSE_INCOMPLETE"
+	CodeCourseDuplicated     = "COURSE_DUPLICATED"
+	CodeInvalidGrade         = "INVALID_GRADE"
+	CodeInvalidCredits       = "INVALID_CREDITS"
+	CodeTranscriptExists     = "TRANSCRIPT_EXISTS"
+	CodeTranscriptSigner     = "TRANSCRIPT_SIGNER"
+	CodeInvalidHonorsLevel   = "INVALID_HONORS_LEVEL"
+	CodeInvalidHonorsRule    = "INVALID_HONORS_RULE"
+	CodeHonorsRuleDuplicated = "HONORS_RULE_DUPLICATED"
+	CodeHonorsReason         = "MISSING_HONORS_REASON"
+	CodeHonorsLocked         = "HONORS_LOCKED"
+	CodeInvalidGrantScope    = "INVALID_GRANT_SCOPE"
+	CodeGrantAudience        = "MISSING_GRANT_AUDIENCE"
+	CodeGrantExpiry          = "INVALID_GRANT_EXPIRY"
+	CodeGrantToken           = "WEAK_GRANT_TOKEN"
+	CodeNotHolder            = "NOT_HOLDER"
+	CodeGrantDenied          = "GRANT_DENIED"
+	CodeVerificationPurpose  = "MISSING_VERIFICATION_PURPOSE"
+	CodeIssuanceLimit        = "ISSUANCE_LIMIT"
+	CodeCertificateFlagged   = "CERTIFICATE_FLAGGED"
+	CodeNotFlagged           = "NOT_FLAGGED"
+	CodeReviewReason         = "MISSING_REVIEW_REASON"
+	CodeSelfReview           = "SELF_REVIEW"
+	CodeDuplicateCertificate = "DUPLICATE_CERTIFICATE"
+	CodeInvalidReissue       = "INVALID_REISSUE"
+	CodeMissingMSP           = "MISSING_MSP_ID"
+	CodeSignedLocked         = "SIGNED_LOCKED"
+	CodeIdentityKey          = "INVALID_IDENTITY_KEY"
+	CodeIdentityKeyMissing   = "IDENTITY_KEY_MISSING"
+	CodeInternal             = "INTERNAL"
+)
+
+// errorSpec code, category and names of the arguments of an error format
+type errorSpec struct {
+	code     string
+	category ErrorCategory
+	params   []string
+}
+
+// errorSpecs specs of the error formats of constants.go. Each argument of a format
+// is reported in the details under the matching param name.
+var errorSpecs = map[string]errorSpec{
+	ErrorParseJws:                 {CodeInvalidJWS, CategoryInvalid, nil},
+	ErrorParseX509:                {CodeInvalidX509, CategoryInvalid, nil},
+	ErrorBase64:                   {CodeInvalidBase64, CategoryInvalid, nil},
+	ErrorVerifying:                {CodeInvalidSignature, CategoryInvalid, nil},
+	ErrorInvalidSpecificOperation: {CodeInvalidOperation, CategoryConflict, []string{"state", "operation"}},
+	ErrorInvalidOperation:         {CodeInvalidOperation, CategoryConflict, nil},
+	ErrorFailedWorldState:         {CodeWorldState, CategoryInternal, []string{"key", "cause"}},
+	ErrorWorldState:               {CodeWorldState, CategoryInternal, []string{"cause"}},
+	ErrorNotExistInState:          {CodeNotFound, CategoryNotFound, []string{"id"}},
+	ErrorAlreadyExistInState:      {CodeAlreadyExists, CategoryConflict, []string{"id"}},
+	ErrorIDSame:                   {CodeSameID, CategoryInvalid, nil},
+	ErrorUnmarshal:                {CodeInvalidJSON, CategoryInvalid, []string{"cause"}},
+	ErrorMarshal:                  {CodeMarshal, CategoryInternal, []string{"cause"}},
+	ErrorGenerateKey:              {CodeInvalidKey, CategoryInternal, nil},
+	ErrorInconsistentStatus:       {CodeInconsistentStatus, CategoryInvalid, nil},
+	ErrorInconsistentInvalidation: {CodeMissingReason, CategoryInvalid, nil},
+	ErrorInconsistentValidation:   {CodeValidation, CategoryConflict, nil},
+	ErrorInvalidDate:              {CodeInvalidDate, CategoryInvalid, []string{"date"}},
+	ErrorFutureDate:               {CodeFutureDate, CategoryInvalid, []string{"date"}},
+	ErrorInvalidDateRange:         {CodeInvalidDateRange, CategoryInvalid, []string{"start", "end"}},
+	ErrorClientIdentity:           {CodeClientIdentity, CategoryForbidden, []string{"cause"}},
+	ErrorNotAdmin:                 {CodeNotAdmin, CategoryForbidden, nil},
+	ErrorForbiddenMSP:             {CodeForbiddenMSP, CategoryForbidden, []string{"msp_id", "id"}},
+	ErrorInactive:                 {CodeInactive, CategoryConflict, []string{"id"}},
+	ErrorFacultyInstitution:       {CodeFacultyInstitution, CategoryInvalid, []string{"faculty_id", "institution_id"}},
+	ErrorInvalidRole:              {CodeInvalidRole, CategoryInvalid, []string{"role"}},
+	ErrorInvalidTerm:              {CodeInvalidTerm, CategoryInvalid, []string{"term_end", "term_start"}},
+	ErrorTermOverlap:              {CodeTermOverlap, CategoryConflict, []string{"signatory_id"}},
+	ErrorSignatoryRole:            {CodeSignatoryRole, CategoryForbidden, []string{"signatory_id", "role"}},
+	ErrorSignatoryScope:           {CodeSignatoryScope, CategoryForbidden, []string{"signatory_id", "scope_id"}},
+	ErrorSignatoryTerm:            {CodeSignatoryTerm, CategoryForbidden, []string{"signatory_id", "date"}},
+	ErrorSignerIdentity:           {CodeSignerIdentity, CategoryForbidden, []string{"signatory_id"}},
+	ErrorInvalidLevel:             {CodeInvalidLevel, CategoryInvalid, []string{"level"}},
+	ErrorInvalidYears:             {CodeInvalidYears, CategoryInvalid, []string{"first_year", "last_year"}},
+	ErrorProgramFaculty:           {CodeProgramFaculty, CategoryInvalid, []string{"program_id", "faculty_id"}},
+	ErrorProgramYear:              {CodeProgramYear, CategoryInvalid, []string{"program_id", "year"}},
+	ErrorTransientMissing:         {CodeTransientMissing, CategoryInvalid, []string{"field"}},
+	ErrorIdentityIncomplete:       {CodeIdentityIncomplete, CategoryInvalid, nil},
+	ErrorInvalidVolumeFolio:       {CodeInvalidVolumeFolio, CategoryInvalid, []string{"entry"}},
+	ErrorInvalidRegistryBook:      {CodeInvalidRegistryBook, CategoryInvalid, []string{"book"}},
+	ErrorFolioTaken:               {CodeFolioTaken, CategoryConflict, []string{"entry", "book", "owner_id", "certificate_id"}},
+	ErrorInvalidSettings:          {CodeInvalidSettings, CategoryInvalid, []string{"cause"}},
+	ErrorBatchSize:                {CodeBatchSize, CategoryInvalid, []string{"size", "limit"}},
+	ErrorBatchDuplicated:          {CodeBatchDuplicated, CategoryInvalid, []string{"id"}},
+	ErrorBatchFailed:              {CodeBatchFailed, CategoryInvalid, []string{"failed", "total", "failures"}},
+	ErrorAlreadyInitialized:       {CodeAlreadyInitialized, CategoryConflict, nil},
+	ErrorFixture:                  {CodeInvalidFixture, CategoryInvalid, []string{"fixture", "cause"}},
+	ErrorSchemaVersion:            {CodeSchemaVersion, CategoryInternal, []string{"id", "version", "supported_version"}},
+	ErrorSchemaUpgrade:            {CodeSchemaUpgrade, CategoryInternal, []string{"id", "version", "cause"}},
+	ErrorInvalidID:                {CodeInvalidID, CategoryInvalid, []string{"id", "doc_type"}},
+	ErrorCompositeKey:             {CodeInvalidKey, CategoryInternal, []string{"id"}},
+	ErrorInvalidFunction:          {CodeInvalidFunction, CategoryInvalid, []string{"function", "args"}},
+	ErrorMissingQuery:             {CodeMissingQuery, CategoryInvalid, nil},
+	ErrorEndorsementPolicy:        {CodeEndorsementPolicy, CategoryInvalid, []string{"id", "cause"}},
+	ErrorNotIssuer:                {CodeNotIssuer, CategoryInvalid, []string{"institution_id", "id"}},
+	ErrorIssuerDuplicated:         {CodeIssuerDuplicated, CategoryInvalid, []string{"institution_id", "id"}},
+	ErrorInvokeChaincode:          {CodeInvokeChaincode, CategoryInternal, []string{"chaincode", "channel", "cause"}},
+	ErrorCertificateNotValid:      {CodeCertificateNotValid, CategoryConflict, []string{"id"}},
+	ErrorLegalizationStatus:       {CodeLegalizationStatus, CategoryConflict, []string{"id", "status", "expected"}},
+	ErrorLegalizationReason:       {CodeLegalizationReason, CategoryInvalid, nil},
+	ErrorApostilleNumber:          {CodeApostilleNumber, CategoryInvalid, nil},
+	ErrorEmptyTranscript:          {CodeEmptyTranscript, CategoryInvalid, nil},
+	ErrorCourseIncomplete:         {CodeCourseIncomplete, CategoryInvalid, nil},
+	ErrorCourseDuplicated:         {CodeCourseDuplicated, CategoryInvalid, []string{"course"}},
+	ErrorInvalidGrade:             {CodeInvalidGrade, CategoryInvalid, []string{"grade", "course", "min_grade", "max_grade"}},
+	ErrorInvalidCredits:           {CodeInvalidCredits, CategoryInvalid, []string{"credits", "course"}},
+	ErrorTranscriptExists:         {CodeTranscriptExists, CategoryConflict, []string{"certificate_id", "transcript_id"}},
+	ErrorTranscriptSigner:         {CodeTranscriptSigner, CategoryForbidden, []string{"signatory_id", "certificate_id", "role"}},
+	ErrorInvalidHonorsLevel:       {CodeInvalidHonorsLevel, CategoryInvalid, []string{"level"}},
+	ErrorInvalidHonorsRule:        {CodeInvalidHonorsRule, CategoryInvalid, []string{"level"}},
+	ErrorHonorsRuleDuplicated:     {CodeHonorsRuleDuplicated, CategoryInvalid, []string{"level"}},
+	ErrorHonorsReason:             {CodeHonorsReason, CategoryInvalid, nil},
+	ErrorHonorsLocked:             {CodeHonorsLocked, CategoryConflict, []string{"id"}},
+	ErrorInvalidGrantScope:        {CodeInvalidGrantScope, CategoryInvalid, []string{"scope"}},
+	ErrorGrantAudience:            {CodeGrantAudience, CategoryInvalid, nil},
+	ErrorGrantExpiry:              {CodeGrantExpiry, CategoryInvalid, []string{"expires_at", "max_days"}},
+	ErrorGrantToken:               {CodeGrantToken, CategoryInvalid, []string{"min_length"}},
+	ErrorNotHolder:                {CodeNotHolder, CategoryForbidden, []string{"id"}},
+	ErrorGrantDenied:              {CodeGrantDenied, CategoryForbidden, []string{"id", "reason"}},
+	ErrorVerificationPurpose:      {CodeVerificationPurpose, CategoryInvalid, nil},
+	ErrorIssuanceLimit:            {CodeIssuanceLimit, CategoryForbidden, []string{"limit", "date"}},
+	ErrorCertificateFlagged:       {CodeCertificateFlagged, CategoryConflict, []string{"id"}},
+	ErrorNotFlagged:               {CodeNotFlagged, CategoryConflict, []string{"id"}},
+	ErrorReviewReason:             {CodeReviewReason, CategoryInvalid, nil},
+	ErrorSelfReview:               {CodeSelfReview, CategoryForbidden, []string{"id"}},
+	ErrorDuplicateCertificate:     {CodeDuplicateCertificate, CategoryConflict, []string{"holder_id", "id", "program_id"}},
+	ErrorInvalidReissue:           {CodeInvalidReissue, CategoryConflict, []string{"id", "reissue_id"}},
+	ErrorMissingMSP:               {CodeMissingMSP, CategoryInvalid, []string{"id"}},
+	ErrorSignedLocked:             {CodeSignedLocked, CategoryConflict, []string{"field", "id"}},
+	ErrorIdentityKey:              {CodeIdentityKey, CategoryInvalid, []string{"min_length"}},
+	ErrorIdentityKeyMissing:       {CodeIdentityKeyMissing, CategoryConflict, nil},
+}
+
+// Errorf returns the *Error of a format of constants.go, with its message rendered in every language.
+// Errors passed as arguments are reported by their message, and the first of them is kept as the cause.
+func Errorf(format string, args ...interface{}) *Error {
+	spec, ok := errorSpecs[format]
+	if !ok {
+		spec = errorSpec{CodeInternal, CategoryInternal, nil}
+	}
+
+	var cause error
+	details := make(map[string]interface{})
+	for i, arg := range args {
+		if err, ok := arg.(error); ok && cause == nil {
+			cause = err
+		}
+		if i < len(spec.params) {
+			details[spec.params[i]] = arg
+			if err, ok := arg.(error); ok {
+				details[spec.params[i]] = AsError(err)
+			}
+		}
+	}
+	if len(details) == 0 {
+		details = nil
+	}
+
+	messages := make(map[Language]string, len(Languages))
+	for _, lang := range Languages {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			switch arg := arg.(type) {
+			case error:
+				values[i] = AsError(arg).Localize(lang).Message
+			case Localizable:
+				values[i] = arg.Localize(lang)
+			default:
+				values[i] = arg
+			}
+		}
+		messages[lang] = fmt.Sprintf(Translate(lang, format), values...)
+	}
+
+	return &Error{
+		Code:     spec.code,
+		Category: spec.category,
+		Status:   spec.category.Status(),
+		Message:  messages[LangEn],
+		Details:  details,
+		Messages: messages,
+		cause:    cause,
+	}
+}
+
+// Localize returns a copy of the error with its message, and the messages of the errors in its details, in lang
+func (e *Error) Localize(lang Language) *Error {
+	localized := *e
+	if message, ok := e.Messages[lang]; ok {
+		localized.Message = message
+	}
+	localized.Messages = nil
+	if e.Details != nil {
+		localized.Details = make(map[string]interface{}, len(e.Details))
+		for name, value := range e.Details {
+			if err, ok := value.(*Error); ok {
+				value = err.Localize(lang)
+			}
+			localized.Details[name] = value
+		}
+	}
+	return &localized
+}
+
+// LocalizedMessage returns the message of err in the language of the request
+func LocalizedMessage(ctx contractapi.TransactionContextInterface, err error) string {
+	return AsError(err).Localize(GetLanguage(ctx)).Message
+}
+
+// AsError returns err as an *Error, errors not created with Errorf are internal errors
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return &Error{Code: CodeInternal, Category: CategoryInternal, Status: CategoryInternal.Status(), Message: err.Error(), cause: err}
+}
+
+// ParseError decodes the JSON message of an *Error, ex: the response of a chaincode invoked with
+// InvokeChaincode. It reports false if message is not the message of an *Error.
+func ParseError(message string) (*Error, bool) {
+	var e Error
+	if err := json.Unmarshal([]byte(message), &e); err != nil || e.Code == "" {
+		return nil, false
+	}
+	return &e, true
+}
+
+// ErrorCode returns the code of err
+func ErrorCode(err error) string {
+	return AsError(err).Code
+}
+
+// ErrorMessage returns the human readable message of err, without the JSON encoding
+func ErrorMessage(err error) string {
+	return AsError(err).Message
+}
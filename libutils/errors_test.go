@@ -0,0 +1,71 @@
+package lib_utils
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestErrorf(t *testing.T) {
+	err := Errorf(ErrorNotExistInState, "CERT20221122103000")
+
+	var decoded map[string]interface{}
+	if e := json.Unmarshal([]byte(err.Error()), &decoded); e != nil {
+		t.Fatalf("the message is not JSON: %v", e)
+	}
+	if decoded["code"] != CodeNotFound || decoded["category"] != string(CategoryNotFound) || decoded["status"] != 404.0 {
+		t.Errorf("error = %v", decoded)
+	}
+	if decoded["message"] != "no state found for CERT20221122103000" {
+		t.Errorf("message = %v", decoded["message"])
+	}
+	if details := decoded["details"].(map[string]interface{}); details["id"] != "CERT20221122103000" {
+		t.Errorf("details = %v", details)
+	}
+
+	if !errors.Is(fmt.Errorf("wrapped: %w", err), &Error{Code: CodeNotFound}) {
+		t.Error("errors.Is does not match the code")
+	}
+}
+
+func TestErrorfCause(t *testing.T) {
+	cause := Errorf(ErrorInvalidDate, "2022-13-01")
+	err := Errorf(ErrorFixture, "certificate CERT20221122103000", cause)
+
+	if err.Code != CodeInvalidFixture || err.Category != CategoryInvalid {
+		t.Errorf("code = %s, category = %s", err.Code, err.Category)
+	}
+	// nested errors are reported by their message, and kept in the details
+	if want := "invalid fixture certificate CERT20221122103000: invalid date 2022-13-01, expected YYYY-MM-DD"; err.Message != want {
+		t.Errorf("message = %s, want %s", err.Message, want)
+	}
+	if err.Details["cause"] != cause || !errors.Is(err, &Error{Code: CodeInvalidDate}) {
+		t.Errorf("cause = %v", err.Details["cause"])
+	}
+}
+
+func TestAsError(t *testing.T) {
+	if code := ErrorCode(errors.New("boom")); code != CodeInternal {
+		t.Errorf("code of a plain error = %s", code)
+	}
+	if message := ErrorMessage(Errorf(ErrorNotAdmin)); message != ErrorNotAdmin {
+		t.Errorf("message = %s", message)
+	}
+	if err := Errorf("unknown format %s", "x"); err.Code != CodeInternal || err.Message != "unknown format x" {
+		t.Errorf("error of an unknown format = %+v", err)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	err := Errorf(ErrorNotExistInState, "CERT20221122103000")
+	parsed, ok := ParseError(err.Error())
+	if !ok || parsed.Code != CodeNotFound || parsed.Message != err.Message || parsed.Details["id"] != "CERT20221122103000" {
+		t.Errorf("parsed error = %+v", parsed)
+	}
+	for _, message := range []string{"chaincode not found", `{"status":500}`} {
+		if _, ok := ParseError(message); ok {
+			t.Errorf("%s parsed as an error", message)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package lib_utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+// Language of the messages and labels returned to the client
+type Language string
+
+const (
+	LangEs Language = "es"
+	LangEn Language = "en"
+)
+
+// DefaultLanguage language used when the request does not select one
+const DefaultLanguage = LangEs
+
+// Languages languages of the catalog, the messages of the errors are rendered in all of them
+var Languages = []Language{LangEs, LangEn}
+
+// ParseLanguage returns the language of a tag like "es", "en-US" or "es_CU"
+func ParseLanguage(tag string) (Language, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	for _, lang := range Languages {
+		if tag == string(lang) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// Localizable values rendered in the language of the request, ex: status labels and roles
+type Localizable interface {
+	Localize(lang Language) string
+}
+
+// Translate returns the translation of an English text of the catalog, or the text itself if it has none
+func Translate(lang Language, text string) string {
+	if translation, ok := catalog[lang][text]; ok {
+		return translation
+	}
+	return text
+}
+
+// GetLanguage returns the language selected by the transient field TransientLanguage, or by the
+// AttrLanguage attribute of the client identity, or DefaultLanguage
+func GetLanguage(ctx contractapi.TransactionContextInterface) Language {
+	return requestLanguage(ctx.GetStub(), ctx.GetClientIdentity())
+}
+
+func requestLanguage(stub shim.ChaincodeStubInterface, identity cid.ClientIdentity) Language {
+	if transient, err := stub.GetTransient(); err == nil {
+		if lang, ok := ParseLanguage(string(transient[TransientLanguage])); ok {
+			return lang
+		}
+	}
+	if identity != nil {
+		if value, found, err := identity.GetAttributeValue(AttrLanguage); err == nil && found {
+			if lang, ok := ParseLanguage(value); ok {
+				return lang
+			}
+		}
+	}
+	return DefaultLanguage
+}
+
+// localizedChaincode renders the errors of a chaincode in the language of the request
+type localizedChaincode struct {
+	shim.Chaincode
+}
+
+// NewLocalizedChaincode wraps cc so the messages of its errors are in the language of the request
+func NewLocalizedChaincode(cc shim.Chaincode) shim.Chaincode {
+	return &localizedChaincode{cc}
+}
+
+func (cc *localizedChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	response := cc.Chaincode.Invoke(stub)
+	if response.Status < shim.ERRORTHRESHOLD {
+		return response
+	}
+	identity, _ := cid.New(stub)
+	response.Message = LocalizeMessage(response.Message, requestLanguage(stub, identity))
+	return response
+}
+
+// LocalizeMessage renders the JSON message of an *Error, and of the errors in its details, in lang.
+// Other messages are returned as they are.
+func LocalizeMessage(message string, lang Language) string {
+	decoder := json.NewDecoder(strings.NewReader(message))
+	decoder.UseNumber()
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil || doc["code"] == nil {
+		return message
+	}
+	localizeDocument(doc, lang)
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return message
+	}
+	return string(bytes.TrimRight(buffer.Bytes(), "\n"))
+}
+
+// localizeDocument replaces the message of the decoded errors found in value by their message in lang
+func localizeDocument(value interface{}, lang Language) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if messages, ok := value["messages"].(map[string]interface{}); ok {
+			if message, ok := messages[string(lang)].(string); ok {
+				value["message"] = message
+			}
+			delete(value, "messages")
+		}
+		for _, item := range value {
+			localizeDocument(item, lang)
+		}
+	case []interface{}:
+		for _, item := range value {
+			localizeDocument(item, lang)
+		}
+	}
+}
+
+// catalog translations of the English texts: error formats of constants.go, status labels and
+// roles. Every translation must keep the verbs of the English text, in the same order.
+var catalog = map[Language]map[string]string{
+	LangEs: {
+		// error formats
+		ErrorParseJws:                 "error al interpretar el JWS",
+		ErrorParseX509:                "error al interpretar el certificado X509",
+		ErrorBase64:                   "error al decodificar base64",
+		ErrorVerifying:                "error al verificar la firma",
+		ErrorInvalidSpecificOperation: "operación no válida en el estado %s: %s",
+		ErrorInvalidOperation:         "operación no válida en el estado",
+		ErrorFailedWorldState:         "no se pudo obtener del estado %s: %s",
+		ErrorWorldState:               "no se pudo acceder al estado del ledger. %s",
+		ErrorNotExistInState:          "no existe %s en el estado",
+		ErrorAlreadyExistInState:      "%s ya existe en el estado",
+		ErrorIDSame:                   "los identificadores son iguales",
+		ErrorUnmarshal:                "error al decodificar %s",
+		ErrorMarshal:                  "error al codificar %s",
+		ErrorGenerateKey:              "error al generar la llave",
+		ErrorInconsistentStatus:       "los datos de los firmantes no corresponden con el estado",
+		ErrorInconsistentInvalidation: "un título anulado requiere el motivo de la anulación",
+		ErrorInconsistentValidation:   "error al firmar el título",
+		ErrorInvalidDate:              "fecha %s no válida, se espera AAAA-MM-DD",
+		ErrorFutureDate:               "la fecha %s es posterior a la fecha de la transacción",
+		ErrorInvalidDateRange:         "rango de fechas no válido, %s es posterior a %s",
+		ErrorClientIdentity:           "no se pudo obtener la identidad del cliente: %v",
+		ErrorNotAdmin:                 "la identidad del cliente no es de un administrador",
+		ErrorForbiddenMSP:             "los miembros de %s no pueden gestionar %s",
+		ErrorInactive:                 "%s no está activo",
+		ErrorFacultyInstitution:       "la facultad %s no pertenece a la institución %s",
+		ErrorInvalidRole:              "cargo de firmante %v no válido",
+		ErrorInvalidTerm:              "el fin del mandato %s es anterior a su inicio %s",
+		ErrorTermOverlap:              "el mandato se solapa con el del firmante %s",
+		ErrorSignatoryRole:            "el firmante %s no ocupa el cargo de %v",
+		ErrorSignatoryScope:           "el firmante %s no ocupa un cargo en %s",
+		ErrorSignatoryTerm:            "el firmante %s no está en el cargo el %s",
+		ErrorSignerIdentity:           "la identidad del cliente no corresponde con el certificado del firmante %s",
+		ErrorInvalidLevel:             "nivel de programa %v no válido",
+		ErrorInvalidYears:             "años de vigencia %d-%d no válidos",
+		ErrorProgramFaculty:           "el programa %s no se imparte en la facultad %s",
+		ErrorProgramYear:              "el programa %s no se impartía en %d",
+		ErrorTransientMissing:         "el campo transitorio %s es obligatorio",
+		ErrorIdentityIncomplete:       "el nombre completo y el carné de identidad del graduado son obligatorios",
+		ErrorInvalidVolumeFolio:       "asiento %s del libro de registro no válido, se espera tomo,folio",
+		ErrorInvalidRegistryBook:      "libro de registro %s no válido",
+		ErrorFolioTaken:               "el asiento %s del libro de registro %s de %s ya está asignado a %s",
+		ErrorInvalidSettings:          "configuración no válida: %s",
+		ErrorBatchSize:                "el lote tiene %d elementos, el límite está entre 1 y %d",
+		ErrorBatchDuplicated:          "%s está repetido en el lote",
+		ErrorBatchFailed:              "fallaron %d de %d elementos del lote: %s",
+		ErrorAlreadyInitialized:       "el ledger ya fue inicializado",
+		ErrorFixture:                  "dato inicial %s no válido: %v",
+		ErrorSchemaVersion:            "%s tiene la versión de esquema %d, posterior a la versión soportada %d",
+		ErrorSchemaUpgrade:            "no se pudo actualizar %s desde la versión de esquema %d: %v",
+		ErrorInvalidID:                "identificador %s no válido, se espera un identificador %s",
+		ErrorCompositeKey:             "error al crear la llave compuesta de: %v",
+		ErrorInvalidFunction:          "función %s no válida, invocada con los argumentos %v",
+		ErrorMissingQuery:             "falta la consulta",
+		ErrorEndorsementPolicy:        "política de endoso de %s no válida: %v",
+		ErrorNotIssuer:                "la institución %s no es emisora de %s",
+		ErrorIssuerDuplicated:         "la institución %s está repetida entre los emisores de %s",
+		ErrorInvokeChaincode:          "error al invocar el chaincode %s en el canal %s: %s",
+		ErrorCertificateNotValid:      "el título %s no es válido",
+		ErrorLegalizationStatus:       "la legalización de %s está en estado %v, se espera %v",
+		ErrorLegalizationReason:       "una legalización denegada requiere el motivo de la denegación",
+		ErrorApostilleNumber:          "el número de la apostilla es obligatorio",
+		ErrorEmptyTranscript:          "la certificación de notas no tiene asignaturas",
+		ErrorCourseIncomplete:         "el código y el nombre de todas las asignaturas son obligatorios",
+		ErrorCourseDuplicated:         "la asignatura %s está repetida en la certificación de notas",
+		ErrorInvalidGrade:             "nota %d de la asignatura %s no válida, se espera de %d a %d",
+		ErrorInvalidCredits:           "créditos %d de la asignatura %s no válidos",
+		ErrorTranscriptExists:         "el título %s ya tiene la certificación de notas %s",
+		ErrorTranscriptSigner:         "el firmante %s no firmó el título %s como %v",
+		ErrorInvalidHonorsLevel:       "nivel de distinción %v no válido",
+		ErrorInvalidHonorsRule:        "regla de distinción del nivel %v no válida, el promedio mínimo debe estar entre 0 y 5 y las asignaturas desaprobadas no pueden ser negativas",
+		ErrorHonorsRuleDuplicated:     "el nivel %v tiene más de una regla de distinción",
+		ErrorHonorsReason:             "el cambio de la distinción requiere su motivo",
+		ErrorHonorsLocked:             "la distinción de %s no puede cambiar una vez válido o anulado",
+		ErrorInvalidGrantScope:        "alcance de la autorización %s no válido",
+		ErrorGrantAudience:            "el destinatario de la autorización es obligatorio",
+		ErrorGrantExpiry:              "vencimiento de la autorización %s no válido, debe ser posterior a la transacción y en a lo sumo %d días",
+		ErrorGrantToken:               "el token de la autorización debe tener al menos %d caracteres",
+		ErrorNotHolder:                "la identidad del cliente no es el titular %s",
+		ErrorGrantDenied:              "la autorización %s no permite el acceso: %s",
+		ErrorVerificationPurpose:      "el propósito de la verificación es obligatorio",
+		ErrorIssuanceLimit:            "la identidad del cliente alcanzó su límite de %d títulos el %s",
+		ErrorCertificateFlagged:       "el título %s está marcado como sospechoso y espera revisión",
+		ErrorNotFlagged:               "el título %s no espera revisión",
+		ErrorReviewReason:             "una revisión rechazada requiere el motivo del rechazo",
+		ErrorSelfReview:               "el título %s no puede ser revisado por la identidad que lo emitió",
+		ErrorDuplicateCertificate:     "el graduado %s ya tiene el título %s del programa %s",
+		ErrorInvalidReissue:           "el título %s no puede ser reemplazado por el duplicado %s, debe ser un título no anulado del mismo emisor, graduado y programa",
+		ErrorMissingMSP:               "la institución %s no tiene MSP, asigne su msp_id con UpdateInstitution",
+		ErrorSignedLocked:             "el campo %s de %s no puede cambiar una vez firmado",
+		ErrorIdentityKey:              "la clave de identidad debe tener al menos %d bytes",
+		ErrorIdentityKeyMissing:       "la clave de identidad no está asignada, un administrador debe asignarla con SetIdentityKey",
+
+		// certificate status labels
+		"Invalid": "Anulado",
+		"Missing Secretary, Dean and Rector signatures": "Faltan las firmas del Secretario, el Decano y el Rector",
+		"Missing Dean and Rector signatures":            "Faltan las firmas del Decano y el Rector",
+		"Missing Rector signature":                      "Falta la firma del Rector",
+		"Valid":                                         "Válido",
+
+		// legalization status labels
+		"Legalization requested": "Legalización solicitada",
+		"Legalized":              "Legalizado",
+		"Apostilled":             "Apostillado",
+		"Legalization rejected":  "Legalización denegada",
+
+		// honors levels
+		"No honors":        "Sin distinción",
+		"With distinction": "Con distinción",
+		"Gold certificate": "Título de Oro",
+
+		// validator roles
+		"NoValidator": "Sin cargo",
+		"Secretary":   "Secretario",
+		"Dean":        "Decano",
+		"Rector":      "Rector",
+		"unknown":     "desconocido",
+	},
+}
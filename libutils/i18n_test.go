@@ -0,0 +1,66 @@
+package lib_utils
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseLanguage(t *testing.T) {
+	tests := map[string]Language{"es": LangEs, "ES-cu": LangEs, "en_US": LangEn, " en ": LangEn}
+	for tag, want := range tests {
+		if got, ok := ParseLanguage(tag); !ok || got != want {
+			t.Errorf("%q = %s, %v, want %s", tag, got, ok, want)
+		}
+	}
+	for _, tag := range []string{"", "fr", "english"} {
+		if _, ok := ParseLanguage(tag); ok {
+			t.Errorf("%q parsed as a language of the catalog", tag)
+		}
+	}
+}
+
+func TestCatalogVerbs(t *testing.T) {
+	for format := range errorSpecs {
+		translation, ok := catalog[LangEs][format]
+		if !ok {
+			t.Errorf("%q has no Spanish translation", format)
+		} else if got, want := verbs(translation), verbs(format); got != want {
+			t.Errorf("%q has verbs %s, want %s", translation, got, want)
+		}
+	}
+}
+
+// verbs returns the formatting verbs of a format, in order
+func verbs(format string) string {
+	found := ""
+	for i := 0; i < len(format)-1; i++ {
+		if format[i] == '%' {
+			found += format[i : i+2]
+			i++
+		}
+	}
+	return found
+}
+
+func TestLocalizeMessage(t *testing.T) {
+	err := Errorf(ErrorFixture, "certificate CERT20221122103000", Errorf(ErrorInvalidDate, "2022-13-01"))
+	if err.Messages[LangEs] != "dato inicial certificate CERT20221122103000 no válido: fecha 2022-13-01 no válida, se espera AAAA-MM-DD" {
+		t.Errorf("Spanish message = %s", err.Messages[LangEs])
+	}
+
+	var localized Error
+	if e := json.Unmarshal([]byte(LocalizeMessage(err.Error(), LangEs)), &localized); e != nil {
+		t.Fatal(e)
+	}
+	if localized.Message != err.Messages[LangEs] || localized.Messages != nil || localized.Code != CodeInvalidFixture {
+		t.Errorf("localized error = %+v", localized)
+	}
+	cause := localized.Details["cause"].(map[string]interface{})
+	if cause["message"] != "fecha 2022-13-01 no válida, se espera AAAA-MM-DD" || cause["messages"] != nil {
+		t.Errorf("localized cause = %v", cause)
+	}
+
+	if got := LocalizeMessage("plain failure", LangEs); got != "plain failure" {
+		t.Errorf("plain message = %s", got)
+	}
+}
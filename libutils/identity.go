@@ -0,0 +1,87 @@
+package lib_utils
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// GetClientID returns the identity that submitted the transaction as MSPID::ID,
+// ex: Org1MSP::x509::CN=User1@org1.example.com,...::CN=ca.org1.example.com,...
+func GetClientID(ctx contractapi.TransactionContextInterface) (string, error) {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", Errorf(ErrorClientIdentity, err)
+	}
+	id, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return "", Errorf(ErrorClientIdentity, err)
+	}
+	// the client identity library returns the id base64 encoded
+	if decoded, err := base64.StdEncoding.DecodeString(id); err == nil {
+		id = string(decoded)
+	}
+
+	return mspID + "::" + id, nil
+}
+
+// AssertAdmin returns an error unless the client identity was registered as an admin
+func AssertAdmin(ctx contractapi.TransactionContextInterface) error {
+	err := ctx.GetClientIdentity().AssertAttributeValue(AttrType, AdminType)
+	if err != nil {
+		return Errorf(ErrorNotAdmin)
+	}
+	return nil
+}
+
+// PublicKeyFromPEM returns the DER encoded public key of a PEM x509 certificate or PEM public key
+func PublicKeyFromPEM(value string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(value))
+	if block == nil {
+		return nil, Errorf(ErrorParseX509)
+	}
+
+	var publicKey interface{}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, Errorf(ErrorParseX509)
+		}
+		publicKey = cert.PublicKey
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, Errorf(ErrorParseX509)
+		}
+		publicKey = key
+	default:
+		return nil, Errorf(ErrorParseX509)
+	}
+
+	return x509.MarshalPKIXPublicKey(publicKey)
+}
+
+// ClientHasPublicKey reports if the certificate of the client identity holds the public key
+// of value, a PEM x509 certificate or PEM public key
+func ClientHasPublicKey(ctx contractapi.TransactionContextInterface, value string) (bool, error) {
+	expected, err := PublicKeyFromPEM(value)
+	if err != nil {
+		return false, err
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return false, Errorf(ErrorClientIdentity, err)
+	} else if cert == nil {
+		return false, nil
+	}
+	clientKey, err := x509.MarshalPKIXPublicKey(cert.PublicKey)
+	if err != nil {
+		return false, Errorf(ErrorParseX509)
+	}
+
+	return bytes.Equal(expected, clientKey), nil
+}
@@ -0,0 +1,137 @@
+package mockstub
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/attrmgr"
+	"github.com/hyperledger/fabric-protos-go/msp"
+)
+
+// Identity client identity of a transaction, an enrollment certificate issued by the CA of an MSP
+type Identity struct {
+	MSPID          string
+	Certificate    *x509.Certificate
+	CertificatePEM string
+	PrivateKey     *ecdsa.PrivateKey
+}
+
+// certificateAuthority CA of an MSP
+type certificateAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+var (
+	authorities   = make(map[string]*certificateAuthority)
+	authoritiesMu sync.Mutex
+	serialNumber  int64
+)
+
+// NewIdentity returns an identity of the MSP mspID, with given common name and attributes as
+// added by Fabric CA, ex: {"hf.Type": "admin"}.
+func NewIdentity(mspID, commonName string, attrs map[string]string) (*Identity, error) {
+	ca, err := authority(mspID)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := newTemplate(pkix.Name{CommonName: commonName, OrganizationalUnit: []string{"client"}})
+	if len(attrs) > 0 {
+		value, err := json.Marshal(attrmgr.Attributes{Attrs: attrs})
+		if err != nil {
+			return nil, err
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: attrmgr.AttrOID, Value: value})
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		MSPID:          mspID,
+		Certificate:    cert,
+		CertificatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKey:     key,
+	}, nil
+}
+
+// MustIdentity is like NewIdentity but panics on error, to build the identities of a test
+func MustIdentity(mspID, commonName string, attrs map[string]string) *Identity {
+	identity, err := NewIdentity(mspID, commonName, attrs)
+	if err != nil {
+		panic(err)
+	}
+	return identity
+}
+
+// Serialize returns the identity as the creator of a transaction proposal
+func (i *Identity) Serialize() ([]byte, error) {
+	if i == nil {
+		return nil, fmt.Errorf("the transaction has no client identity")
+	}
+	return proto.Marshal(&msp.SerializedIdentity{Mspid: i.MSPID, IdBytes: []byte(i.CertificatePEM)})
+}
+
+// authority returns the CA of mspID, created on first use
+func authority(mspID string) (*certificateAuthority, error) {
+	authoritiesMu.Lock()
+	defer authoritiesMu.Unlock()
+
+	if ca, ok := authorities[mspID]; ok {
+		return ca, nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := newTemplate(pkix.Name{CommonName: "ca." + strings.ToLower(strings.TrimSuffix(mspID, "MSP")) + ".example.com"})
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage |= x509.KeyUsageCertSign
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &certificateAuthority{cert: cert, key: key}
+	authorities[mspID] = ca
+	return ca, nil
+}
+
+// newTemplate returns a certificate template valid from 2000 to ten years from now, so it
+// covers the transaction timestamps set by the tests
+func newTemplate(subject pkix.Name) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(atomic.AddInt64(&serialNumber, 1)),
+		Subject:      subject,
+		NotBefore:    time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+}
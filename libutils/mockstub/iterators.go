@@ -0,0 +1,59 @@
+package mockstub
+
+import (
+	"fmt"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// stateIterator iterates over a snapshot of the results of a query
+type stateIterator struct {
+	results []*queryresult.KV
+	closed  bool
+}
+
+func newStateIterator(results []*queryresult.KV) *stateIterator {
+	return &stateIterator{results: results}
+}
+
+func (it *stateIterator) HasNext() bool {
+	return !it.closed && len(it.results) > 0
+}
+
+func (it *stateIterator) Next() (*queryresult.KV, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("no more results")
+	}
+	result := it.results[0]
+	it.results = it.results[1:]
+	return result, nil
+}
+
+func (it *stateIterator) Close() error {
+	it.closed = true
+	return nil
+}
+
+// historyIterator iterates over a snapshot of the history of a key
+type historyIterator struct {
+	results []*queryresult.KeyModification
+	closed  bool
+}
+
+func (it *historyIterator) HasNext() bool {
+	return !it.closed && len(it.results) > 0
+}
+
+func (it *historyIterator) Next() (*queryresult.KeyModification, error) {
+	if !it.HasNext() {
+		return nil, fmt.Errorf("no more results")
+	}
+	result := it.results[0]
+	it.results = it.results[1:]
+	return result, nil
+}
+
+func (it *historyIterator) Close() error {
+	it.closed = true
+	return nil
+}
@@ -0,0 +1,417 @@
+package mockstub
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+)
+
+// query subset of a CouchDB Mango query. The indexes (use_index) are ignored, every
+// query is evaluated against all the JSON documents.
+type query struct {
+	Selector map[string]interface{} `json:"selector"`
+	Sort     []interface{}          `json:"sort"`
+	Limit    int                    `json:"limit"`
+	Skip     int                    `json:"skip"`
+	Fields   []string               `json:"fields"`
+	UseIndex interface{}            `json:"use_index"`
+}
+
+// sortField field of the sort clause of a query
+type sortField struct {
+	path []string
+	desc bool
+}
+
+// richQuery returns the JSON documents of state matching the query, sorted by the query sort
+// fields, or by key. The second result reports if the query sets a limit.
+func richQuery(state map[string][]byte, queryString string) ([]*queryresult.KV, bool, error) {
+	var q query
+	decoder := json.NewDecoder(strings.NewReader(queryString))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&q)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid query %s: %v", queryString, err)
+	}
+	if q.Selector == nil {
+		return nil, false, fmt.Errorf("invalid query %s: the selector is required", queryString)
+	}
+	sortFields, err := parseSort(q.Sort)
+	if err != nil {
+		return nil, false, err
+	}
+
+	type match struct {
+		kv  *queryresult.KV
+		doc map[string]interface{}
+	}
+	matches := make([]match, 0)
+	for _, kv := range rangeResults(state, "", "") {
+		// non JSON values are stored as attachments by CouchDB, they never match a selector
+		var doc map[string]interface{}
+		if json.Unmarshal(kv.Value, &doc) != nil {
+			continue
+		}
+		ok, err := matchSelector(doc, q.Selector)
+		if err != nil {
+			return nil, false, err
+		}
+		if ok {
+			matches = append(matches, match{kv: kv, doc: doc})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		for _, field := range sortFields {
+			a, _ := lookup(matches[i].doc, field.path)
+			b, _ := lookup(matches[j].doc, field.path)
+			if c := collate(a, b); c != 0 {
+				return (c < 0) != field.desc
+			}
+		}
+		return false
+	})
+
+	if q.Skip > len(matches) {
+		q.Skip = len(matches)
+	}
+	matches = matches[q.Skip:]
+	if q.Limit > 0 && q.Limit < len(matches) {
+		matches = matches[:q.Limit]
+	}
+
+	results := make([]*queryresult.KV, 0, len(matches))
+	for _, m := range matches {
+		value := m.kv.Value
+		if len(q.Fields) > 0 {
+			value, err = json.Marshal(project(m.doc, q.Fields))
+			if err != nil {
+				return nil, false, err
+			}
+		}
+		results = append(results, &queryresult.KV{Key: m.kv.Key, Value: value})
+	}
+
+	return results, q.Limit > 0, nil
+}
+
+// parseSort parses a sort clause, ex: ["date"] or [{"date": "desc"}]
+func parseSort(clause []interface{}) ([]sortField, error) {
+	fields := make([]sortField, 0, len(clause))
+	for _, item := range clause {
+		switch item := item.(type) {
+		case string:
+			fields = append(fields, sortField{path: strings.Split(item, ".")})
+		case map[string]interface{}:
+			if len(item) != 1 {
+				return nil, fmt.Errorf("invalid sort field %v", item)
+			}
+			for name, direction := range item {
+				if direction != "asc" && direction != "desc" {
+					return nil, fmt.Errorf("invalid sort direction %v", direction)
+				}
+				fields = append(fields, sortField{path: strings.Split(name, "."), desc: direction == "desc"})
+			}
+		default:
+			return nil, fmt.Errorf("invalid sort field %v", item)
+		}
+	}
+	return fields, nil
+}
+
+// matchSelector reports if doc matches every condition of selector
+func matchSelector(doc interface{}, selector map[string]interface{}) (bool, error) {
+	for key, condition := range selector {
+		var ok bool
+		var err error
+		switch key {
+		case "$and", "$or", "$nor":
+			ok, err = matchCombination(doc, key, condition)
+		case "$not":
+			sub, isMap := condition.(map[string]interface{})
+			if !isMap {
+				return false, fmt.Errorf("$not expects a selector")
+			}
+			ok, err = matchSelector(doc, sub)
+			ok = !ok
+		default:
+			if strings.HasPrefix(key, "$") {
+				return false, fmt.Errorf("unsupported operator %s", key)
+			}
+			value, found := lookup(doc, strings.Split(key, "."))
+			ok, err = matchCondition(value, found, condition)
+		}
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchCombination evaluates the $and, $or and $nor operators
+func matchCombination(doc interface{}, operator string, condition interface{}) (bool, error) {
+	selectors, ok := condition.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("%s expects an array of selectors", operator)
+	}
+	matched := 0
+	for _, item := range selectors {
+		sub, ok := item.(map[string]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s expects an array of selectors", operator)
+		}
+		ok, err := matchSelector(doc, sub)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			matched++
+		}
+	}
+	switch operator {
+	case "$and":
+		return matched == len(selectors), nil
+	case "$or":
+		return matched > 0, nil
+	default:
+		return matched == 0, nil
+	}
+}
+
+// matchCondition evaluates the condition on a field, an implicit $eq, an object of
+// operators or a nested selector
+func matchCondition(value interface{}, found bool, condition interface{}) (bool, error) {
+	operators, ok := condition.(map[string]interface{})
+	if !ok {
+		return found && collate(value, condition) == 0, nil
+	}
+	isOperators := len(operators) > 0
+	for key := range operators {
+		isOperators = isOperators && strings.HasPrefix(key, "$")
+	}
+	if !isOperators {
+		return matchNested(value, operators)
+	}
+
+	for operator, argument := range operators {
+		ok, err := matchOperator(value, found, operator, argument)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// matchNested evaluates a nested selector on a field, only objects can match it
+func matchNested(value interface{}, selector map[string]interface{}) (bool, error) {
+	if _, ok := value.(map[string]interface{}); !ok {
+		return false, nil
+	}
+	return matchSelector(value, selector)
+}
+
+// matchOperator evaluates a condition operator. As in CouchDB, only $exists matches missing fields.
+func matchOperator(value interface{}, found bool, operator string, argument interface{}) (bool, error) {
+	if operator == "$exists" {
+		exists, ok := argument.(bool)
+		if !ok {
+			return false, fmt.Errorf("$exists expects a boolean")
+		}
+		return found == exists, nil
+	}
+	if !found {
+		return false, nil
+	}
+
+	switch operator {
+	case "$eq":
+		return collate(value, argument) == 0, nil
+	case "$ne":
+		return collate(value, argument) != 0, nil
+	case "$gt":
+		return sameType(value, argument) && collate(value, argument) > 0, nil
+	case "$gte":
+		return sameType(value, argument) && collate(value, argument) >= 0, nil
+	case "$lt":
+		return sameType(value, argument) && collate(value, argument) < 0, nil
+	case "$lte":
+		return sameType(value, argument) && collate(value, argument) <= 0, nil
+	case "$in", "$nin":
+		items, ok := argument.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("%s expects an array", operator)
+		}
+		in := false
+		for _, item := range items {
+			in = in || collate(value, item) == 0
+		}
+		return in == (operator == "$in"), nil
+	case "$regex":
+		pattern, ok := argument.(string)
+		if !ok {
+			return false, fmt.Errorf("$regex expects a string")
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		text, ok := value.(string)
+		return ok && re.MatchString(text), nil
+	case "$size":
+		size, ok := argument.(float64)
+		if !ok {
+			return false, fmt.Errorf("$size expects a number")
+		}
+		items, ok := value.([]interface{})
+		return ok && float64(len(items)) == size, nil
+	case "$all":
+		expected, ok := argument.([]interface{})
+		if !ok {
+			return false, fmt.Errorf("$all expects an array")
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return false, nil
+		}
+		for _, e := range expected {
+			in := false
+			for _, item := range items {
+				in = in || collate(item, e) == 0
+			}
+			if !in {
+				return false, nil
+			}
+		}
+		return true, nil
+	case "$elemMatch":
+		items, ok := value.([]interface{})
+		if !ok {
+			return false, nil
+		}
+		for _, item := range items {
+			ok, err := matchCondition(item, true, argument)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	case "$not":
+		ok, err := matchCondition(value, found, argument)
+		return !ok, err
+	default:
+		return false, fmt.Errorf("unsupported operator %s", operator)
+	}
+}
+
+// lookup returns the value at path in doc
+func lookup(doc interface{}, path []string) (interface{}, bool) {
+	value := doc
+	for _, name := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		value, ok = object[name]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// project returns the fields of doc listed in fields, dotted paths included
+func project(doc map[string]interface{}, fields []string) map[string]interface{} {
+	projected := make(map[string]interface{})
+	for _, field := range fields {
+		path := strings.Split(field, ".")
+		value, found := lookup(doc, path)
+		if !found {
+			continue
+		}
+		target := projected
+		for _, name := range path[:len(path)-1] {
+			next, ok := target[name].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				target[name] = next
+			}
+			target = next
+		}
+		target[path[len(path)-1]] = value
+	}
+	return projected
+}
+
+// typeRank rank of the JSON type of value in the CouchDB collation
+func typeRank(value interface{}) int {
+	switch value := value.(type) {
+	case nil:
+		return 0
+	case bool:
+		if value {
+			return 2
+		}
+		return 1
+	case float64:
+		return 3
+	case string:
+		return 4
+	case []interface{}:
+		return 5
+	default:
+		return 6
+	}
+}
+
+// sameType reports if a and b are of the same JSON type, range operators never match across types
+func sameType(a, b interface{}) bool {
+	ra, rb := typeRank(a), typeRank(b)
+	return ra == rb || (ra <= 2 && rb <= 2 && ra != 0 && rb != 0)
+}
+
+// collate compares two JSON values following the CouchDB collation: null, false, true,
+// numbers, strings, arrays and objects.
+func collate(a, b interface{}) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+
+	switch a := a.(type) {
+	case float64:
+		b := b.(float64)
+		if a < b {
+			return -1
+		} else if a > b {
+			return 1
+		}
+		return 0
+	case string:
+		return strings.Compare(a, b.(string))
+	case []interface{}:
+		b := b.([]interface{})
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := collate(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+		return collate(float64(len(a)), float64(len(b)))
+	case map[string]interface{}:
+		if reflect.DeepEqual(a, b) {
+			return 0
+		}
+		aJSON, _ := json.Marshal(a)
+		bJSON, _ := json.Marshal(b)
+		return strings.Compare(string(aJSON), string(bJSON))
+	default:
+		return 0
+	}
+}
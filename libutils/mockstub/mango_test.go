@@ -0,0 +1,82 @@
+package mockstub
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRichQuery(t *testing.T) {
+	state := map[string][]byte{
+		"\x00CERT\x002010\x00": []byte(`{"docType":"CERT","date":"2010-11-08","gold":true,"folio":{"volume":254,"folio":136},"tags":["a","b"]}`),
+		"\x00CERT\x002015\x00": []byte(`{"docType":"CERT","date":"2015-07-20","gold":false,"folio":{"volume":31,"folio":7},"tags":["b"]}`),
+		"\x00CERT\x002019\x00": []byte(`{"docType":"CERT","date":"2019-01-10","gold":false}`),
+		"\x00INST\x002022\x00": []byte(`{"docType":"INST","name":"UH"}`),
+		"\x00HLDR\x00x\x00":    {0x00},
+	}
+
+	tests := []struct {
+		query string
+		want  string // years of the results, in order
+	}{
+		{`{"selector":{"docType":"CERT"}}`, "2010,2015,2019"},
+		{`{"selector":{"docType":"CERT","date":{"$gte":"2011-01-01","$lte":"2019-01-10"}}}`, "2015,2019"},
+		{`{"selector":{"docType":"CERT"},"sort":[{"date":"desc"}]}`, "2019,2015,2010"},
+		{`{"selector":{"docType":"CERT"},"sort":[{"date":"desc"}],"skip":1,"limit":1}`, "2015"},
+		{`{"selector":{"folio.volume":{"$gt":100}}}`, "2010"},
+		{`{"selector":{"folio":{"folio":7}}}`, "2015"},
+		{`{"selector":{"folio":{"$exists":false},"docType":"CERT"}}`, "2019"},
+		{`{"selector":{"$or":[{"gold":true},{"date":"2019-01-10"}]}}`, "2010,2019"},
+		{`{"selector":{"docType":{"$in":["CERT"]},"$not":{"gold":true}}}`, "2015,2019"},
+		{`{"selector":{"folio.volume":{"$ne":31}}}`, "2010"}, // missing fields never match
+		{`{"selector":{"tags":{"$all":["b"]},"date":{"$regex":"^2015"}}}`, "2015"},
+		{`{"selector":{"tags":{"$elemMatch":{"$eq":"a"}}}}`, "2010"},
+		{`{"selector":{"date":{"$gt":2000}}}`, ""}, // range operators never match across types
+	}
+
+	for _, test := range tests {
+		results, _, err := richQuery(state, test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		years := make([]string, 0, len(results))
+		for _, result := range results {
+			_, parts := splitKey(result.Key)
+			years = append(years, parts[0])
+		}
+		if got := strings.Join(years, ","); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+}
+
+func TestRichQueryFieldsAndErrors(t *testing.T) {
+	state := map[string][]byte{
+		"\x00CERT\x002010\x00": []byte(`{"docType":"CERT","date":"2010-11-08","folio":{"volume":254,"folio":136}}`),
+	}
+
+	results, _, err := richQuery(state, `{"selector":{"docType":"CERT"},"fields":["date","folio.volume"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(results[0].Value), `{"date":"2010-11-08","folio":{"volume":254}}`; got != want {
+		t.Errorf("projection = %s, want %s", got, want)
+	}
+
+	for _, query := range []string{
+		`{"docType":"CERT"}`,
+		`{"selector":{"date":{"$unknown":1}}}`,
+		`{"selector":{"$or":{"date":1}}}`,
+		`{"selector":{},"sort":[{"date":"up"}]}`,
+		`not json`,
+	} {
+		if _, _, err := richQuery(state, query); err == nil {
+			t.Errorf("%s: expected an error", query)
+		}
+	}
+}
+
+func splitKey(key string) (string, []string) {
+	parts := strings.Split(strings.Trim(key, "\x00"), "\x00")
+	return parts[0], parts[1:]
+}
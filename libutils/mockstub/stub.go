@@ -0,0 +1,698 @@
+// Package mockstub provides an in-memory shim.ChaincodeStubInterface to test the contracts.
+//
+// Unlike the shimtest MockStub it follows the semantics of a peer: the reads of a transaction
+// never see its own writes, the writes are only applied to the world state (and its history)
+// when the transaction commits, range and partial composite key scans exclude the keys of the
+// other kind, rich queries are evaluated with a subset of the CouchDB Mango selectors and the
+// client identity is a real x509 certificate, so cid and the contractapi work unchanged.
+//
+// A test runs every transaction through Submit or Evaluate:
+//
+//	stub := mockstub.NewStub("mychannel")
+//	err := stub.Submit(admin, func(ctx contractapi.TransactionContextInterface) error {
+//		return contract.CreateAsset(ctx, &asset)
+//	})
+package mockstub
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/pkg/cid"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+)
+
+const (
+	compositeKeyNamespace = "\x00"
+	emptyKeySubstitute    = "\x01"
+	minUnicodeRuneValue   = 0
+	maxUnicodeRuneValue   = 0x10FFFF
+)
+
+// TxFunc body of a transaction, ex: a call to a contract function
+type TxFunc func(ctx contractapi.TransactionContextInterface) error
+
+// write pending write of a transaction, a nil value deletes the key
+type write struct {
+	value []byte
+}
+
+// transaction state of the transaction in progress
+type transaction struct {
+	id         string
+	timestamp  *timestamp.Timestamp
+	creator    []byte
+	args       [][]byte
+	transient  map[string][]byte
+	writes     map[string]*write
+	private    map[string]map[string]*write
+	validation map[string][]byte
+	event      *peer.ChaincodeEvent
+	paginated  bool    // paginated queries are only valid in read only transactions
+	invoked    []*Stub // chaincodes of the channel invoked by the transaction
+}
+
+// chaincode chaincode reachable through InvokeChaincode
+type chaincode struct {
+	cc   shim.Chaincode
+	stub *Stub
+}
+
+// Stub in-memory world state of a chaincode in a channel
+type Stub struct {
+	ChannelID string
+
+	state      map[string][]byte
+	private    map[string]map[string][]byte
+	history    map[string][]*queryresult.KeyModification
+	validation map[string][]byte
+	events     []*peer.ChaincodeEvent
+	chaincodes map[string]*chaincode
+
+	clock     time.Time
+	txCount   int
+	transient map[string][]byte
+	tx        *transaction
+}
+
+// NewStub returns an empty world state. The first transaction is timestamped 2022-01-01 at noon UTC.
+func NewStub(channelID string) *Stub {
+	return &Stub{
+		ChannelID:  channelID,
+		state:      make(map[string][]byte),
+		private:    make(map[string]map[string][]byte),
+		history:    make(map[string][]*queryresult.KeyModification),
+		validation: make(map[string][]byte),
+		chaincodes: make(map[string]*chaincode),
+		clock:      time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+		transient:  make(map[string][]byte),
+	}
+}
+
+// SetTime sets the timestamp of the next transaction. Every transaction advances the clock one second.
+func (s *Stub) SetTime(t time.Time) {
+	s.clock = t.UTC()
+}
+
+// Time returns the timestamp of the next transaction
+func (s *Stub) Time() time.Time {
+	return s.clock
+}
+
+// SetTransient adds a field to the transient map of the next transaction
+func (s *Stub) SetTransient(key string, value []byte) {
+	s.transient[key] = value
+}
+
+// RegisterChaincode makes cc, running on stub, reachable through InvokeChaincode from this stub.
+// Invocations of chaincodes of the same channel commit with the calling transaction, the ones of
+// other channels are read only, like in a peer.
+func (s *Stub) RegisterChaincode(name string, cc shim.Chaincode, stub *Stub) {
+	s.chaincodes[name+"/"+stub.ChannelID] = &chaincode{cc: cc, stub: stub}
+}
+
+// Submit runs fn as a transaction submitted by identity. The writes are committed if fn succeeds
+// and discarded otherwise.
+func (s *Stub) Submit(identity *Identity, fn TxFunc) error {
+	ctx, err := s.begin(identity, nil)
+	if err != nil {
+		return err
+	}
+	err = fn(ctx)
+	if err != nil {
+		s.rollback()
+		return err
+	}
+	return s.commit()
+}
+
+// Evaluate runs fn as a transaction evaluated by identity. The writes are always discarded.
+func (s *Stub) Evaluate(identity *Identity, fn TxFunc) error {
+	ctx, err := s.begin(identity, nil)
+	if err != nil {
+		return err
+	}
+	defer s.rollback()
+	return fn(ctx)
+}
+
+// Invoke submits a transaction through the chaincode cc, ex: a contractapi.ContractChaincode,
+// with args as the function and parameters. The writes are committed if the response is OK.
+func (s *Stub) Invoke(identity *Identity, cc shim.Chaincode, args ...string) peer.Response {
+	byteArgs := make([][]byte, len(args))
+	for i, arg := range args {
+		byteArgs[i] = []byte(arg)
+	}
+	_, err := s.begin(identity, byteArgs)
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	response := cc.Invoke(s)
+	if response.Status >= shim.ERRORTHRESHOLD {
+		s.rollback()
+		return response
+	}
+	err = s.commit()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return response
+}
+
+// begin starts a transaction and returns its context
+func (s *Stub) begin(identity *Identity, args [][]byte) (*contractapi.TransactionContext, error) {
+	if s.tx != nil {
+		return nil, fmt.Errorf("transaction %s in progress", s.tx.id)
+	}
+	creator, err := identity.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	s.txCount++
+	hash := sha256.Sum256([]byte(s.ChannelID + strconv.Itoa(s.txCount)))
+	s.tx = newTransaction(fmt.Sprintf("%x", hash), &timestamp.Timestamp{Seconds: s.clock.Unix(), Nanos: int32(s.clock.Nanosecond())}, creator, args, s.transient)
+	s.clock = s.clock.Add(time.Second)
+	s.transient = make(map[string][]byte)
+
+	ctx, err := s.context()
+	if err != nil {
+		s.rollback()
+		return nil, err
+	}
+	return ctx, nil
+}
+
+func newTransaction(id string, ts *timestamp.Timestamp, creator []byte, args [][]byte, transient map[string][]byte) *transaction {
+	return &transaction{
+		id:         id,
+		timestamp:  ts,
+		creator:    creator,
+		args:       args,
+		transient:  transient,
+		writes:     make(map[string]*write),
+		private:    make(map[string]map[string]*write),
+		validation: make(map[string][]byte),
+	}
+}
+
+// context returns a transaction context of the transaction in progress, as the contractapi builds it
+func (s *Stub) context() (*contractapi.TransactionContext, error) {
+	clientIdentity, err := cid.New(s)
+	if err != nil {
+		return nil, err
+	}
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(s)
+	ctx.SetClientIdentity(clientIdentity)
+	return ctx, nil
+}
+
+// commit applies the writes of the transaction in progress, and of the chaincodes it invoked
+func (s *Stub) commit() error {
+	tx := s.tx
+	defer s.rollback()
+	if tx.paginated && (len(tx.writes) > 0 || len(tx.private) > 0) {
+		return fmt.Errorf("transaction %s writes to the state after a paginated query", tx.id)
+	}
+
+	keys := make([]string, 0, len(tx.writes))
+	for key := range tx.writes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := tx.writes[key].value
+		modification := &queryresult.KeyModification{TxId: tx.id, Value: value, Timestamp: tx.timestamp, IsDelete: value == nil}
+		s.history[key] = append([]*queryresult.KeyModification{modification}, s.history[key]...)
+		if value == nil {
+			delete(s.state, key)
+			delete(s.validation, key)
+		} else {
+			s.state[key] = value
+		}
+	}
+	for key, ep := range tx.validation {
+		if _, ok := s.state[key]; ok {
+			s.validation[key] = ep
+		}
+	}
+	for collection, writes := range tx.private {
+		if s.private[collection] == nil {
+			s.private[collection] = make(map[string][]byte)
+		}
+		for key, w := range writes {
+			if w.value == nil {
+				delete(s.private[collection], key)
+			} else {
+				s.private[collection][key] = w.value
+			}
+		}
+	}
+	if tx.event != nil {
+		s.events = append(s.events, tx.event)
+	}
+
+	for _, invoked := range tx.invoked {
+		err := invoked.commit()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rollback discards the transaction in progress
+func (s *Stub) rollback() {
+	s.tx = nil
+}
+
+// State returns the committed value of key, nil if it does not exist
+func (s *Stub) State(key string) []byte {
+	return s.state[key]
+}
+
+// Keys returns the committed keys of the world state, sorted
+func (s *Stub) Keys() []string {
+	keys := make([]string, 0, len(s.state))
+	for key := range s.state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PrivateState returns the committed value of key in collection, nil if it does not exist
+func (s *Stub) PrivateState(collection, key string) []byte {
+	return s.private[collection][key]
+}
+
+// ValidationParameter returns the committed key-level endorsement policy of key
+func (s *Stub) ValidationParameter(key string) []byte {
+	return s.validation[key]
+}
+
+// Events returns the events of the committed transactions, oldest first
+func (s *Stub) Events() []*peer.ChaincodeEvent {
+	return s.events
+}
+
+// LastEvent returns the event of the last committed transaction that emitted one, nil if none did
+func (s *Stub) LastEvent() *peer.ChaincodeEvent {
+	if len(s.events) == 0 {
+		return nil
+	}
+	return s.events[len(s.events)-1]
+}
+
+func (s *Stub) activeTx() (*transaction, error) {
+	if s.tx == nil {
+		return nil, fmt.Errorf("no transaction in progress")
+	}
+	return s.tx, nil
+}
+
+// GetArgs returns the arguments of the transaction, only set by Invoke
+func (s *Stub) GetArgs() [][]byte {
+	if s.tx == nil {
+		return nil
+	}
+	return s.tx.args
+}
+
+func (s *Stub) GetStringArgs() []string {
+	args := s.GetArgs()
+	strargs := make([]string, 0, len(args))
+	for _, arg := range args {
+		strargs = append(strargs, string(arg))
+	}
+	return strargs
+}
+
+func (s *Stub) GetFunctionAndParameters() (string, []string) {
+	args := s.GetStringArgs()
+	if len(args) == 0 {
+		return "", []string{}
+	}
+	return args[0], args[1:]
+}
+
+func (s *Stub) GetArgsSlice() ([]byte, error) {
+	res := []byte{}
+	for _, arg := range s.GetArgs() {
+		res = append(res, arg...)
+	}
+	return res, nil
+}
+
+func (s *Stub) GetTxID() string {
+	if s.tx == nil {
+		return ""
+	}
+	return s.tx.id
+}
+
+func (s *Stub) GetChannelID() string {
+	return s.ChannelID
+}
+
+// InvokeChaincode calls a chaincode registered with RegisterChaincode, in the same transaction
+func (s *Stub) InvokeChaincode(chaincodeName string, args [][]byte, channel string) peer.Response {
+	tx, err := s.activeTx()
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	if channel == "" {
+		channel = s.ChannelID
+	}
+	target, ok := s.chaincodes[chaincodeName+"/"+channel]
+	if !ok {
+		return shim.Error(fmt.Sprintf("chaincode %s not found in channel %s", chaincodeName, channel))
+	}
+	if target.stub.tx != nil {
+		return shim.Error(fmt.Sprintf("chaincode %s is already in the transaction", chaincodeName))
+	}
+
+	target.stub.tx = newTransaction(tx.id, tx.timestamp, tx.creator, args, tx.transient)
+	response := target.cc.Invoke(target.stub)
+	if response.Status >= shim.ERRORTHRESHOLD || channel != s.ChannelID {
+		target.stub.rollback()
+	} else {
+		tx.invoked = append(tx.invoked, target.stub)
+	}
+	return response
+}
+
+// GetState returns the committed value of key, the writes of the transaction are not visible
+func (s *Stub) GetState(key string) ([]byte, error) {
+	return s.state[key], nil
+}
+
+func (s *Stub) PutState(key string, value []byte) error {
+	tx, err := s.activeTx()
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return fmt.Errorf("key must not be an empty string")
+	}
+	// the peer handles an empty value as a delete
+	if len(value) == 0 {
+		value = nil
+	}
+	tx.writes[key] = &write{value: value}
+	return nil
+}
+
+func (s *Stub) DelState(key string) error {
+	tx, err := s.activeTx()
+	if err != nil {
+		return err
+	}
+	tx.writes[key] = &write{}
+	return nil
+}
+
+func (s *Stub) SetStateValidationParameter(key string, ep []byte) error {
+	tx, err := s.activeTx()
+	if err != nil {
+		return err
+	}
+	tx.validation[key] = ep
+	return nil
+}
+
+func (s *Stub) GetStateValidationParameter(key string) ([]byte, error) {
+	return s.validation[key], nil
+}
+
+func (s *Stub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, err
+	}
+	return newStateIterator(rangeResults(s.state, startKey, endKey)), nil
+}
+
+func (s *Stub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, nil, err
+	}
+	return s.paginate(rangeResults(s.state, startKey, endKey), pageSize, bookmark)
+}
+
+func (s *Stub) GetStateByPartialCompositeKey(objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(rangeResults(s.state, startKey, endKey)), nil
+}
+
+func (s *Stub) GetStateByPartialCompositeKeyWithPagination(objectType string, keys []string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.paginate(rangeResults(s.state, startKey, endKey), pageSize, bookmark)
+}
+
+func (s *Stub) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	return shim.CreateCompositeKey(objectType, attributes)
+}
+
+func (s *Stub) SplitCompositeKey(compositeKey string) (string, []string, error) {
+	if !strings.HasPrefix(compositeKey, compositeKeyNamespace) || !strings.HasSuffix(compositeKey, string(rune(minUnicodeRuneValue))) {
+		return "", nil, fmt.Errorf("invalid composite key %q", compositeKey)
+	}
+	parts := strings.Split(compositeKey[1:len(compositeKey)-1], string(rune(minUnicodeRuneValue)))
+	return parts[0], parts[1:], nil
+}
+
+// GetQueryResult evaluates a Mango query against the committed JSON documents
+func (s *Stub) GetQueryResult(query string) (shim.StateQueryIteratorInterface, error) {
+	results, _, err := richQuery(s.state, query)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(results), nil
+}
+
+func (s *Stub) GetQueryResultWithPagination(query string, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	results, limited, err := richQuery(s.state, query)
+	if err != nil {
+		return nil, nil, err
+	} else if limited {
+		return nil, nil, fmt.Errorf("the query limit is not allowed in paginated queries, use the page size")
+	}
+	return s.paginate(results, pageSize, bookmark)
+}
+
+// paginate returns the page of results after the bookmark, the key of the last result of the previous page
+func (s *Stub) paginate(results []*queryresult.KV, pageSize int32, bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+	if s.tx != nil {
+		s.tx.paginated = true
+	}
+	if pageSize <= 0 {
+		return nil, nil, fmt.Errorf("the page size must be positive")
+	}
+	if bookmark != "" {
+		start := len(results)
+		for i, result := range results {
+			if result.Key == bookmark {
+				start = i + 1
+				break
+			}
+		}
+		results = results[start:]
+	}
+	if len(results) > int(pageSize) {
+		results = results[:pageSize]
+	}
+
+	metadata := &peer.QueryResponseMetadata{FetchedRecordsCount: int32(len(results))}
+	if len(results) > 0 {
+		metadata.Bookmark = results[len(results)-1].Key
+	}
+	return newStateIterator(results), metadata, nil
+}
+
+// GetHistoryForKey returns the committed modifications of key, newest first
+func (s *Stub) GetHistoryForKey(key string) (shim.HistoryQueryIteratorInterface, error) {
+	return &historyIterator{results: s.history[key]}, nil
+}
+
+func (s *Stub) GetPrivateData(collection, key string) ([]byte, error) {
+	return s.private[collection][key], nil
+}
+
+func (s *Stub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value := s.private[collection][key]
+	if value == nil {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+func (s *Stub) PutPrivateData(collection string, key string, value []byte) error {
+	if key == "" {
+		return fmt.Errorf("key must not be an empty string")
+	}
+	if len(value) == 0 {
+		value = nil
+	}
+	return s.putPrivate(collection, key, value)
+}
+
+func (s *Stub) DelPrivateData(collection, key string) error {
+	return s.putPrivate(collection, key, nil)
+}
+
+func (s *Stub) PurgePrivateData(collection, key string) error {
+	return s.putPrivate(collection, key, nil)
+}
+
+func (s *Stub) putPrivate(collection, key string, value []byte) error {
+	tx, err := s.activeTx()
+	if err != nil {
+		return err
+	}
+	if collection == "" {
+		return fmt.Errorf("collection must not be an empty string")
+	}
+	if tx.private[collection] == nil {
+		tx.private[collection] = make(map[string]*write)
+	}
+	tx.private[collection][key] = &write{value: value}
+	return nil
+}
+
+func (s *Stub) SetPrivateDataValidationParameter(collection, key string, ep []byte) error {
+	return s.SetStateValidationParameter(collection+compositeKeyNamespace+key, ep)
+}
+
+func (s *Stub) GetPrivateDataValidationParameter(collection, key string) ([]byte, error) {
+	return s.validation[collection+compositeKeyNamespace+key], nil
+}
+
+func (s *Stub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if startKey == "" {
+		startKey = emptyKeySubstitute
+	}
+	if err := validateSimpleKeys(startKey, endKey); err != nil {
+		return nil, err
+	}
+	return newStateIterator(rangeResults(s.private[collection], startKey, endKey)), nil
+}
+
+func (s *Stub) GetPrivateDataByPartialCompositeKey(collection, objectType string, keys []string) (shim.StateQueryIteratorInterface, error) {
+	startKey, endKey, err := partialCompositeKeyRange(objectType, keys)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(rangeResults(s.private[collection], startKey, endKey)), nil
+}
+
+func (s *Stub) GetPrivateDataQueryResult(collection, query string) (shim.StateQueryIteratorInterface, error) {
+	results, _, err := richQuery(s.private[collection], query)
+	if err != nil {
+		return nil, err
+	}
+	return newStateIterator(results), nil
+}
+
+func (s *Stub) GetCreator() ([]byte, error) {
+	tx, err := s.activeTx()
+	if err != nil {
+		return nil, err
+	}
+	return tx.creator, nil
+}
+
+func (s *Stub) GetTransient() (map[string][]byte, error) {
+	tx, err := s.activeTx()
+	if err != nil {
+		return nil, err
+	}
+	return tx.transient, nil
+}
+
+func (s *Stub) GetBinding() ([]byte, error) {
+	return nil, fmt.Errorf("the binding is not supported by the mock stub")
+}
+
+func (s *Stub) GetDecorations() map[string][]byte {
+	return map[string][]byte{}
+}
+
+func (s *Stub) GetSignedProposal() (*peer.SignedProposal, error) {
+	return nil, fmt.Errorf("the signed proposal is not supported by the mock stub")
+}
+
+func (s *Stub) GetTxTimestamp() (*timestamp.Timestamp, error) {
+	tx, err := s.activeTx()
+	if err != nil {
+		return nil, err
+	}
+	return tx.timestamp, nil
+}
+
+// SetEvent sets the event of the transaction, only the last one is emitted
+func (s *Stub) SetEvent(name string, payload []byte) error {
+	tx, err := s.activeTx()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("event name can not be empty string")
+	}
+	tx.event = &peer.ChaincodeEvent{TxId: tx.id, EventName: name, Payload: payload}
+	return nil
+}
+
+// rangeResults returns the entries of state with keys in [startKey, endKey), sorted by key.
+// An empty endKey leaves the range open.
+func rangeResults(state map[string][]byte, startKey, endKey string) []*queryresult.KV {
+	results := make([]*queryresult.KV, 0)
+	for key, value := range state {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			results = append(results, &queryresult.KV{Key: key, Value: value})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Key < results[j].Key
+	})
+	return results
+}
+
+func partialCompositeKeyRange(objectType string, attributes []string) (string, string, error) {
+	startKey, err := shim.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return "", "", err
+	}
+	return startKey, startKey + string(rune(maxUnicodeRuneValue)), nil
+}
+
+// validateSimpleKeys returns an error if a key is a composite key, range queries are only for simple keys
+func validateSimpleKeys(keys ...string) error {
+	for _, key := range keys {
+		if strings.HasPrefix(key, compositeKeyNamespace) {
+			return fmt.Errorf("first character of the key [%s] contains a null character which is not allowed", key)
+		}
+	}
+	return nil
+}
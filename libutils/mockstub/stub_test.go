@@ -0,0 +1,231 @@
+package mockstub
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+var errTest = errors.New("test error")
+
+var member = MustIdentity("Org1MSP", "User1@org1.example.com", map[string]string{"hf.Type": "client"})
+
+func TestReadsDoNotSeeOwnWrites(t *testing.T) {
+	stub := NewStub("mychannel")
+
+	err := stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		err := ctx.GetStub().PutState("key", []byte("value"))
+		if err != nil {
+			return err
+		}
+		value, err := ctx.GetStub().GetState("key")
+		if value != nil {
+			t.Errorf("the write is visible in the transaction: %s", value)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(stub.State("key")); got != "value" {
+		t.Errorf("committed value = %q, want %q", got, "value")
+	}
+}
+
+func TestFailedTransactionIsDiscarded(t *testing.T) {
+	stub := NewStub("mychannel")
+
+	err := stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		_ = ctx.GetStub().PutState("key", []byte("value"))
+		_ = ctx.GetStub().SetEvent("Event", nil)
+		return errTest
+	})
+	if err != errTest {
+		t.Fatalf("err = %v, want %v", err, errTest)
+	}
+	if stub.State("key") != nil || stub.LastEvent() != nil {
+		t.Error("the writes of a failed transaction were committed")
+	}
+
+	err = stub.Evaluate(member, func(ctx contractapi.TransactionContextInterface) error {
+		return ctx.GetStub().PutState("key", []byte("value"))
+	})
+	if err != nil || stub.State("key") != nil {
+		t.Errorf("the writes of an evaluated transaction were committed, err = %v", err)
+	}
+}
+
+func TestHistoryAndTimestamps(t *testing.T) {
+	stub := NewStub("mychannel")
+	start := time.Date(2022, 11, 22, 10, 30, 0, 0, time.UTC)
+	stub.SetTime(start)
+
+	txIDs := make([]string, 0)
+	for _, value := range []string{"v1", "v2", ""} {
+		err := stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+			txIDs = append(txIDs, ctx.GetStub().GetTxID())
+			if value == "" {
+				return ctx.GetStub().DelState("key")
+			}
+			return ctx.GetStub().PutState("key", []byte(value))
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	iterator, err := stub.GetHistoryForKey("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// newest first
+	expected := []struct {
+		value    string
+		isDelete bool
+		seconds  int64
+	}{{"", true, start.Unix() + 2}, {"v2", false, start.Unix() + 1}, {"v1", false, start.Unix()}}
+	for i, want := range expected {
+		modification, err := iterator.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(modification.Value) != want.value || modification.IsDelete != want.isDelete ||
+			modification.Timestamp.Seconds != want.seconds || modification.TxId != txIDs[len(txIDs)-1-i] {
+			t.Errorf("modification %d = %v, want %v", i, modification, want)
+		}
+	}
+	if iterator.HasNext() {
+		t.Error("unexpected history entries")
+	}
+}
+
+func TestRangeAndPartialCompositeKeyScans(t *testing.T) {
+	stub := NewStub("mychannel")
+
+	err := stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		for _, attrs := range [][]string{{"a", "1"}, {"a", "2"}, {"b", "1"}} {
+			key, err := ctx.GetStub().CreateCompositeKey("IDX", attrs)
+			if err != nil {
+				return err
+			}
+			err = ctx.GetStub().PutState(key, []byte{0x00})
+			if err != nil {
+				return err
+			}
+		}
+		return ctx.GetStub().PutState("simple", []byte("value"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iterator, err := stub.GetStateByPartialCompositeKey("IDX", []string{"a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	attrs := make([]string, 0)
+	for iterator.HasNext() {
+		kv, _ := iterator.Next()
+		_, parts, err := stub.SplitCompositeKey(kv.Key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		attrs = append(attrs, strings.Join(parts, "/"))
+	}
+	if got := strings.Join(attrs, ","); got != "a/1,a/2" {
+		t.Errorf("partial key scan = %s, want a/1,a/2", got)
+	}
+
+	// range queries only return simple keys
+	iterator, err = stub.GetStateByRange("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv, _ := iterator.Next()
+	if kv.Key != "simple" || iterator.HasNext() {
+		t.Errorf("range scan returned %s and more = %v, want only the simple key", kv.Key, iterator.HasNext())
+	}
+	if _, err = stub.GetStateByRange("\x00IDX", ""); err == nil {
+		t.Error("range scan accepted a composite key")
+	}
+}
+
+func TestPaginatedQueryIsReadOnly(t *testing.T) {
+	stub := NewStub("mychannel")
+
+	err := stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		_, _, err := ctx.GetStub().GetQueryResultWithPagination(`{"selector":{}}`, 10, "")
+		if err != nil {
+			return err
+		}
+		return ctx.GetStub().PutState("key", []byte("value"))
+	})
+	if err == nil || stub.State("key") != nil {
+		t.Errorf("a transaction writing after a paginated query was committed, err = %v", err)
+	}
+}
+
+func TestClientIdentity(t *testing.T) {
+	stub := NewStub("mychannel")
+	admin := MustIdentity("Org1MSP", "Admin@org1.example.com", map[string]string{"hf.Type": "admin"})
+
+	err := stub.Evaluate(admin, func(ctx contractapi.TransactionContextInterface) error {
+		mspID, err := ctx.GetClientIdentity().GetMSPID()
+		if err != nil {
+			return err
+		}
+		if mspID != "Org1MSP" {
+			t.Errorf("msp id = %s, want Org1MSP", mspID)
+		}
+		id, err := ctx.GetClientIdentity().GetID()
+		if err != nil {
+			return err
+		}
+		decoded, _ := base64.StdEncoding.DecodeString(id)
+		if want := "x509::CN=Admin@org1.example.com,OU=client::CN=ca.org1.example.com"; string(decoded) != want {
+			t.Errorf("id = %s, want %s", decoded, want)
+		}
+		return ctx.GetClientIdentity().AssertAttributeValue("hf.Type", "admin")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransientAndPrivateData(t *testing.T) {
+	stub := NewStub("mychannel")
+	stub.SetTransient("secret", []byte("s3cr3t"))
+
+	err := stub.Submit(member, func(ctx contractapi.TransactionContextInterface) error {
+		transient, err := ctx.GetStub().GetTransient()
+		if err != nil {
+			return err
+		}
+		return ctx.GetStub().PutPrivateData("collection", "key", transient["secret"])
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(stub.PrivateState("collection", "key")); got != "s3cr3t" {
+		t.Errorf("private value = %q, want s3cr3t", got)
+	}
+	if hash, _ := stub.GetPrivateDataHash("collection", "key"); len(hash) != 32 {
+		t.Errorf("private data hash = %x", hash)
+	}
+
+	// the transient map only lasts one transaction
+	err = stub.Evaluate(member, func(ctx contractapi.TransactionContextInterface) error {
+		transient, err := ctx.GetStub().GetTransient()
+		if len(transient) != 0 {
+			t.Errorf("transient map = %v, want empty", transient)
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
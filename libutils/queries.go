@@ -2,7 +2,6 @@ package lib_utils
 
 import (
 	"encoding/json"
-	"fmt"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
 )
@@ -90,9 +89,9 @@ func GetQueryResultForQueryStringWithPagination(ctx contractapi.TransactionConte
 func GetState(stub shim.ChaincodeStubInterface, key string) ([]byte, error) {
 	assetByte, err := stub.GetState(key)
 	if err != nil {
-		return nil, fmt.Errorf(ErrorWorldState, key)
+		return nil, Errorf(ErrorWorldState, err)
 	} else if assetByte == nil {
-		return nil, fmt.Errorf(ErrorNotExistInState, key)
+		return nil, Errorf(ErrorNotExistInState, key)
 	}
 
 	return assetByte, nil
@@ -120,7 +119,7 @@ func ExistsAssetFromId(stub shim.ChaincodeStubInterface, objectType string, asse
 	}
 	asBytes, err := stub.GetState(compositeKey)
 	if err != nil {
-		return compositeKey, responseKey, nil, fmt.Errorf("failed to get asset in the world state: %v", err)
+		return compositeKey, responseKey, nil, Errorf(ErrorWorldState, err)
 	}
 	if asBytes == nil {
 		return compositeKey, responseKey, nil, nil
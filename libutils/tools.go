@@ -14,13 +14,13 @@ import (
 )
 
 func GetTimestampRFC3339(timestamp *timestamp.Timestamp) string {
-	tm := time.Unix(timestamp.Seconds, int64(timestamp.Nanos))
+	tm := time.Unix(timestamp.Seconds, int64(timestamp.Nanos)).UTC()
 	return tm.Format(time.RFC3339)
 }
 
 func UnknownTransactionHandler(ctx contractapi.TransactionContextInterface) error {
 	fcn, args := ctx.GetStub().GetFunctionAndParameters()
-	return fmt.Errorf("invalid function %s passed with args %v", fcn, args)
+	return Errorf(ErrorInvalidFunction, fcn, args)
 }
 
 // GenerateBytesUUID returns a UUID based on RFC 4122 returning the generated bytes
@@ -102,13 +102,13 @@ func ValidateID(codAsset, iD string) error {
 
 	// check iD length
 	if lID != lengthID {
-		return fmt.Errorf("invalid id")
+		return Errorf(ErrorInvalidID, iD, codAsset)
 	}
 
 	COD := iD[0:lCod]
 
 	if strings.Compare(COD, codAsset) != 0 {
-		return fmt.Errorf("invalid id")
+		return Errorf(ErrorInvalidID, iD, codAsset)
 	}
 
 	return nil
@@ -131,7 +131,7 @@ func CompositeKeyFromID(stub shim.ChaincodeStubInterface, objectType string, ass
 	if err != nil {
 		return "", nil, err
 	} else if compositeKey == "" {
-		return "", nil, fmt.Errorf("error creating compound key for: %v", assetID)
+		return "", nil, Errorf(ErrorCompositeKey, assetID)
 	}
 
 	return compositeKey, responseKey, nil
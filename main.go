@@ -3,26 +3,32 @@ package main
 import (
 	"academic_certificates/contracts/certificate"
 	"academic_certificates/contracts/common"
+	"academic_certificates/contracts/graduate"
+	"academic_certificates/contracts/institution"
+	"academic_certificates/contracts/program"
+	"academic_certificates/contracts/signatory"
+	"academic_certificates/contracts/verification"
 	lus "academic_certificates/libutils"
+	"flag"
 	"fmt"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
-	"io/ioutil"
 	"log"
 	"os"
-	"strconv"
+	"time"
 )
 
-type serverConfig struct {
-	CCID    string
-	Address string
-}
-
 func main() {
-	// See chaincode.env.example
-	config := serverConfig{
-		CCID:    os.Getenv("CHAINCODE_ID"),
-		Address: os.Getenv("CHAINCODE_SERVER_ADDRESS"),
+	// See chaincode.env, the settings can also be read from such a file with -env-file
+	config, err := loadConfig(os.Args[1:], os.LookupEnv, time.Now())
+	if err == flag.ErrHelp {
+		return
+	} else if err != nil {
+		log.Fatal(err)
+	}
+	config.report(os.Stdout)
+	if config.CheckOnly {
+		return
 	}
 
 	contractCommon := new(common.ContractCommon)
@@ -35,7 +41,32 @@ func main() {
 	contractCert.Info.Version = "0.0.1"
 	contractCert.UnknownTransaction = lus.UnknownTransactionHandler
 
-	chaincode, err := contractapi.NewChaincode(contractCommon, contractCert)
+	contractInstitution := new(institution.ContractInstitution)
+	contractInstitution.Name = lus.ContractNameInstitution
+	contractInstitution.Info.Version = "0.0.1"
+	contractInstitution.UnknownTransaction = lus.UnknownTransactionHandler
+
+	contractSignatory := new(signatory.ContractSignatory)
+	contractSignatory.Name = lus.ContractNameSignatory
+	contractSignatory.Info.Version = "0.0.1"
+	contractSignatory.UnknownTransaction = lus.UnknownTransactionHandler
+
+	contractProgram := new(program.ContractProgram)
+	contractProgram.Name = lus.ContractNameProgram
+	contractProgram.Info.Version = "0.0.1"
+	contractProgram.UnknownTransaction = lus.UnknownTransactionHandler
+
+	contractGraduate := new(graduate.ContractGraduate)
+	contractGraduate.Name = lus.ContractNameGraduate
+	contractGraduate.Info.Version = "0.0.1"
+	contractGraduate.UnknownTransaction = lus.UnknownTransactionHandler
+
+	contractVerification := new(verification.ContractVerification)
+	contractVerification.Name = lus.ContractNameVerification
+	contractVerification.Info.Version = "0.0.1"
+	contractVerification.UnknownTransaction = lus.UnknownTransactionHandler
+
+	chaincode, err := contractapi.NewChaincode(contractCommon, contractCert, contractInstitution, contractSignatory, contractProgram, contractGraduate, contractVerification)
 
 	if err != nil {
 		panic(fmt.Sprintf("Error creating chaincode. %s", err.Error()))
@@ -48,8 +79,8 @@ func main() {
 	server := &shim.ChaincodeServer{
 		CCID:     config.CCID,
 		Address:  config.Address,
-		CC:       chaincode,
-		TLSProps: getTLSProperties(),
+		CC:       lus.NewLocalizedChaincode(chaincode),
+		TLSProps: config.TLS,
 	}
 
 	fmt.Println("starting the chaincode on address: ", config.Address)
@@ -58,59 +89,3 @@ func main() {
 		log.Panicf("error starting asset-transfer-basic chaincode: %s", err)
 	}
 }
-
-func getTLSProperties() shim.TLSProperties {
-	// Check if chaincode is TLS enabled
-	tlsDisabledStr := getEnvOrDefault("CHAINCODE_TLS_DISABLED", "true")
-	key := getEnvOrDefault("CHAINCODE_TLS_KEY", "")
-	cert := getEnvOrDefault("CHAINCODE_TLS_CERT", "")
-	clientCACert := getEnvOrDefault("CHAINCODE_CLIENT_CA_CERT", "")
-
-	// convert tlsDisabledStr to boolean
-	tlsDisabled := getBoolOrDefault(tlsDisabledStr, false)
-	var keyBytes, certBytes, clientCACertBytes []byte
-	var err error
-
-	if !tlsDisabled {
-		keyBytes, err = ioutil.ReadFile(key)
-		if err != nil {
-			log.Panicf("error while reading the crypto file: %s", err)
-		}
-		certBytes, err = ioutil.ReadFile(cert)
-		if err != nil {
-			log.Panicf("error while reading the crypto file: %s", err)
-		}
-	}
-	// Did not request for the peer cert verification
-	if clientCACert != "" {
-		clientCACertBytes, err = ioutil.ReadFile(clientCACert)
-		if err != nil {
-			log.Panicf("error while reading the crypto file: %s", err)
-		}
-	}
-
-	return shim.TLSProperties{
-		Disabled:      tlsDisabled,
-		Key:           keyBytes,
-		Cert:          certBytes,
-		ClientCACerts: clientCACertBytes,
-	}
-}
-
-func getEnvOrDefault(env, defaultVal string) string {
-	value, ok := os.LookupEnv(env)
-	if !ok {
-		value = defaultVal
-	}
-	return value
-}
-
-// Note that the method returns default value if the string
-// cannot be parsed!
-func getBoolOrDefault(value string, defaultVal bool) bool {
-	parsed, err := strconv.ParseBool(value)
-	if err != nil {
-		return defaultVal
-	}
-	return parsed
-}